@@ -0,0 +1,416 @@
+package credis
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+	"time"
+)
+
+// Bit offsets address at most MAX_STRING_LENGTH bytes
+const MAX_BIT_OFFSET = MAX_STRING_LENGTH*8 - 1
+
+// BitRange is the optional `start end [BYTE|BIT]` range of BITCOUNT and
+// BITPOS. End is nil when only start was given, Bit is set when the bounds
+// are bit rather than byte offsets
+type BitRange struct {
+	Start *int64
+	End   *int64
+	Bit   bool
+}
+
+// bounds resolves r against a value of length bytes to the first and last
+// bit it covers. ok is false for an empty range
+func (r BitRange) bounds(length int) (first int64, last int64, ok bool) {
+	total := int64(length)
+	if r.Bit {
+		total *= 8
+	}
+	start, end := int64(0), total-1
+	if r.Start != nil {
+		start = *r.Start
+	}
+	if r.End != nil {
+		end = *r.End
+	}
+	if start < 0 {
+		start = max(total+start, 0)
+	}
+	if end < 0 {
+		end = max(total+end, 0)
+	}
+	end = min(end, total-1)
+	if start > end || total == 0 {
+		return 0, 0, false
+	}
+	if r.Bit {
+		return start, end, true
+	}
+	return start * 8, end*8 + 7, true
+}
+
+const (
+	BITOP_AND  = "AND"
+	BITOP_OR   = "OR"
+	BITOP_XOR  = "XOR"
+	BITOP_NOT  = "NOT"
+	BITOP_DIFF = "DIFF"
+)
+
+func bitAt(data []byte, offset int64) int {
+	if offset/8 >= int64(len(data)) {
+		return 0
+	}
+	return int(data[offset/8]>>(7-offset%8)) & 1
+}
+
+// lookupBytes returns the string at key, nil when key does not exist.
+// Caller must hold the lock
+func (s *store) lookupBytes(key string) ([]byte, error) {
+	val, err := s.ks.lookupType(key, STRING_TYPE, time.Now())
+	if val == nil || err != nil {
+		return nil, err
+	}
+	return val.data.([]byte), nil
+}
+
+// SetBit sets the bit at offset and returns its previous value. The string
+// grows with zero bytes to reach offset
+func (s *store) SetBit(key string, offset int64, bit int) (int, error) {
+	s.ks.mu.Lock()
+	defer s.ks.mu.Unlock()
+	val, err := s.ks.lookupType(key, STRING_TYPE, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	var data []byte
+	if val != nil {
+		data = val.data.([]byte)
+	}
+	data = grow(data, int(offset/8)+1)
+	old := bitAt(data, offset)
+	mask := byte(1) << (7 - offset%8)
+	if bit == 1 {
+		data[offset/8] |= mask
+	} else {
+		data[offset/8] &^= mask
+	}
+	if val == nil {
+		s.ks.set(key, STRING_TYPE, data, nil)
+	} else {
+		val.data = data
+	}
+	return old, nil
+}
+
+func (s *store) GetBit(key string, offset int64) (int, error) {
+	s.ks.mu.RLock()
+	defer s.ks.mu.RUnlock()
+	data, err := s.lookupBytes(key)
+	if err != nil {
+		return 0, err
+	}
+	return bitAt(data, offset), nil
+}
+
+func (s *store) BitCount(key string, r BitRange) (int, error) {
+	s.ks.mu.RLock()
+	defer s.ks.mu.RUnlock()
+	data, err := s.lookupBytes(key)
+	if err != nil {
+		return 0, err
+	}
+	first, last, ok := r.bounds(len(data))
+	if !ok {
+		return 0, nil
+	}
+	count := 0
+	// Partial bytes at both ends, whole bytes in between
+	for first <= last && first%8 != 0 {
+		count += bitAt(data, first)
+		first++
+	}
+	for last >= first && last%8 != 7 {
+		count += bitAt(data, last)
+		last--
+	}
+	for i := first / 8; i <= last/8 && first <= last; i++ {
+		count += bits.OnesCount8(data[i])
+	}
+	return count, nil
+}
+
+// BitPos returns the position of the first bit set to bit within r, or -1.
+// Looking for a clear bit without an explicit end finds the first bit past
+// the value, as the string is considered padded with zeros
+func (s *store) BitPos(key string, bit int, r BitRange) (int64, error) {
+	s.ks.mu.RLock()
+	defer s.ks.mu.RUnlock()
+	data, err := s.lookupBytes(key)
+	if err != nil {
+		return 0, err
+	}
+	if data == nil {
+		if bit == 1 {
+			return -1, nil
+		}
+		return 0, nil
+	}
+	first, last, ok := r.bounds(len(data))
+	if !ok {
+		return -1, nil
+	}
+	// Bytes that can not contain bit are skipped whole
+	skip := byte(0)
+	if bit == 0 {
+		skip = 0xFF
+	}
+	for pos := first; pos <= last; {
+		if pos%8 == 0 && pos+7 <= last && data[pos/8] == skip {
+			pos += 8
+			continue
+		}
+		if bitAt(data, pos) == bit {
+			return pos, nil
+		}
+		pos++
+	}
+	if bit == 0 && r.End == nil {
+		return last + 1, nil
+	}
+	return -1, nil
+}
+
+// BitOp stores the result of op over keys at dest and returns its length.
+// Missing keys count as strings of zero bytes, dest is deleted when the
+// result is empty
+func (s *store) BitOp(op string, dest string, keys []string) (int, error) {
+	s.ks.mu.Lock()
+	defer s.ks.mu.Unlock()
+	sources := make([][]byte, len(keys))
+	length := 0
+	for i, key := range keys {
+		data, err := s.lookupBytes(key)
+		if err != nil {
+			return 0, err
+		}
+		sources[i] = data
+		length = max(length, len(data))
+	}
+	if length == 0 {
+		s.ks.remove(dest)
+		return 0, nil
+	}
+	result := make([]byte, length)
+	copy(result, sources[0])
+	switch op {
+	case BITOP_NOT:
+		for i := range result {
+			result[i] = ^result[i]
+		}
+	case BITOP_DIFF:
+		// Bits of the first key set in none of the others
+		others := make([]byte, length)
+		for _, src := range sources[1:] {
+			for i, b := range src {
+				others[i] |= b
+			}
+		}
+		for i := range result {
+			result[i] &^= others[i]
+		}
+	default:
+		for _, src := range sources[1:] {
+			for i := range result {
+				var b byte
+				if i < len(src) {
+					b = src[i]
+				}
+				switch op {
+				case BITOP_AND:
+					result[i] &= b
+				case BITOP_OR:
+					result[i] |= b
+				case BITOP_XOR:
+					result[i] ^= b
+				default:
+					return 0, fmt.Errorf("ERR unsupported BITOP operation %v", op)
+				}
+			}
+		}
+	}
+	s.ks.set(dest, STRING_TYPE, result, nil)
+	return length, nil
+}
+
+const (
+	BITFIELD_GET    = "get"
+	BITFIELD_SET    = "set"
+	BITFIELD_INCRBY = "incrby"
+)
+
+const (
+	BITFIELD_OVERFLOW_WRAP = "wrap"
+	BITFIELD_OVERFLOW_SAT  = "sat"
+	BITFIELD_OVERFLOW_FAIL = "fail"
+)
+
+// BitFieldOp is a GET, SET or INCRBY subcommand of BITFIELD on the field of
+// Bits bits starting at Offset, with the OVERFLOW mode in effect for it
+type BitFieldOp struct {
+	Op       string
+	Signed   bool
+	Bits     int
+	Offset   int64
+	Value    int64
+	Overflow string
+}
+
+// getField reads the bits bits at offset as an unsigned integer, bits past
+// the end of data being 0
+func getField(data []byte, offset int64, bits int) uint64 {
+	var field uint64
+	for i := range int64(bits) {
+		field = field<<1 | uint64(bitAt(data, offset+i))
+	}
+	return field
+}
+
+// setField writes the lowest bits bits of field at offset. data must be
+// long enough to hold them
+func setField(data []byte, offset int64, bits int, field uint64) {
+	for i := int64(bits) - 1; i >= 0; i-- {
+		mask := byte(1) << (7 - (offset+i)%8)
+		if field&1 == 1 {
+			data[(offset+i)/8] |= mask
+		} else {
+			data[(offset+i)/8] &^= mask
+		}
+		field >>= 1
+	}
+}
+
+// signExtend interprets the lowest bits bits of field as a two's complement
+// integer
+func signExtend(field uint64, bits int) int64 {
+	return int64(field<<(64-bits)) >> (64 - bits)
+}
+
+// unsignedOverflow adds incr to value for a field of bits bits, which is at
+// most 63. ok is false when the result overflows and overflow is FAIL
+func unsignedOverflow(value uint64, incr int64, bits int, overflow string) (result uint64, ok bool) {
+	limit := uint64(1)<<bits - 1
+	maxIncr, minIncr := int64(limit-value), -int64(value)
+	switch {
+	case value > limit || incr > 0 && incr > maxIncr:
+		if overflow == BITFIELD_OVERFLOW_SAT {
+			return limit, true
+		}
+	case incr < 0 && incr < minIncr:
+		if overflow == BITFIELD_OVERFLOW_SAT {
+			return 0, true
+		}
+	default:
+		return value + uint64(incr), true
+	}
+	if overflow == BITFIELD_OVERFLOW_FAIL {
+		return 0, false
+	}
+	return (value + uint64(incr)) & limit, true
+}
+
+// signedOverflow adds incr to value for a field of bits bits. ok is false
+// when the result overflows and overflow is FAIL
+func signedOverflow(value int64, incr int64, bits int, overflow string) (result int64, ok bool) {
+	limit := int64(math.MaxInt64)
+	if bits < 64 {
+		limit = int64(1)<<(bits-1) - 1
+	}
+	floor := -limit - 1
+	// Both wrap around for 64 bits fields, hence the sign checks below
+	maxIncr, minIncr := limit-value, floor-value
+	switch {
+	case value > limit || bits != 64 && incr > maxIncr || value >= 0 && incr > 0 && incr > maxIncr:
+		if overflow == BITFIELD_OVERFLOW_SAT {
+			return limit, true
+		}
+	case value < floor || bits != 64 && incr < minIncr || value < 0 && incr < 0 && incr < minIncr:
+		if overflow == BITFIELD_OVERFLOW_SAT {
+			return floor, true
+		}
+	default:
+		return value + incr, true
+	}
+	if overflow == BITFIELD_OVERFLOW_FAIL {
+		return 0, false
+	}
+	return signExtend(uint64(value)+uint64(incr), bits), true
+}
+
+// BitField runs ops in order and returns the reply of each: the value read
+// by GET, the previous value for SET and the new one for INCRBY. Replies
+// are nil for writes prevented by OVERFLOW FAIL. When there are writes the
+// string is created or grown to hold every field first
+func (s *store) BitField(key string, ops []BitFieldOp) ([]*int64, error) {
+	s.ks.mu.Lock()
+	defer s.ks.mu.Unlock()
+	val, err := s.ks.lookupType(key, STRING_TYPE, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	var data []byte
+	if val != nil {
+		data = val.data.([]byte)
+	}
+	size := 0
+	for _, op := range ops {
+		if op.Op != BITFIELD_GET {
+			size = max(size, int((op.Offset+int64(op.Bits)-1)/8)+1)
+		}
+	}
+	if size > 0 {
+		data = grow(data, size)
+		if val == nil {
+			s.ks.set(key, STRING_TYPE, data, nil)
+		} else {
+			val.data = data
+		}
+	}
+	replies := make([]*int64, len(ops))
+	for i, op := range ops {
+		field := getField(data, op.Offset, op.Bits)
+		current := int64(field)
+		if op.Signed {
+			current = signExtend(field, op.Bits)
+		}
+		if op.Op == BITFIELD_GET {
+			replies[i] = &current
+			continue
+		}
+		// SET checks the value to set for overflows as an increment of 0
+		value, incr := current, op.Value
+		if op.Op == BITFIELD_SET {
+			value, incr = op.Value, 0
+		}
+		var result int64
+		if op.Signed {
+			var ok bool
+			if result, ok = signedOverflow(value, incr, op.Bits, op.Overflow); !ok {
+				continue
+			}
+		} else {
+			unsigned, ok := unsignedOverflow(uint64(value), incr, op.Bits, op.Overflow)
+			if !ok {
+				continue
+			}
+			result = int64(unsigned)
+		}
+		setField(data, op.Offset, op.Bits, uint64(result))
+		if op.Op == BITFIELD_SET {
+			replies[i] = &current
+		} else {
+			replies[i] = &result
+		}
+	}
+	return replies, nil
+}
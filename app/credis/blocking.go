@@ -0,0 +1,176 @@
+package credis
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// BlockingSpecs are the specs of commands that wait for one of their keys
+// to get data when none has any, like BLPOP
+type BlockingSpecs interface {
+	Specs
+	// WaitKeys returns the keys waited on, in the order they are tried
+	WaitKeys() []string
+	// Timeout returns the seconds to wait for, nil to wait forever
+	Timeout() *float64
+	// serve replies using the data at key, nil when key has none yet
+	serve(e *executor, key string) Response
+	// timedOut is the reply sent once the timeout fires
+	timedOut() []byte
+}
+
+// readingSpecs are blocking specs that read the data of their keys without
+// taking it, like XREAD, so the clients blocked before them do not keep them
+// from being served
+type readingSpecs interface {
+	BlockingSpecs
+	readsOnly()
+}
+
+// blockedClient is a request parked in the waiting area until one of its
+// keys gets data or its timeout fires
+type blockedClient struct {
+	req   Request
+	spec  BlockingSpecs
+	timer *time.Timer
+	// Set once the client got its reply
+	done bool
+}
+
+// WaitingArea holds the clients of a server blocked on keys. Writes signal
+// the keys they add data to on the keyspace, and the clients blocked on
+// them are served once the command is done
+type WaitingArea struct {
+	ks *keyspace
+	// Clients blocked on each key, first come first served
+	queue map[string][]*blockedClient
+	mu    sync.Mutex
+}
+
+func NewWaitingArea(ks *keyspace) *WaitingArea {
+	return &WaitingArea{
+		ks:    ks,
+		queue: make(map[string][]*blockedClient),
+	}
+}
+
+// block replies from the first of the spec's keys having data, or parks the
+// request and returns nil. Keys that already have clients waiting are left
+// to them, they get served once their keys are handled, unless the spec only
+// reads them
+func block(e *executor, req Request, spec BlockingSpecs) Response {
+	wa := e.store.Waiting
+	wa.mu.Lock()
+	defer wa.mu.Unlock()
+	_, readsOnly := spec.(readingSpecs)
+	for _, key := range spec.WaitKeys() {
+		if len(wa.queue[key]) > 0 && !readsOnly {
+			continue
+		}
+		if res := spec.serve(e, key); res != nil {
+			return res
+		}
+	}
+	select {
+	case <-req.Ctx().Done():
+		// Nobody waits for the reply, as for commands queued by MULTI, so
+		// the request times out right away
+		return &response{data: spec.timedOut(), propagate: []Token{}}
+	default:
+	}
+	wa.park(req, spec)
+	return nil
+}
+
+// park queues the request on each of its keys and starts its timeout. The
+// request leaves the waiting area once its context is done, as when its
+// client disconnects. Caller must hold the lock
+func (wa *WaitingArea) park(req Request, spec BlockingSpecs) {
+	c := &blockedClient{
+		req:  req,
+		spec: spec,
+	}
+	keys := []string{}
+	for _, key := range spec.WaitKeys() {
+		if !slices.Contains(keys, key) {
+			keys = append(keys, key)
+			wa.queue[key] = append(wa.queue[key], c)
+		}
+	}
+	if timeout := spec.Timeout(); timeout != nil {
+		c.timer = time.AfterFunc(time.Duration(*timeout*float64(time.Second)), func() {
+			wa.expire(c)
+		})
+	}
+	go func() {
+		<-req.Ctx().Done()
+		wa.expire(c)
+	}()
+}
+
+// unpark removes the client from the queues of all its keys and stops its
+// timeout. Caller must hold the lock
+func (wa *WaitingArea) unpark(c *blockedClient) {
+	c.done = true
+	if c.timer != nil {
+		c.timer.Stop()
+	}
+	for _, key := range c.spec.WaitKeys() {
+		queue := slices.DeleteFunc(wa.queue[key], func(other *blockedClient) bool {
+			return other == c
+		})
+		if len(queue) == 0 {
+			delete(wa.queue, key)
+		} else {
+			wa.queue[key] = queue
+		}
+	}
+}
+
+// expire replies to a client whose timeout fired or whose request was
+// cancelled before it got served
+func (wa *WaitingArea) expire(c *blockedClient) {
+	wa.mu.Lock()
+	if c.done {
+		wa.mu.Unlock()
+		return
+	}
+	wa.unpark(c)
+	wa.mu.Unlock()
+	c.req.Client().Receive() <- &response{data: c.spec.timedOut()}
+}
+
+// servedClient is a blocked client along with the reply it got
+type servedClient struct {
+	req Request
+	res Response
+}
+
+// serveReady hands the data of the keys signaled as ready to the clients
+// blocked on them, in the order they arrived. Serving a client can signal
+// more keys, as BLMOVE does, so this goes on until none is left
+func (wa *WaitingArea) serveReady(e *executor) []servedClient {
+	wa.mu.Lock()
+	defer wa.mu.Unlock()
+	served := []servedClient{}
+	for ready := wa.ks.takeReady(); len(ready) > 0; ready = wa.ks.takeReady() {
+		for _, key := range ready {
+			for _, c := range slices.Clone(wa.queue[key]) {
+				if c.done {
+					// Served from another key by a previous client
+					continue
+				}
+				res := c.spec.serve(e, key)
+				if res == nil {
+					// Readers may wait for entries newer than the ones the
+					// key got, the clients after them can still be served
+					continue
+				}
+				wa.unpark(c)
+				served = append(served, servedClient{req: c.req, res: res})
+			}
+		}
+	}
+	return served
+}
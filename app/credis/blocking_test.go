@@ -0,0 +1,150 @@
+package credis
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+// testClient is a client that only receives the replies of blocked requests
+type testClient struct {
+	Client
+	receive chan Response
+}
+
+func (c *testClient) Receive() chan Response {
+	return c.receive
+}
+
+func newBlockingExec() *executor {
+	ks := NewKeyspace()
+	return NewExec(dataStores{
+		Keyspace: ks,
+		List:     NewListStore[string](ks),
+		Waiting:  NewWaitingArea(ks),
+	}, nil, nil).(*executor)
+}
+
+// blpop runs BLPOP on keys, the request being cancelled along with ctx
+func blpop(e *executor, ctx context.Context, timeout *float64, keys ...string) (Request, Response) {
+	req := NewRequest(&testClient{receive: make(chan Response, 1)}, ctx)
+	req.SetSpecs(&BLPOPSpecs{Keys: keys, Lifetime: timeout})
+	return req, e.Exec(req)
+}
+
+func popped(key string, value string) string {
+	return string(NewEncoder().Array(bulkStrings([]string{key, value})...))
+}
+
+// Clients are served in the order they blocked, whatever their other keys
+func TestBlockingServeOrder(t *testing.T) {
+	tests := []struct {
+		name    string
+		blocked [][]string
+		// Key and element pushed, in order
+		pushes [][2]string
+		// Popped element by blocked client, empty when still blocked
+		want []string
+	}{
+		{
+			name:    "one key",
+			blocked: [][]string{{"a"}, {"a"}, {"a"}},
+			pushes:  [][2]string{{"a", "1"}, {"a", "2"}},
+			want:    []string{popped("a", "1"), popped("a", "2"), ""},
+		},
+		{
+			name:    "several keys",
+			blocked: [][]string{{"a", "b"}, {"b"}, {"b", "a"}},
+			pushes:  [][2]string{{"b", "1"}, {"a", "2"}},
+			want:    []string{popped("b", "1"), "", popped("a", "2")},
+		},
+		{
+			name:    "served once",
+			blocked: [][]string{{"a", "b"}, {"b"}},
+			pushes:  [][2]string{{"a", "1"}, {"b", "2"}},
+			want:    []string{popped("a", "1"), popped("b", "2")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newBlockingExec()
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			reqs := []Request{}
+			for _, keys := range tt.blocked {
+				req, res := blpop(e, ctx, nil, keys...)
+				if res != nil {
+					t.Fatalf("BLPOP %v did not block: %q", keys, res.Data())
+				}
+				reqs = append(reqs, req)
+			}
+			for _, kv := range tt.pushes {
+				e.store.List.Push(kv[0], []string{kv[1]}, false)
+			}
+			got := make([]string, len(reqs))
+			for _, served := range e.serveReady() {
+				got[slices.Index(reqs, served.req)] = string(served.res.Data())
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// A key with clients blocked on it is left to them
+func TestBlockingQueuedFirst(t *testing.T) {
+	e := newBlockingExec()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	first, _ := blpop(e, ctx, nil, "a")
+	e.store.List.Push("a", []string{"1"}, false)
+	if _, res := blpop(e, ctx, nil, "a"); res != nil {
+		t.Fatalf("BLPOP skipped the blocked client: %q", res.Data())
+	}
+	served := e.serveReady()
+	if len(served) != 1 || served[0].req != first {
+		t.Fatalf("served %d clients, want the first one", len(served))
+	}
+}
+
+// Clients leave the waiting area when their timeout fires or they go away,
+// with a null reply, and are no longer served
+func TestBlockingRelease(t *testing.T) {
+	timeout := 0.01
+	tests := []struct {
+		name    string
+		timeout *float64
+		cancel  bool
+	}{
+		{"timeout", &timeout, false},
+		{"disconnect", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newBlockingExec()
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			req, _ := blpop(e, ctx, tt.timeout, "a", "b")
+			if tt.cancel {
+				cancel()
+			}
+			select {
+			case res := <-req.Client().Receive():
+				if want := NewEncoder().NullArray(); string(res.Data()) != string(want) {
+					t.Fatalf("got %q, want %q", res.Data(), want)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("client was not released")
+			}
+			e.store.List.Push("a", []string{"1"}, false)
+			if served := e.serveReady(); len(served) != 0 {
+				t.Fatalf("served %d released clients", len(served))
+			}
+			if n, _ := e.store.List.Len("a"); n != 1 {
+				t.Fatalf("released client popped from the list")
+			}
+		})
+	}
+}
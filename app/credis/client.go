@@ -18,6 +18,14 @@ type response struct {
 	data      []byte
 	artifacts any // This will contain other data depending on command
 	isError   bool
+	// Command sent to replicas in place of the request, used when replaying
+	// the request as is would not be deterministic. Empty when the request
+	// changed nothing
+	propagate []Token
+	// Commands sent to replicas in place of the request when it amounts to
+	// several writes, like a DEL followed by a ZADD. Takes precedence over
+	// propagate
+	propagateAll [][]Token
 }
 
 func (r *response) Data() []byte {
@@ -28,9 +36,19 @@ func (r *response) Artifacts() any {
 	return r.artifacts
 }
 
+func (r *response) Propagate() []Token {
+	return r.propagate
+}
+
+func (r *response) PropagateAll() [][]Token {
+	return r.propagateAll
+}
+
 type Response interface {
 	Data() []byte
 	Artifacts() any
+	Propagate() []Token
+	PropagateAll() [][]Token
 }
 
 type request struct {
@@ -110,12 +128,12 @@ type client struct {
 		Watching bool
 		Dirty    bool
 	}
-	sortedSet SortedSet
 }
 
 type Client interface {
 	net.Conn
 	TryParse() (Token, int, error)
+	WaitInput() error
 	ProcessRDB() error
 	Id() string
 	Send() chan<- Request
@@ -130,8 +148,7 @@ type Client interface {
 	CurrentUser() string
 	IsAuthenticated() bool
 	Authenticate(user string, password string) bool
-	SortedSet() SortedSet
-	Watch(cmd string) *CmdNotifier
+	Watch(cmd string)
 	IsWatching(cmd string) bool
 	MakeDirty(cmd string)
 	IsDirty() bool
@@ -149,9 +166,9 @@ func NewClient(conn net.Conn, srv Server) Client {
 		receive:          make(chan Response),
 		subCancelMapping: make(map[string]func()),
 		exec:             srv.Hub().Executor(),
+		processed:        &atomic.Uint64{},
 		currentUser:      DefaultAuth().user,
 		isAuthenticated:  !srv.Auth(DefaultAuth().user).PassRequired(),
-		sortedSet:        NewSortedSet(),
 		watchList: make(map[string]struct {
 			Watching bool
 			Dirty    bool
@@ -163,10 +180,6 @@ func (c *client) Srv() Server {
 	return c.srv
 }
 
-func (c *client) SortedSet() SortedSet {
-	return c.sortedSet
-}
-
 func (c *client) IsAuthenticated() bool {
 	return c.isAuthenticated
 }
@@ -180,15 +193,16 @@ func (c *client) CancelSub(channelId string) {
 	}
 }
 
-func (c *client) Watch(cmd string) *CmdNotifier {
+func (c *client) Watch(cmd string) {
 	c.mu.Lock()
-	defer c.mu.Unlock()
 	c.watchList[cmd] = struct {
 		Watching bool
 		Dirty    bool
 	}{true, false}
+	c.mu.Unlock()
 	fmt.Printf("Client %v: Key %v is in watchlist\n", c.id, cmd)
-	return c.srv.Hub().Watcher().Add(c.id)
+	// Not under the lock, the watcher takes it to make keys dirty
+	c.srv.Hub().Watcher().Add(c)
 }
 
 func (c *client) IsWatching(cmd string) bool {
@@ -219,9 +233,9 @@ func (c *client) IsDirty() bool {
 }
 
 func (c *client) TerminateWatcher() {
+	c.srv.Hub().Watcher().Cancel(c.id)
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	c.srv.Hub().Watcher().Cancel(c.id)
 	c.watchList = make(map[string]struct {
 		Watching bool
 		Dirty    bool
@@ -263,6 +277,10 @@ func (c *client) TryParse() (Token, int, error) {
 	return token, len, err
 }
 
+func (c *client) WaitInput() error {
+	return c.parser.Wait()
+}
+
 func (c *client) ProcessRDB() error {
 	c.parser.ProcessRDB()
 	return c.parser.Error()
@@ -307,9 +325,32 @@ func (c *client) Authenticate(user string, password string) bool {
 	return true
 }
 
+// awaitBlocked waits for the reply of a request that may block, watching the
+// connection meanwhile. The request is cancelled when the client disconnects
+// so that it leaves the waiting area
+func awaitBlocked(client Client, cancel context.CancelFunc) (res Response, disconnected bool) {
+	input := make(chan error, 1)
+	go func() {
+		input <- client.WaitInput()
+	}()
+	select {
+	case res = <-client.Receive():
+	case err := <-input:
+		if disconnected = err != nil; disconnected {
+			cancel()
+		}
+		// Either way the reply comes, from the command or once cancelled
+		return <-client.Receive(), disconnected
+	}
+	// Stop watching before the connection is read again
+	client.SetReadDeadline(time.Now())
+	<-input
+	client.SetReadDeadline(time.Time{})
+	return res, false
+}
+
 func handle(client Client) {
 	clientCtx, clientCancel := context.WithCancel(context.Background())
-	cmdNotifier := false
 	isAuthenticated := client.IsAuthenticated()
 	user := client.CurrentUser()
 	for {
@@ -390,9 +431,7 @@ func handle(client Client) {
 			client.Write(NewEncoder().SimpleError(err.Error()))
 			continue
 		}
-		if len(tkns) > 1 {
-			args = append(args, tkns[1:]...)
-		}
+		req.SetArgs(args...)
 
 		if !client.Srv().SubManager().IsAllowed(cmd, client.Id()) {
 			sendAndCancel(&response{
@@ -401,30 +440,10 @@ func handle(client Client) {
 			continue
 		}
 
-		send := client.Send()
 		if client.GetTX().IsMulti() && !slices.Contains([]string{MULTI, DISCARD}, cmd) {
 			sendAndCancel(&response{
 				data: client.GetTX().Enqueue(req),
 			})
-		} else if spec, ok := specs.(*BLPOPSpecs); ok && spec.Lifetime != nil {
-			var res Response
-			deadline := time.Duration(*spec.Lifetime * float64(time.Second))
-			timer := time.NewTimer(deadline)
-			go func() {
-				select {
-				case send <- req:
-				case <-req.Ctx().Done():
-				}
-			}()
-			select {
-			case <-timer.C:
-				res = &response{
-					data: NewEncoder().NullArray(),
-				}
-			case res = <-client.Receive():
-			}
-			timer.Stop()
-			sendAndCancel(res)
 		} else if spec, ok := specs.(*WAITSpecs); ok {
 			timeout := spec.Timeout
 			var res Response
@@ -455,6 +474,13 @@ func handle(client Client) {
 				}
 			}
 			sendAndCancel(res)
+		} else if _, ok := specs.(BlockingSpecs); ok {
+			client.Send() <- req
+			res, disconnected := awaitBlocked(client, cancel)
+			sendAndCancel(res)
+			if disconnected {
+				break
+			}
 		} else {
 			client.Send() <- req
 			res := <-client.Receive()
@@ -469,20 +495,14 @@ func handle(client Client) {
 					client.AddSub(sub.Channel, sub.Cancel)
 					go ListenForMsgs(clientCtx, sub, client)
 				}
-			case WATCH:
-				if notifier, ok := artifacts.(*CmdNotifier); ok && !cmdNotifier {
-					// TODO: Maybe convert to atomic???
-					cmdNotifier = true
-					go func() {
-						for key := range notifier.C {
-							client.MakeDirty(key)
-						}
-						cmdNotifier = false
-					}()
-				}
+			case PSYNC:
+				client.Srv().AddToReplicaGroup(client.Id(), client)
 			}
 		}
 	}
 	clientCancel()
 	client.TerminateWatcher()
+	if client.Srv().IsPartOfReplicaGroup(client.Id()) {
+		client.Srv().RemoveFromReplicaGroup(client.Id())
+	}
 }
@@ -1,9 +1,9 @@
 package credis
 
 import (
-	"bufio"
-	"bytes"
 	"fmt"
+	"math"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -59,16 +59,17 @@ func (s *CONFIGSpecs) Execute(e *executor, req Request) Response {
 }
 
 func (spec *GETSpecs) Execute(e *executor, req Request) Response {
-	val := e.store.KV.Get(spec.Key, spec.CurrentTime)
+	val, err := e.store.KV.Get(spec.Key, spec.CurrentTime)
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
+	}
+	if val == nil {
+		return &response{data: NewEncoder().BulkString(nil)}
+	}
 	switch val.Type {
 	case BULK_STRING, SIMPLE_STRING:
 		data := val.Literal.(string)
-		var enc []byte
-		if data == "" {
-			enc = NewEncoder().BulkString(nil)
-		} else {
-			enc = NewEncoder().BulkString(&data)
-		}
+		enc := NewEncoder().BulkString(&data)
 		if enc == nil {
 			return &response{data: NewEncoder().SimpleError("ERR encoding failed")}
 		}
@@ -79,46 +80,59 @@ func (spec *GETSpecs) Execute(e *executor, req Request) Response {
 	}
 }
 
+// incrBy adds delta to the integer at key. Replicas get the result as a SET
+// so they never drift
+func incrBy(e *executor, key string, delta int64) Response {
+	updated, err := e.store.KV.IncrBy(key, delta)
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
+	}
+	return &response{
+		data: NewEncoder().Integer(int(updated)),
+		propagate: []Token{
+			NewToken(BULK_STRING, SET),
+			NewToken(BULK_STRING, key),
+			NewToken(BULK_STRING, strconv.FormatInt(updated, 10)),
+			NewToken(BULK_STRING, "KEEPTTL"),
+		},
+	}
+}
+
 func (spec *INCRSpecs) Execute(e *executor, req Request) Response {
-	key := spec.Key
-	val := e.store.KV.Get(key, spec.CurrentTime)
+	return incrBy(e, spec.Key, 1)
+}
 
-	// Check if value is integer
-	switch val.Type {
-	case BULK_STRING, SIMPLE_STRING:
-		var updaredNum int
-		if val.Literal.(string) == "" {
-			// Value does not exists, create one
-			updaredNum = 1
-			value := NewToken(BULK_STRING, fmt.Sprintf("%v", 1))
-			e.store.KV.Set(key, value, nil)
-			if hasErr, data := EncodeError(e.store.KV.Error(), NewEncoder()); hasErr {
-				return &response{data: data}
-			}
-		} else {
-			num, err := strconv.ParseInt(val.Literal.(string), 10, 64)
-			if err != nil {
-				if hasErr, data := EncodeError(&ErrNotInteger{
-					data: num,
-				}, NewEncoder()); hasErr {
-					return &response{data: data}
-				}
-				return nil
-			}
-			updaredNum = int(num) + 1
-			updatedValue := NewToken(BULK_STRING, fmt.Sprintf("%v", updaredNum))
-			e.store.KV.Update(key, updatedValue)
-			if hasErr, data := EncodeError(e.store.KV.Error(), NewEncoder()); hasErr {
-				return &response{data: data}
-			}
-		}
-		enc := NewEncoder().Integer(updaredNum)
-		if enc == nil {
-			return &response{data: NewEncoder().SimpleError("ERR encoding failed")}
-		}
-		return &response{data: enc}
-	default:
-		return &response{data: NewEncoder().SimpleError(fmt.Sprintf("ERR unsupported value for command INCR: %v", val.Literal))}
+func (spec *INCRBYSpecs) Execute(e *executor, req Request) Response {
+	return incrBy(e, spec.Key, spec.Increment)
+}
+
+func (spec *DECRSpecs) Execute(e *executor, req Request) Response {
+	return incrBy(e, spec.Key, -1)
+}
+
+func (spec *DECRBYSpecs) Execute(e *executor, req Request) Response {
+	if spec.Decrement == math.MinInt64 {
+		return &response{data: NewEncoder().SimpleError("ERR decrement would overflow")}
+	}
+	return incrBy(e, spec.Key, -spec.Decrement)
+}
+
+func (spec *INCRBYFLOATSpecs) Execute(e *executor, req Request) Response {
+	if math.IsNaN(spec.Increment) || math.IsInf(spec.Increment, 0) {
+		return &response{data: NewEncoder().SimpleError((&ErrNaNOrInfinity{}).Error())}
+	}
+	updated, err := e.store.KV.IncrByFloat(spec.Key, spec.Increment)
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
+	}
+	return &response{
+		data: NewEncoder().BulkString(&updated),
+		propagate: []Token{
+			NewToken(BULK_STRING, SET),
+			NewToken(BULK_STRING, spec.Key),
+			NewToken(BULK_STRING, updated),
+			NewToken(BULK_STRING, "KEEPTTL"),
+		},
 	}
 }
 
@@ -129,6 +143,11 @@ func (spec *INFOSpecs) Execute(e *executor, req Request) Response {
 	for key, value := range sectionInfo {
 		fmt.Fprintf(&resp, "%v:%v\r\n", key, value)
 	}
+	if strings.EqualFold(section, "stats") {
+		stats := e.store.Keyspace.Stats()
+		fmt.Fprintf(&resp, "expired_keys:%v\r\n", stats.ExpiredKeys)
+		fmt.Fprintf(&resp, "expired_stale_perc:%.2f\r\n", stats.ExpiredStalePerc)
+	}
 	data := resp.String()
 	enc := NewEncoder().BulkString(&data)
 	if enc == nil {
@@ -138,24 +157,26 @@ func (spec *INFOSpecs) Execute(e *executor, req Request) Response {
 }
 
 func (spec *KEYSSpecs) Execute(e *executor, req Request) Response {
-	filter := spec.Filter
-	if filter == "*" {
-		keys := []Token{}
-		for k := range e.store.KV.Keys() {
-			keys = append(keys, NewToken(BULK_STRING, k))
-		}
-		return &response{data: NewEncoder().Array(keys...)}
-	} else {
-		return &response{data: NewEncoder().SimpleError(fmt.Sprintf("ERR unknown subcommand for KEYS: %v", filter))}
+	keys := []Token{}
+	for _, k := range e.store.Keyspace.Keys(spec.Filter, time.Now()) {
+		keys = append(keys, NewToken(BULK_STRING, k))
 	}
+	return &response{data: NewEncoder().Array(keys...)}
 }
 
 func (spec *LLENSpecs) Execute(e *executor, req Request) Response {
-	return &response{data: NewEncoder().Integer(e.store.List.Len(spec.Key))}
+	length, err := e.store.List.Len(spec.Key)
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
+	}
+	return &response{data: NewEncoder().Integer(length)}
 }
 
 func (spec *LRANGESpecs) Execute(e *executor, req Request) Response {
-	data := e.store.List.Get(spec.Key, spec.Start, spec.End)
+	data, err := e.store.List.Get(spec.Key, spec.Start, spec.End)
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
+	}
 	dataTokens := []Token{}
 	for _, el := range data {
 		dataTokens = append(dataTokens, NewToken(BULK_STRING, el))
@@ -176,7 +197,8 @@ func (spec *PSYNCSpecs) Execute(e *executor, req Request) Response {
 		data += " "
 		data += offset
 	}
-	_ = [88]uint8{
+	// Empty RDB file sent for the full resync
+	emptyRDB := [88]uint8{
 		0x52, 0x45, 0x44, 0x49, 0x53, 0x30, 0x30, 0x31, 0x31, 0xFA, 0x09, 0x72,
 		0x65, 0x64, 0x69, 0x73, 0x2D, 0x76, 0x65, 0x72, 0x05, 0x37, 0x2E, 0x32,
 		0x2E, 0x30, 0xFA, 0x0A, 0x72, 0x65, 0x64, 0x69, 0x73, 0x2D, 0x62, 0x69,
@@ -186,9 +208,11 @@ func (spec *PSYNCSpecs) Execute(e *executor, req Request) Response {
 		0x66, 0x2D, 0x62, 0x61, 0x73, 0x65, 0xC0, 0x00, 0xFF, 0xF0, 0x6E, 0x3B, 0xFE,
 		0xC0, 0xFF, 0x5A, 0xA2}
 
-	return &response{data: NewEncoder().SimpleString(data)}
-	// TODO: Fix verified replica logic
-	// e.verifiedReplica = true
+	res := NewEncoder().SimpleString(data)
+	res = append(res, fmt.Sprintf("%v%v\r\n", BULK_STRING, len(emptyRDB))...)
+	res = append(res, emptyRDB[:]...)
+	// Artifact marks the connection as a replica
+	return &response{data: res, artifacts: true}
 }
 
 func (spec *REPLCONFSpecs) Execute(e *executor, req Request) Response {
@@ -217,10 +241,11 @@ func (spec *REPLCONFSpecs) Execute(e *executor, req Request) Response {
 }
 
 func (spec *RPUSHSpecs) Execute(e *executor, req Request) Response {
-	go func() {
-		keyUpdatesChan <- spec.Key
-	}()
-	return &response{data: NewEncoder().Integer(e.store.List.Push(spec.Key, spec.Elements))}
+	length, err := e.store.List.Push(spec.Key, spec.Elements, false)
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
+	}
+	return &response{data: NewEncoder().Integer(length)}
 }
 
 func (s *MULTISpecs) Execute(e *executor, req Request) Response {
@@ -232,36 +257,83 @@ func (s *DISCARDSpecs) Execute(e *executor, req Request) Response {
 }
 
 func (spec *LPUSHSpecs) Execute(e *executor, req Request) Response {
-	go func() {
-		keyUpdatesChan <- spec.Key
-	}()
-	return &response{data: NewEncoder().Integer(e.store.List.Prepend(spec.Key, spec.Elements))}
+	length, err := e.store.List.Prepend(spec.Key, spec.Elements, false)
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
+	}
+	return &response{data: NewEncoder().Integer(length)}
 }
 
-func (spec *SETSpecs) Execute(e *executor, req Request) Response {
-	if spec.Px != nil {
-		exp := time.Now().Add(time.Duration(*spec.Px * uint64(time.Millisecond)))
-		e.store.KV.Set(spec.Key, spec.Value, &exp)
-	} else {
-		e.store.KV.Set(spec.Key, spec.Value, nil)
+// expiryOption resolves the EX, PX, EXAT and PXAT options of SET and
+// GETEX, nil when none is given
+func expiryOption(cmd string, ex, px, exat, pxat *int64) (*time.Time, error) {
+	var at time.Time
+	var err error
+	now := time.Now().UnixMilli()
+	switch {
+	case ex != nil:
+		at, err = expireTime(cmd, now, *ex, 1000)
+	case px != nil:
+		at, err = expireTime(cmd, now, *px, 1)
+	case exat != nil:
+		at, err = expireTime(cmd, 0, *exat, 1000)
+	case pxat != nil:
+		at, err = expireTime(cmd, 0, *pxat, 1)
+	default:
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
 	}
+	return &at, nil
+}
 
-	if e.store.KV.Error() != nil {
-		return &response{data: NewEncoder().SimpleError(fmt.Sprintf("ERR: %v", e.store.KV.Error()))}
+func (spec *SETSpecs) Execute(e *executor, req Request) Response {
+	exp, err := expiryOption(SET, spec.Ex, spec.Px, spec.Exat, spec.Pxat)
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
 	}
-	return &response{data: NewEncoder().Ok()}
+	old, written, err := e.store.KV.SetIf(spec.Key, spec.Value, exp, SetOptions{
+		NX:      spec.Nx,
+		XX:      spec.Xx,
+		KeepTTL: spec.KeepTtl,
+		Get:     spec.Get,
+	})
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
+	}
+	res := &response{}
+	switch {
+	case spec.Get && old != nil:
+		data := old.Literal.(string)
+		res.data = NewEncoder().BulkString(&data)
+	case spec.Get, !written:
+		res.data = NewEncoder().BulkString(nil)
+	default:
+		res.data = NewEncoder().Ok()
+	}
+	// Replicas get the outcome: an absolute expiry and no condition
+	res.propagate = []Token{}
+	if written {
+		res.propagate = []Token{
+			NewToken(BULK_STRING, SET),
+			NewToken(BULK_STRING, spec.Key),
+			spec.Value,
+		}
+		if exp != nil {
+			res.propagate = append(res.propagate,
+				NewToken(BULK_STRING, "PXAT"),
+				NewToken(BULK_STRING, strconv.FormatInt(exp.UnixMilli(), 10)),
+			)
+		} else if spec.KeepTtl {
+			res.propagate = append(res.propagate, NewToken(BULK_STRING, "KEEPTTL"))
+		}
+	}
+	return res
 }
 
 func (spec *TYPESpecs) Execute(e *executor, req Request) Response {
-	key := spec.Key
-	var data []byte
-	if e.store.Stream.IsStreamKey(key) {
-		data = NewEncoder().SimpleString("stream")
-	} else if e.store.KV.Get(key, spec.CurrentTime).Literal.(string) != "" {
-		data = NewEncoder().SimpleString("string")
-	} else {
-		data = NewEncoder().SimpleString("none")
-	}
+	data := NewEncoder().SimpleString(e.store.Keyspace.Type(spec.Key, spec.CurrentTime))
 	if data == nil {
 		return &response{data: NewEncoder().SimpleError("ERR encoding failed")}
 	}
@@ -270,72 +342,244 @@ func (spec *TYPESpecs) Execute(e *executor, req Request) Response {
 
 func (spec *XADDSpecs) Execute(e *executor, req Request) Response {
 	createStreamOpts := []AddStreamOpts{}
-	if spec.Id == nil && spec.Seq == nil {
-		// TODO: Generate new id and seq
+	if spec.Id == nil {
+		// Both generated
 	} else if spec.Seq == nil {
-		// TODO: Generate new Seq
 		createStreamOpts = append(
 			createStreamOpts,
-			WithPredefinedId(int(*spec.Id)),
+			WithPredefinedId(*spec.Id),
 		)
 	} else {
 		// Both Provided
 		createStreamOpts = append(
 			createStreamOpts,
-			WithPredefinedIdAndSequence(
-				int(*spec.Id), int(*spec.Seq),
-			),
+			WithPredefinedIdAndSequence(*spec.Id, *spec.Seq),
 		)
 	}
 	generatedId, err := e.store.Stream.CreateOrUpdateStream(spec.Key, spec.KVs, createStreamOpts...)
-	if err != nil {
-		return &response{data: NewEncoder().SimpleError(fmt.Sprintf("ERR: %v", err))}
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data, propagate: []Token{}}
+	}
+	// Replicas get the ID generated here rather than generating their own
+	args := []string{XADD, spec.Key, generatedId}
+	for _, kv := range spec.KVs {
+		args = append(args, kv.Key, kv.Value)
+	}
+	return &response{
+		data:      NewEncoder().BulkString(&generatedId),
+		propagate: bulkStrings(args),
 	}
-	return &response{data: NewEncoder().BulkString(&generatedId)}
 }
 
-func (spec *LPOPSpecs) Execute(e *executor, req Request) Response {
-	var data []byte
-	if spec.AmountToRemove != nil {
-		elements := []Token{}
-		for range *spec.AmountToRemove {
-			popped := e.store.List.Pop(spec.Key)
-			if popped == nil {
-				break
-			}
-			elements = append(elements, NewToken(BULK_STRING, *popped))
+func (spec *XLENSpecs) Execute(e *executor, req Request) Response {
+	length, err := e.store.Stream.Len(spec.Key)
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
+	}
+	return &response{data: NewEncoder().Integer(length)}
+}
+
+// streamEntries encodes every entry as its ID followed by its fields and
+// values
+func streamEntries(entries []StreamEntry) []Token {
+	tkns := []Token{}
+	for _, entry := range entries {
+		fields := []Token{}
+		for _, kv := range entry.Fields {
+			fields = append(fields, NewToken(BULK_STRING, kv.Key), NewToken(BULK_STRING, kv.Value))
 		}
-		data = NewEncoder().Array(elements...)
-	} else {
-		popped := e.store.List.Pop(spec.Key)
-		data = NewEncoder().BulkString(popped)
+		tkns = append(tkns, NewToken(ARRAY, []Token{
+			NewToken(BULK_STRING, entry.ID.String()),
+			NewToken(ARRAY, fields),
+		}))
+	}
+	return tkns
+}
+
+func streamRange(e *executor, key string, start StreamID, end StreamID, count int64, rev bool) Response {
+	entries, err := e.store.Stream.Range(key, start, end, int(count), rev)
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
+	}
+	return &response{data: NewEncoder().Array(streamEntries(entries)...)}
+}
+
+func (spec *XRANGESpecs) Execute(e *executor, req Request) Response {
+	return streamRange(e, spec.Key, spec.Start, spec.End, spec.Count, false)
+}
+
+func (spec *XREVRANGESpecs) Execute(e *executor, req Request) Response {
+	return streamRange(e, spec.Key, spec.Start, spec.End, spec.Count, true)
+}
+
+func (spec *XREADSpecs) Execute(e *executor, req Request) Response {
+	if spec.Block == nil {
+		if res := spec.serve(e, ""); res != nil {
+			return res
+		}
+		return &response{data: NewEncoder().NullArray()}
+	}
+	// "$" waits for the entries added after the ones there are now
+	for i, cursor := range spec.Cursors {
+		if !cursor.New {
+			continue
+		}
+		id, err := e.store.Stream.LastID(spec.Keys[i])
+		if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+			return &response{data: data}
+		}
+		spec.Cursors[i] = StreamCursor{ID: id}
+	}
+	return block(e, req, spec)
+}
+
+func (spec *XREADSpecs) WaitKeys() []string {
+	return spec.Keys
+}
+
+func (spec *XREADSpecs) Timeout() *float64 {
+	if *spec.Block == 0 {
+		return nil
+	}
+	timeout := float64(*spec.Block) / 1000
+	return &timeout
+}
+
+// serve reads every stream rather than the one at key, as a blocked XREAD
+// replies with all the streams that got entries
+func (spec *XREADSpecs) serve(e *executor, key string) Response {
+	read, err := e.store.Stream.Read(spec.Keys, spec.Cursors, int(spec.Count))
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
+	}
+	if len(read) == 0 {
+		return nil
+	}
+	streams := []Token{}
+	for _, strm := range read {
+		streams = append(streams, NewToken(ARRAY, []Token{
+			NewToken(BULK_STRING, strm.Key),
+			NewToken(ARRAY, streamEntries(strm.Entries)),
+		}))
+	}
+	return &response{data: NewEncoder().Array(streams...)}
+}
+
+func (spec *XREADSpecs) timedOut() []byte {
+	return NewEncoder().NullArray()
+}
+
+func (spec *XREADSpecs) readsOnly() {}
+
+func (spec *XINFO_STREAMSpecs) Execute(e *executor, req Request) Response {
+	info, err := e.store.Stream.Info(spec.Key)
+	if err == nil && info == nil {
+		err = fmt.Errorf("ERR no such key")
+	}
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
+	}
+	entry := func(entry *StreamEntry) Token {
+		if entry == nil {
+			return NewToken(BULK_STRING, nil)
+		}
+		return streamEntries([]StreamEntry{*entry})[0]
+	}
+	firstID := MIN_STREAM_ID
+	if info.FirstEntry != nil {
+		firstID = info.FirstEntry.ID
+	}
+	return &response{data: NewEncoder().Array(
+		NewToken(BULK_STRING, "length"),
+		NewToken(INTEGER, info.Length),
+		NewToken(BULK_STRING, "last-generated-id"),
+		NewToken(BULK_STRING, info.LastGeneratedID.String()),
+		NewToken(BULK_STRING, "max-deleted-entry-id"),
+		NewToken(BULK_STRING, info.MaxDeletedID.String()),
+		NewToken(BULK_STRING, "entries-added"),
+		NewToken(INTEGER, int(info.EntriesAdded)),
+		NewToken(BULK_STRING, "recorded-first-entry-id"),
+		NewToken(BULK_STRING, firstID.String()),
+		NewToken(BULK_STRING, "groups"),
+		NewToken(INTEGER, 0),
+		NewToken(BULK_STRING, "first-entry"),
+		entry(info.FirstEntry),
+		NewToken(BULK_STRING, "last-entry"),
+		entry(info.LastEntry),
+	)}
+}
+
+// listPop pops a single element, or up to count of them when count is given
+func listPop(e *executor, key string, count *int64, tail bool) Response {
+	pop := e.store.List.Pop
+	if tail {
+		pop = e.store.List.PopTail
+	}
+	if count == nil {
+		popped, err := pop(key, 1)
+		if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+			return &response{data: data}
+		}
+		if len(popped) == 0 {
+			return &response{data: NewEncoder().BulkString(nil)}
+		}
+		return &response{data: NewEncoder().BulkString(&popped[0])}
+	}
+	if *count < 0 {
+		return &response{data: NewEncoder().SimpleError("ERR value is out of range, must be positive")}
+	}
+	popped, err := pop(key, int(*count))
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
+	}
+	if popped == nil {
+		return &response{data: NewEncoder().NullArray()}
+	}
+	return &response{data: NewEncoder().Array(bulkStrings(popped)...)}
+}
+
+func (spec *LPOPSpecs) Execute(e *executor, req Request) Response {
+	return listPop(e, spec.Key, spec.AmountToRemove, false)
+}
+
+// blockingPop pops a single element from key for BLPOP and BRPOP, nil when
+// key has none. Replicas are sent the LPOP or RPOP of key
+func blockingPop(e *executor, key string, tail bool) Response {
+	pop, cmd := e.store.List.Pop, LPOP
+	if tail {
+		pop, cmd = e.store.List.PopTail, RPOP
+	}
+	popped, err := pop(key, 1)
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data, propagate: []Token{}}
+	}
+	if len(popped) == 0 {
+		return nil
+	}
+	return &response{
+		data:      NewEncoder().Array(bulkStrings([]string{key, popped[0]})...),
+		propagate: bulkStrings([]string{cmd, key}),
 	}
-	return &response{data: data}
 }
 
 func (spec *BLPOPSpecs) Execute(e *executor, req Request) Response {
-	removedElements := []string{}
-	for i := 0; i < len(spec.Keys); i++ {
-		key := spec.Keys[0]
-		popped := e.store.List.Pop(key)
-		if popped == nil {
-			waitingArea.mu.Lock()
-			waitingArea.queue[key] = append(waitingArea.queue[key], BLPOPHold{
-				req:  req,
-				resp: removedElements,
-				keys: spec.Keys[i:],
-			})
-			waitingArea.mu.Unlock()
-			return nil
-		}
-		removedElements = append(removedElements, key, *popped)
-	}
-	tokens := []Token{}
-	for _, data := range removedElements {
-		tokens = append(tokens, NewToken(BULK_STRING, data))
-	}
-	spec.Concluded = true
-	return &response{data: NewEncoder().Array(tokens...)}
+	return block(e, req, spec)
+}
+
+func (spec *BLPOPSpecs) WaitKeys() []string {
+	return spec.Keys
+}
+
+func (spec *BLPOPSpecs) Timeout() *float64 {
+	return spec.Lifetime
+}
+
+func (spec *BLPOPSpecs) serve(e *executor, key string) Response {
+	return blockingPop(e, key, false)
+}
+
+func (spec *BLPOPSpecs) timedOut() []byte {
+	return NewEncoder().NullArray()
 }
 
 func (s *SUBSCRIBESpecs) Execute(e *executor, req Request) Response {
@@ -412,9 +656,13 @@ func (s *AUTHSpecs) Execute(e *executor, req Request) Response {
 	return &response{data: NewEncoder().Ok()}
 }
 
-func (s *ZRANKSpecs) Execute(e *executor, req Request) Response {
-	var rank int
-	rank = req.Client().SortedSet().Rank(s.Key, s.Value)
+// zrank replies with the rank of value, from the highest score when rev is
+// set
+func zrank(e *executor, key string, value string, rev bool) Response {
+	rank, err := e.store.SortedSet.Rank(key, value, rev)
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
+	}
 	var data []byte
 	if rank == -1 {
 		data = NewEncoder().BulkString(nil)
@@ -426,112 +674,1495 @@ func (s *ZRANKSpecs) Execute(e *executor, req Request) Response {
 	}
 }
 
-func (s *ZRANGESpecs) Execute(e *executor, req Request) Response {
-	elems := req.Client().SortedSet().Range(s.Key, s.Start, s.End)
-	tkns := []Token{}
-	for _, e := range elems {
-		tkns = append(tkns, NewToken(BULK_STRING, e))
+func (s *ZRANKSpecs) Execute(e *executor, req Request) Response {
+	return zrank(e, s.Key, s.Value, false)
+}
+
+func (s *ZREVRANKSpecs) Execute(e *executor, req Request) Response {
+	return zrank(e, s.Key, s.Value, true)
+}
+
+// zrange replies with the members selected by opts, each followed by its
+// score with withScores
+func zrange(e *executor, key string, opts ZRangeOptions, withScores bool) Response {
+	members, err := e.store.SortedSet.Range(key, opts)
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
 	}
 	return &response{
-		data: NewEncoder().Array(tkns...),
+		data: NewEncoder().Array(zmembers(members, withScores)...),
+	}
+}
+
+func (s *ZRANGESpecs) Execute(e *executor, req Request) Response {
+	return zrange(e, s.Key, s.Options, s.WithScores)
+}
+
+func (s *ZREVRANGESpecs) Execute(e *executor, req Request) Response {
+	return zrange(e, s.Key, s.Options, s.WithScores)
+}
+
+func (s *ZRANGEBYSCORESpecs) Execute(e *executor, req Request) Response {
+	return zrange(e, s.Key, s.Options, s.WithScores)
+}
+
+func (s *ZREVRANGEBYSCORESpecs) Execute(e *executor, req Request) Response {
+	return zrange(e, s.Key, s.Options, s.WithScores)
+}
+
+func (s *ZRANGEBYLEXSpecs) Execute(e *executor, req Request) Response {
+	return zrange(e, s.Key, s.Options, s.WithScores)
+}
+
+func (s *ZREVRANGEBYLEXSpecs) Execute(e *executor, req Request) Response {
+	return zrange(e, s.Key, s.Options, s.WithScores)
+}
+
+func (s *ZRANGESTORESpecs) Execute(e *executor, req Request) Response {
+	stored, err := e.store.SortedSet.RangeStore(s.Destination, s.Source, s.Options)
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
+	}
+	return &response{data: NewEncoder().Integer(stored)}
+}
+
+// zcombine replies with the result of op over keys, each member followed by
+// its score with withScores
+func zcombine(e *executor, op string, keys []string, opts ZCombineOptions, withScores bool) Response {
+	members, err := e.store.SortedSet.Combine(op, keys, opts)
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
 	}
+	return &response{data: NewEncoder().Array(zmembers(members, withScores)...)}
+}
+
+// zcombineStore stores the result of op over keys at dest. Replicas are
+// sent the deletion of dest followed by a ZADD of the result
+func zcombineStore(e *executor, op string, dest string, keys []string, opts ZCombineOptions) Response {
+	members, err := e.store.SortedSet.CombineStore(op, dest, keys, opts)
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data, propagate: []Token{}}
+	}
+	res := &response{
+		data:         NewEncoder().Integer(len(members)),
+		propagateAll: [][]Token{bulkStrings([]string{DEL, dest})},
+	}
+	if len(members) > 0 {
+		zadd := []string{ZADD, dest}
+		for _, m := range members {
+			zadd = append(zadd, formatScore(m.Score), m.Member)
+		}
+		res.propagateAll = append(res.propagateAll, bulkStrings(zadd))
+	}
+	return res
+}
+
+func (s *ZUNIONSpecs) Execute(e *executor, req Request) Response {
+	return zcombine(e, SET_OP_UNION, s.Keys, s.Options, s.WithScores)
+}
+
+func (s *ZINTERSpecs) Execute(e *executor, req Request) Response {
+	return zcombine(e, SET_OP_INTER, s.Keys, s.Options, s.WithScores)
+}
+
+func (s *ZDIFFSpecs) Execute(e *executor, req Request) Response {
+	return zcombine(e, SET_OP_DIFF, s.Keys, ZCombineOptions{}, s.WithScores)
+}
+
+func (s *ZUNIONSTORESpecs) Execute(e *executor, req Request) Response {
+	return zcombineStore(e, SET_OP_UNION, s.Destination, s.Keys, s.Options)
+}
+
+func (s *ZINTERSTORESpecs) Execute(e *executor, req Request) Response {
+	return zcombineStore(e, SET_OP_INTER, s.Destination, s.Keys, s.Options)
+}
+
+func (s *ZDIFFSTORESpecs) Execute(e *executor, req Request) Response {
+	return zcombineStore(e, SET_OP_DIFF, s.Destination, s.Keys, ZCombineOptions{})
+}
+
+func (s *ZINTERCARDSpecs) Execute(e *executor, req Request) Response {
+	count, err := e.store.SortedSet.InterCard(s.Keys, int(s.Limit))
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
+	}
+	return &response{data: NewEncoder().Integer(count)}
+}
+
+func (s *ZCOUNTSpecs) Execute(e *executor, req Request) Response {
+	count, err := e.store.SortedSet.Count(s.Key, s.Scores)
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
+	}
+	return &response{data: NewEncoder().Integer(count)}
+}
+
+func (s *ZLEXCOUNTSpecs) Execute(e *executor, req Request) Response {
+	count, err := e.store.SortedSet.LexCount(s.Key, s.Lex)
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
+	}
+	return &response{data: NewEncoder().Integer(count)}
 }
 
 func (s *ZSCORESpecs) Execute(e *executor, req Request) Response {
-	scr := req.Client().SortedSet().Get(s.Key, s.Value)
+	scr, err := e.store.SortedSet.Get(s.Key, s.Value)
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
+	}
 	return &response{
 		data: NewEncoder().BulkString(scr),
 	}
 }
 
 func (s *ZCARDSpecs) Execute(e *executor, req Request) Response {
-	card := req.Client().SortedSet().Cardinality(s.Key)
+	card, err := e.store.SortedSet.Cardinality(s.Key)
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
+	}
 	return &response{
 		data: NewEncoder().Integer(card),
 	}
 }
 
 func (s *ZREMSpecs) Execute(e *executor, req Request) Response {
-	card := req.Client().SortedSet().Remove(s.Key, s.Value)
+	card, err := e.store.SortedSet.Remove(s.Key, s.Value)
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
+	}
 	return &response{
 		data: NewEncoder().Integer(card),
 	}
 }
 
 func (s *ZADDSpecs) Execute(e *executor, req Request) Response {
-	newLen := req.Client().SortedSet().Add(s.Key, s.Value, s.Score)
-	return &response{data: NewEncoder().Integer(int(newLen))}
-}
-
-func (s *WATCHSpecs) Execute(e *executor, req Request) Response {
-	enc := NewEncoder()
-	var notifier *CmdNotifier
-	for _, k := range s.Keys {
-		notifier = req.Client().Watch(strings.ToLower(k))
+	changed, score, err := e.store.SortedSet.Add(s.Key, s.Members, s.Options)
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
 	}
-	return &response{
-		data:      enc.Ok(),
-		artifacts: notifier,
+	if !s.Options.Incr {
+		return &response{data: NewEncoder().Integer(changed)}
 	}
+	if score == nil {
+		return &response{data: NewEncoder().BulkString(nil)}
+	}
+	formatted := formatScore(*score)
+	return &response{data: NewEncoder().BulkString(&formatted)}
 }
 
-func (s *UNWATCHSpecs) Execute(e *executor, req Request) Response {
-	enc := NewEncoder()
-	req.Client().TerminateWatcher()
-	return &response{
-		data:      enc.Ok(),
-		artifacts: false,
+func (s *ZINCRBYSpecs) Execute(e *executor, req Request) Response {
+	members := []ZMember{{Member: s.Member, Score: s.Increment}}
+	_, score, err := e.store.SortedSet.Add(s.Key, members, ZAddOptions{Incr: true})
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
 	}
+	formatted := formatScore(*score)
+	return &response{data: NewEncoder().BulkString(&formatted)}
 }
 
-func (s *GEOADDSpecs) Execute(e *executor, req Request) Response {
-	var data []byte
-	if !ValidateCoords(s.Lng, LNG) || !ValidateCoords(s.Lat, LAT) {
-		data = NewEncoder().SimpleError(fmt.Sprintf("ERR invalid longitude,latitude pair %v,%v", s.Lng, s.Lat))
-	} else {
-		score := Score(s.Lat, s.Lng)
-		zaddSpec := ZADDSpecs{
-			Key:   s.Key,
-			Value: s.Member,
-			Score: float64(score),
+func (s *ZMSCORESpecs) Execute(e *executor, req Request) Response {
+	scores, err := e.store.SortedSet.Scores(s.Key, s.Members)
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
+	}
+	replies := [][]byte{}
+	for _, score := range scores {
+		if score == nil {
+			replies = append(replies, NewEncoder().BulkString(nil))
+			continue
 		}
-		res := zaddSpec.Execute(e, req)
-		data = res.Data()
+		formatted := formatScore(*score)
+		replies = append(replies, NewEncoder().BulkString(&formatted))
 	}
+	return &response{data: NewEncoder().ArrayRaw(replies)}
+}
 
-	return &response{
-		data: data,
+// zpop replies with the members popped from key, each followed by its score.
+// Nothing is propagated when the key was empty
+func zpop(e *executor, key string, count *int64, highest bool) Response {
+	n := int64(1)
+	if count != nil {
+		n = *count
 	}
+	if n < 0 {
+		return &response{data: NewEncoder().SimpleError("ERR value is out of range, must be positive")}
+	}
+	popped, err := e.store.SortedSet.Pop(key, int(n), highest)
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
+	}
+	res := &response{data: NewEncoder().Array(zmembers(popped, true)...)}
+	if len(popped) == 0 {
+		res.propagate = []Token{}
+	}
+	return res
 }
 
-func (s *GEOPOSSpecs) Execute(e *executor, req Request) Response {
-	var data []byte
-	zscoreSpec := ZSCORESpecs{
-		Key: s.Key,
-	}
-	responses := []Token{}
-	for _, k := range s.Locs {
-		zscoreSpec.Value = k
-		res := zscoreSpec.Execute(e, req)
-		decoded, _ := NewParser(bufio.NewReader(bytes.NewReader(res.Data()))).TryParse()
-		if score, err := strconv.ParseFloat(decoded.Literal.(string), 64); err == nil {
-			lat, lng := LatLng(int(score))
-			arr := NewToken(ARRAY, []Token{
-				NewToken(BULK_STRING, fmt.Sprintf("%v", lat)),
-				NewToken(BULK_STRING, fmt.Sprintf("%v", lng)),
-			})
-			responses = append(responses, arr)
-		} else {
-			arr := NewToken(ARRAY, []Token{
-				NewToken(BULK_STRING, "0"),
-				NewToken(BULK_STRING, "0"),
-			})
-			responses = append(responses, arr)
+// zmembers returns the members as bulk strings, each followed by its score
+// with withScores
+func zmembers(members []ZMember, withScores bool) []Token {
+	tkns := []Token{}
+	for _, m := range members {
+		tkns = append(tkns, NewToken(BULK_STRING, m.Member))
+		if withScores {
+			tkns = append(tkns, NewToken(BULK_STRING, formatScore(m.Score)))
 		}
 	}
+	return tkns
+}
 
-	data = NewEncoder().Array(responses...)
+func (s *ZPOPMINSpecs) Execute(e *executor, req Request) Response {
+	return zpop(e, s.Key, s.Count, false)
+}
+
+func (s *ZPOPMAXSpecs) Execute(e *executor, req Request) Response {
+	return zpop(e, s.Key, s.Count, true)
+}
+
+// blockingZPop pops the member with the lowest score from key for BZPOPMIN,
+// the highest for BZPOPMAX, nil when key has none. Replicas are sent the
+// ZPOPMIN or ZPOPMAX of key
+func blockingZPop(e *executor, key string, highest bool) Response {
+	cmd := ZPOPMIN
+	if highest {
+		cmd = ZPOPMAX
+	}
+	popped, err := e.store.SortedSet.Pop(key, 1, highest)
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data, propagate: []Token{}}
+	}
+	if len(popped) == 0 {
+		return nil
+	}
 	return &response{
-		data: data,
+		data:      NewEncoder().Array(bulkStrings([]string{key, popped[0].Member, formatScore(popped[0].Score)})...),
+		propagate: bulkStrings([]string{cmd, key}),
 	}
 }
+
+func (spec *BZPOPMINSpecs) Execute(e *executor, req Request) Response {
+	return block(e, req, spec)
+}
+
+func (spec *BZPOPMINSpecs) WaitKeys() []string {
+	return spec.Keys
+}
+
+func (spec *BZPOPMINSpecs) Timeout() *float64 {
+	return spec.Lifetime
+}
+
+func (spec *BZPOPMINSpecs) serve(e *executor, key string) Response {
+	return blockingZPop(e, key, false)
+}
+
+func (spec *BZPOPMINSpecs) timedOut() []byte {
+	return NewEncoder().NullArray()
+}
+
+func (spec *BZPOPMAXSpecs) Execute(e *executor, req Request) Response {
+	return block(e, req, spec)
+}
+
+func (spec *BZPOPMAXSpecs) WaitKeys() []string {
+	return spec.Keys
+}
+
+func (spec *BZPOPMAXSpecs) Timeout() *float64 {
+	return spec.Lifetime
+}
+
+func (spec *BZPOPMAXSpecs) serve(e *executor, key string) Response {
+	return blockingZPop(e, key, true)
+}
+
+func (spec *BZPOPMAXSpecs) timedOut() []byte {
+	return NewEncoder().NullArray()
+}
+
+// zmultiPop pops up to count members from the where side of key for ZMPOP
+// and BZMPOP, nil when key has none. Replicas are sent the ZPOPMIN or
+// ZPOPMAX of key with the count
+func zmultiPop(e *executor, key string, where string, count int64) Response {
+	highest, cmd := where == "MAX", ZPOPMIN
+	if highest {
+		cmd = ZPOPMAX
+	}
+	popped, err := e.store.SortedSet.Pop(key, int(count), highest)
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data, propagate: []Token{}}
+	}
+	if len(popped) == 0 {
+		return nil
+	}
+	pairs := [][]byte{}
+	for _, m := range popped {
+		pairs = append(pairs, NewEncoder().Array(bulkStrings([]string{m.Member, formatScore(m.Score)})...))
+	}
+	return &response{
+		data: NewEncoder().ArrayRaw([][]byte{
+			NewEncoder().BulkString(&key),
+			NewEncoder().ArrayRaw(pairs),
+		}),
+		propagate: bulkStrings([]string{cmd, key, strconv.Itoa(len(popped))}),
+	}
+}
+
+func (spec *ZMPOPSpecs) Execute(e *executor, req Request) Response {
+	for _, key := range spec.Keys {
+		if res := zmultiPop(e, key, spec.Where, spec.Count); res != nil {
+			return res
+		}
+	}
+	return &response{data: NewEncoder().NullArray(), propagate: []Token{}}
+}
+
+func (spec *BZMPOPSpecs) Execute(e *executor, req Request) Response {
+	return block(e, req, spec)
+}
+
+func (spec *BZMPOPSpecs) WaitKeys() []string {
+	return spec.Keys
+}
+
+func (spec *BZMPOPSpecs) Timeout() *float64 {
+	return spec.Lifetime
+}
+
+func (spec *BZMPOPSpecs) serve(e *executor, key string) Response {
+	return zmultiPop(e, key, spec.Where, spec.Count)
+}
+
+func (spec *BZMPOPSpecs) timedOut() []byte {
+	return NewEncoder().NullArray()
+}
+
+func (s *ZRANDMEMBERSpecs) Execute(e *executor, req Request) Response {
+	count := 1
+	if s.Count != nil {
+		// Negating lower counts would overflow
+		if *s.Count < -math.MaxInt64/2 {
+			return &response{data: NewEncoder().SimpleError("ERR value is out of range")}
+		}
+		count = int(*s.Count)
+	}
+	members, err := e.store.SortedSet.RandMember(s.Key, count)
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
+	}
+	if s.Count != nil {
+		return &response{data: NewEncoder().Array(zmembers(members, s.WithScores)...)}
+	}
+	if len(members) == 0 {
+		return &response{data: NewEncoder().BulkString(nil)}
+	}
+	return &response{data: NewEncoder().BulkString(&members[0].Member)}
+}
+
+// zremRange deletes the members of key selected by opts. Nothing is
+// propagated when none got deleted
+func zremRange(e *executor, key string, opts ZRangeOptions) Response {
+	removed, err := e.store.SortedSet.RemoveRange(key, opts)
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
+	}
+	res := &response{data: NewEncoder().Integer(removed)}
+	if removed == 0 {
+		res.propagate = []Token{}
+	}
+	return res
+}
+
+func (s *ZREMRANGEBYRANKSpecs) Execute(e *executor, req Request) Response {
+	return zremRange(e, s.Key, s.Options)
+}
+
+func (s *ZREMRANGEBYSCORESpecs) Execute(e *executor, req Request) Response {
+	return zremRange(e, s.Key, s.Options)
+}
+
+func (s *ZREMRANGEBYLEXSpecs) Execute(e *executor, req Request) Response {
+	return zremRange(e, s.Key, s.Options)
+}
+
+func (s *WATCHSpecs) Execute(e *executor, req Request) Response {
+	enc := NewEncoder()
+	for _, k := range s.Keys {
+		req.Client().Watch(k)
+	}
+	return &response{
+		data: enc.Ok(),
+	}
+}
+
+func (s *UNWATCHSpecs) Execute(e *executor, req Request) Response {
+	enc := NewEncoder()
+	req.Client().TerminateWatcher()
+	return &response{
+		data:      enc.Ok(),
+		artifacts: false,
+	}
+}
+
+func (s *GEOADDSpecs) Execute(e *executor, req Request) Response {
+	var data []byte
+	if !ValidateCoords(s.Lng, LNG) || !ValidateCoords(s.Lat, LAT) {
+		data = NewEncoder().SimpleError(fmt.Sprintf("ERR invalid longitude,latitude pair %v,%v", s.Lng, s.Lat))
+	} else {
+		score := Score(s.Lat, s.Lng)
+		zaddSpec := ZADDSpecs{
+			Key:     s.Key,
+			Members: []ZMember{{Member: s.Member, Score: float64(score)}},
+		}
+		res := zaddSpec.Execute(e, req)
+		data = res.Data()
+	}
+
+	return &response{
+		data: data,
+	}
+}
+
+func (s *GEOPOSSpecs) Execute(e *executor, req Request) Response {
+	var data []byte
+	responses := []Token{}
+	for _, k := range s.Locs {
+		scr, err := e.store.SortedSet.Get(s.Key, k)
+		if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+			return &response{data: data}
+		}
+		if scr != nil {
+			if score, err := strconv.ParseFloat(*scr, 64); err == nil {
+				lat, lng := LatLng(int(score))
+				arr := NewToken(ARRAY, []Token{
+					NewToken(BULK_STRING, fmt.Sprintf("%v", lat)),
+					NewToken(BULK_STRING, fmt.Sprintf("%v", lng)),
+				})
+				responses = append(responses, arr)
+				continue
+			}
+		}
+		arr := NewToken(ARRAY, []Token{
+			NewToken(BULK_STRING, "0"),
+			NewToken(BULK_STRING, "0"),
+		})
+		responses = append(responses, arr)
+	}
+
+	data = NewEncoder().Array(responses...)
+	return &response{
+		data: data,
+	}
+}
+
+func (s *DELSpecs) Execute(e *executor, req Request) Response {
+	return &response{data: NewEncoder().Integer(e.store.Keyspace.Delete(s.Keys...))}
+}
+
+func (s *UNLINKSpecs) Execute(e *executor, req Request) Response {
+	return &response{data: NewEncoder().Integer(e.store.Keyspace.Delete(s.Keys...))}
+}
+
+func (s *EXISTSSpecs) Execute(e *executor, req Request) Response {
+	return &response{data: NewEncoder().Integer(e.store.Keyspace.Exists(s.Keys...))}
+}
+
+// expireTime converts when, counted in unit milliseconds, to an absolute time
+// relative to base (unix ms), failing on int64 overflow like redis does
+func expireTime(cmd string, base int64, when int64, unit int64) (time.Time, error) {
+	if when > math.MaxInt64/unit || when < math.MinInt64/unit {
+		return time.Time{}, &ErrInvalidExpireTime{cmd: cmd}
+	}
+	when *= unit
+	if (when > 0 && base > math.MaxInt64-when) || (when < 0 && base < math.MinInt64-when) {
+		return time.Time{}, &ErrInvalidExpireTime{cmd: cmd}
+	}
+	return time.UnixMilli(base + when), nil
+}
+
+// expireAt is shared by the EXPIRE family. Replicas always receive an absolute
+// PEXPIREAT, or a DEL when the key got expired right away
+func expireAt(e *executor, key string, at time.Time, cond ExpireCondition) Response {
+	updated := e.store.Keyspace.Expire(key, at, cond)
+	// Nothing is propagated when the expiry did not change
+	res := &response{data: NewEncoder().Integer(updated), propagate: []Token{}}
+	if updated == 1 {
+		if at.After(time.Now()) {
+			res.propagate = []Token{
+				NewToken(BULK_STRING, PEXPIREAT),
+				NewToken(BULK_STRING, key),
+				NewToken(BULK_STRING, strconv.FormatInt(at.UnixMilli(), 10)),
+			}
+		} else {
+			res.propagate = []Token{
+				NewToken(BULK_STRING, DEL),
+				NewToken(BULK_STRING, key),
+			}
+		}
+	}
+	return res
+}
+
+func (s *EXPIRESpecs) Execute(e *executor, req Request) Response {
+	at, err := expireTime(EXPIRE, time.Now().UnixMilli(), s.Seconds, 1000)
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
+	}
+	return expireAt(e, s.Key, at, s.Condition)
+}
+
+func (s *PEXPIRESpecs) Execute(e *executor, req Request) Response {
+	at, err := expireTime(PEXPIRE, time.Now().UnixMilli(), s.Milliseconds, 1)
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
+	}
+	return expireAt(e, s.Key, at, s.Condition)
+}
+
+func (s *EXPIREATSpecs) Execute(e *executor, req Request) Response {
+	at, err := expireTime(EXPIREAT, 0, s.UnixTimeSeconds, 1000)
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
+	}
+	return expireAt(e, s.Key, at, s.Condition)
+}
+
+func (s *PEXPIREATSpecs) Execute(e *executor, req Request) Response {
+	at, err := expireTime(PEXPIREAT, 0, s.UnixTimeMilliseconds, 1)
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
+	}
+	return expireAt(e, s.Key, at, s.Condition)
+}
+
+// keyTTL returns -2 when key does not exist and -1 when it has no expiry
+func keyTTL(e *executor, key string, currentTime time.Time, unit time.Duration) int {
+	exp, exists := e.store.Keyspace.Expiry(key, currentTime)
+	if !exists {
+		return -2
+	}
+	if exp == nil {
+		return -1
+	}
+	// In milliseconds, a time.Duration overflows for far away expiries
+	ttl := exp.UnixMilli() - currentTime.UnixMilli()
+	ms := unit.Milliseconds()
+	return int((ttl + ms/2) / ms)
+}
+
+func (s *TTLSpecs) Execute(e *executor, req Request) Response {
+	return &response{data: NewEncoder().Integer(keyTTL(e, s.Key, s.CurrentTime, time.Second))}
+}
+
+func (s *PTTLSpecs) Execute(e *executor, req Request) Response {
+	return &response{data: NewEncoder().Integer(keyTTL(e, s.Key, s.CurrentTime, time.Millisecond))}
+}
+
+func (s *EXPIRETIMESpecs) Execute(e *executor, req Request) Response {
+	exp, exists := e.store.Keyspace.Expiry(s.Key, s.CurrentTime)
+	if !exists {
+		return &response{data: NewEncoder().Integer(-2)}
+	}
+	if exp == nil {
+		return &response{data: NewEncoder().Integer(-1)}
+	}
+	return &response{data: NewEncoder().Integer(int(exp.Unix()))}
+}
+
+func (s *PEXPIRETIMESpecs) Execute(e *executor, req Request) Response {
+	exp, exists := e.store.Keyspace.Expiry(s.Key, s.CurrentTime)
+	if !exists {
+		return &response{data: NewEncoder().Integer(-2)}
+	}
+	if exp == nil {
+		return &response{data: NewEncoder().Integer(-1)}
+	}
+	return &response{data: NewEncoder().Integer(int(exp.UnixMilli()))}
+}
+
+func (s *PERSISTSpecs) Execute(e *executor, req Request) Response {
+	return &response{data: NewEncoder().Integer(e.store.Keyspace.Persist(s.Key))}
+}
+
+// scanReply encodes the [cursor, [elements...]] reply of the SCAN family
+func scanReply(cursor uint64, elems []string) Response {
+	tkns := []Token{}
+	for _, elem := range elems {
+		tkns = append(tkns, NewToken(BULK_STRING, elem))
+	}
+	return &response{
+		data: NewEncoder().Array(
+			NewToken(BULK_STRING, strconv.FormatUint(cursor, 10)),
+			NewToken(ARRAY, tkns),
+		),
+	}
+}
+
+func (s *SCANSpecs) Execute(e *executor, req Request) Response {
+	return scanReply(e.store.Keyspace.Scan(s.Cursor, s.Options, s.CurrentTime))
+}
+
+func (s *ZSCANSpecs) Execute(e *executor, req Request) Response {
+	next, elems, err := e.store.SortedSet.Scan(s.Key, s.Cursor, s.Options)
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
+	}
+	return scanReply(next, elems)
+}
+
+func (s *APPENDSpecs) Execute(e *executor, req Request) Response {
+	length, err := e.store.KV.Append(s.Key, s.Value)
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
+	}
+	return &response{data: NewEncoder().Integer(length)}
+}
+
+func (s *STRLENSpecs) Execute(e *executor, req Request) Response {
+	val, err := e.store.KV.Get(s.Key, s.CurrentTime)
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
+	}
+	if val == nil {
+		return &response{data: NewEncoder().Integer(0)}
+	}
+	return &response{data: NewEncoder().Integer(len(val.Literal.(string)))}
+}
+
+func (s *GETRANGESpecs) Execute(e *executor, req Request) Response {
+	val, err := e.store.KV.Get(s.Key, s.CurrentTime)
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
+	}
+	data := ""
+	if val == nil {
+		return &response{data: NewEncoder().BulkString(&data)}
+	}
+	str := val.Literal.(string)
+	start, end, length := s.Start, s.End, int64(len(str))
+	if start < 0 {
+		start = max(length+start, 0)
+	}
+	if end < 0 {
+		end = max(length+end, 0)
+	}
+	if end >= length {
+		end = length - 1
+	}
+	if start <= end && length > 0 {
+		data = str[start : end+1]
+	}
+	return &response{data: NewEncoder().BulkString(&data)}
+}
+
+func (s *SETRANGESpecs) Execute(e *executor, req Request) Response {
+	if s.Offset < 0 {
+		return &response{data: NewEncoder().SimpleError("ERR offset is out of range")}
+	}
+	length, err := e.store.KV.SetRange(s.Key, s.Offset, s.Value)
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
+	}
+	return &response{data: NewEncoder().Integer(length)}
+}
+
+func (s *MGETSpecs) Execute(e *executor, req Request) Response {
+	tkns := []Token{}
+	for _, val := range e.store.KV.MGet(s.Keys, s.CurrentTime) {
+		if val == nil {
+			tkns = append(tkns, NewToken(BULK_STRING, nil))
+		} else {
+			tkns = append(tkns, NewToken(BULK_STRING, val.Literal))
+		}
+	}
+	return &response{data: NewEncoder().Array(tkns...)}
+}
+
+func (s *MSETSpecs) Execute(e *executor, req Request) Response {
+	e.store.KV.MSet(s.KVs, false)
+	return &response{data: NewEncoder().Ok()}
+}
+
+func (s *MSETNXSpecs) Execute(e *executor, req Request) Response {
+	res := &response{data: NewEncoder().Integer(0)}
+	if e.store.KV.MSet(s.KVs, true) {
+		res.data = NewEncoder().Integer(1)
+	} else {
+		res.propagate = []Token{}
+	}
+	return res
+}
+
+func (s *GETDELSpecs) Execute(e *executor, req Request) Response {
+	val, err := e.store.KV.GetDel(s.Key)
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
+	}
+	res := &response{
+		data: NewEncoder().BulkString(nil),
+		propagate: []Token{
+			NewToken(BULK_STRING, DEL),
+			NewToken(BULK_STRING, s.Key),
+		},
+	}
+	if val == nil {
+		res.propagate = []Token{}
+		return res
+	}
+	data := val.Literal.(string)
+	res.data = NewEncoder().BulkString(&data)
+	return res
+}
+
+func (s *GETEXSpecs) Execute(e *executor, req Request) Response {
+	exp, err := expiryOption(GETEX, s.Ex, s.Px, s.Exat, s.Pxat)
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
+	}
+	val, err := e.store.KV.GetEx(s.Key, exp, s.Persist)
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
+	}
+	res := &response{
+		data:      NewEncoder().BulkString(nil),
+		propagate: []Token{},
+	}
+	if val == nil {
+		return res
+	}
+	data := val.Literal.(string)
+	res.data = NewEncoder().BulkString(&data)
+	// Replicas get the resulting expiry, a plain GETEX changes nothing
+	switch {
+	case exp != nil && !exp.After(time.Now()):
+		res.propagate = []Token{
+			NewToken(BULK_STRING, DEL),
+			NewToken(BULK_STRING, s.Key),
+		}
+	case exp != nil:
+		res.propagate = []Token{
+			NewToken(BULK_STRING, PEXPIREAT),
+			NewToken(BULK_STRING, s.Key),
+			NewToken(BULK_STRING, strconv.FormatInt(exp.UnixMilli(), 10)),
+		}
+	case s.Persist:
+		res.propagate = []Token{
+			NewToken(BULK_STRING, PERSIST),
+			NewToken(BULK_STRING, s.Key),
+		}
+	}
+	return res
+}
+
+func (s *GETSETSpecs) Execute(e *executor, req Request) Response {
+	old, _, err := e.store.KV.SetIf(s.Key, s.Value, nil, SetOptions{Get: true})
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
+	}
+	res := &response{
+		data: NewEncoder().BulkString(nil),
+		propagate: []Token{
+			NewToken(BULK_STRING, SET),
+			NewToken(BULK_STRING, s.Key),
+			s.Value,
+		},
+	}
+	if old != nil {
+		data := old.Literal.(string)
+		res.data = NewEncoder().BulkString(&data)
+	}
+	return res
+}
+
+func (s *HSETSpecs) Execute(e *executor, req Request) Response {
+	added, err := e.store.Hash.Set(s.Key, s.Fields, false)
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
+	}
+	return &response{data: NewEncoder().Integer(added)}
+}
+
+func (s *HSETNXSpecs) Execute(e *executor, req Request) Response {
+	added, err := e.store.Hash.Set(s.Key, []KeyValue{{Key: s.Field, Value: s.Value}}, true)
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
+	}
+	res := &response{data: NewEncoder().Integer(added)}
+	if added == 0 {
+		res.propagate = []Token{}
+	}
+	return res
+}
+
+func (s *HGETSpecs) Execute(e *executor, req Request) Response {
+	value, err := e.store.Hash.Get(s.Key, s.Field)
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
+	}
+	return &response{data: NewEncoder().BulkString(value)}
+}
+
+func (s *HMGETSpecs) Execute(e *executor, req Request) Response {
+	values, err := e.store.Hash.MGet(s.Key, s.Fields)
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
+	}
+	tkns := []Token{}
+	for _, value := range values {
+		if value == nil {
+			tkns = append(tkns, NewToken(BULK_STRING, nil))
+		} else {
+			tkns = append(tkns, NewToken(BULK_STRING, *value))
+		}
+	}
+	return &response{data: NewEncoder().Array(tkns...)}
+}
+
+func (s *HDELSpecs) Execute(e *executor, req Request) Response {
+	deleted, err := e.store.Hash.Delete(s.Key, s.Fields)
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
+	}
+	return &response{data: NewEncoder().Integer(deleted)}
+}
+
+// hashFields encodes the fields and/or the values of the hash at key
+func hashFields(e *executor, key string, withFields bool, withValues bool) Response {
+	fields, err := e.store.Hash.GetAll(key)
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
+	}
+	tkns := []Token{}
+	for _, kv := range fields {
+		if withFields {
+			tkns = append(tkns, NewToken(BULK_STRING, kv.Key))
+		}
+		if withValues {
+			tkns = append(tkns, NewToken(BULK_STRING, kv.Value))
+		}
+	}
+	return &response{data: NewEncoder().Array(tkns...)}
+}
+
+func (s *HGETALLSpecs) Execute(e *executor, req Request) Response {
+	return hashFields(e, s.Key, true, true)
+}
+
+func (s *HKEYSSpecs) Execute(e *executor, req Request) Response {
+	return hashFields(e, s.Key, true, false)
+}
+
+func (s *HVALSSpecs) Execute(e *executor, req Request) Response {
+	return hashFields(e, s.Key, false, true)
+}
+
+func (s *HINCRBYSpecs) Execute(e *executor, req Request) Response {
+	updated, err := e.store.Hash.IncrBy(s.Key, s.Field, s.Increment)
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
+	}
+	return &response{data: NewEncoder().Integer(int(updated))}
+}
+
+func (s *HINCRBYFLOATSpecs) Execute(e *executor, req Request) Response {
+	if math.IsNaN(s.Increment) || math.IsInf(s.Increment, 0) {
+		return &response{data: NewEncoder().SimpleError((&ErrNaNOrInfinity{}).Error())}
+	}
+	updated, err := e.store.Hash.IncrByFloat(s.Key, s.Field, s.Increment)
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
+	}
+	return &response{
+		data: NewEncoder().BulkString(&updated),
+		propagate: []Token{
+			NewToken(BULK_STRING, HSET),
+			NewToken(BULK_STRING, s.Key),
+			NewToken(BULK_STRING, s.Field),
+			NewToken(BULK_STRING, updated),
+		},
+	}
+}
+
+func (s *HLENSpecs) Execute(e *executor, req Request) Response {
+	length, err := e.store.Hash.Len(s.Key)
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
+	}
+	return &response{data: NewEncoder().Integer(length)}
+}
+
+func (s *HEXISTSSpecs) Execute(e *executor, req Request) Response {
+	value, err := e.store.Hash.Get(s.Key, s.Field)
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
+	}
+	if value == nil {
+		return &response{data: NewEncoder().Integer(0)}
+	}
+	return &response{data: NewEncoder().Integer(1)}
+}
+
+func (s *HSTRLENSpecs) Execute(e *executor, req Request) Response {
+	value, err := e.store.Hash.Get(s.Key, s.Field)
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
+	}
+	if value == nil {
+		return &response{data: NewEncoder().Integer(0)}
+	}
+	return &response{data: NewEncoder().Integer(len(*value))}
+}
+
+func (s *HSCANSpecs) Execute(e *executor, req Request) Response {
+	next, elems, err := e.store.Hash.Scan(s.Key, s.Cursor, s.Options)
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
+	}
+	return scanReply(next, elems)
+}
+
+// bitOffset parses a SETBIT / GETBIT offset, which must address a bit within
+// MAX_STRING_LENGTH
+func bitOffset(offset string) (int64, error) {
+	val, err := strconv.ParseInt(offset, 10, 64)
+	if err != nil || val < 0 || val > MAX_BIT_OFFSET {
+		return 0, &ErrBitOffset{}
+	}
+	return val, nil
+}
+
+func (s *SETBITSpecs) Execute(e *executor, req Request) Response {
+	offset, err := bitOffset(s.Offset)
+	if err == nil && s.Value != "0" && s.Value != "1" {
+		err = &ErrBitValue{}
+	}
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
+	}
+	old, err := e.store.KV.SetBit(s.Key, offset, int(s.Value[0]-'0'))
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
+	}
+	return &response{data: NewEncoder().Integer(old)}
+}
+
+func (s *GETBITSpecs) Execute(e *executor, req Request) Response {
+	offset, err := bitOffset(s.Offset)
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
+	}
+	bit, err := e.store.KV.GetBit(s.Key, offset)
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
+	}
+	return &response{data: NewEncoder().Integer(bit)}
+}
+
+// bitField replies with an integer for each op, nil for writes prevented
+// by OVERFLOW FAIL
+func bitField(e *executor, key string, ops []BitFieldOp) Response {
+	replies, err := e.store.KV.BitField(key, ops)
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data, propagate: []Token{}}
+	}
+	encoded := [][]byte{}
+	for _, reply := range replies {
+		if reply == nil {
+			encoded = append(encoded, NewEncoder().BulkString(nil))
+		} else {
+			encoded = append(encoded, NewEncoder().Integer(int(*reply)))
+		}
+	}
+	res := &response{data: NewEncoder().ArrayRaw(encoded)}
+	if !slices.ContainsFunc(ops, func(op BitFieldOp) bool { return op.Op != BITFIELD_GET }) {
+		// Nothing to replay for reads only
+		res.propagate = []Token{}
+	}
+	return res
+}
+
+func (s *BITFIELDSpecs) Execute(e *executor, req Request) Response {
+	return bitField(e, s.Key, s.Ops)
+}
+
+func (s *BITFIELD_ROSpecs) Execute(e *executor, req Request) Response {
+	return bitField(e, s.Key, s.Ops)
+}
+
+func (s *PFADDSpecs) Execute(e *executor, req Request) Response {
+	updated, err := e.store.HLL.Add(s.Key, s.Elements)
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
+	}
+	if !updated {
+		return &response{data: NewEncoder().Integer(0), propagate: []Token{}}
+	}
+	return &response{data: NewEncoder().Integer(1)}
+}
+
+// PFCOUNT of a single key caches the estimate in it, which is replayed on
+// replicas so that they hold the same bytes
+func (s *PFCOUNTSpecs) Execute(e *executor, req Request) Response {
+	count, stored, err := e.store.HLL.Count(s.Keys)
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
+	}
+	res := &response{data: NewEncoder().Integer(int(count))}
+	if !stored {
+		res.propagate = []Token{}
+	}
+	return res
+}
+
+func (s *PFMERGESpecs) Execute(e *executor, req Request) Response {
+	err := e.store.HLL.Merge(s.Destination, s.Keys)
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
+	}
+	return &response{data: NewEncoder().Ok()}
+}
+
+func (s *BITCOUNTSpecs) Execute(e *executor, req Request) Response {
+	count, err := e.store.KV.BitCount(s.Key, s.Range)
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
+	}
+	return &response{data: NewEncoder().Integer(count)}
+}
+
+func (s *BITPOSSpecs) Execute(e *executor, req Request) Response {
+	pos, err := e.store.KV.BitPos(s.Key, int(s.Bit), s.Range)
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
+	}
+	return &response{data: NewEncoder().Integer(int(pos))}
+}
+
+func (s *BITOPSpecs) Execute(e *executor, req Request) Response {
+	op := strings.ToUpper(s.Operation)
+	switch {
+	case op == BITOP_NOT && len(s.Keys) != 1:
+		return &response{data: NewEncoder().SimpleError("ERR BITOP NOT must be called with a single source key.")}
+	case op == BITOP_DIFF && len(s.Keys) < 2:
+		return &response{data: NewEncoder().SimpleError("ERR BITOP DIFF must be called with at least two source keys.")}
+	case op != BITOP_AND && op != BITOP_OR && op != BITOP_XOR && op != BITOP_NOT && op != BITOP_DIFF:
+		return &response{data: NewEncoder().SimpleError("ERR syntax error")}
+	}
+	length, err := e.store.KV.BitOp(op, s.DestKey, s.Keys)
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
+	}
+	return &response{data: NewEncoder().Integer(length)}
+}
+
+func bulkStrings(members []string) []Token {
+	tkns := make([]Token, 0, len(members))
+	for _, member := range members {
+		tkns = append(tkns, NewToken(BULK_STRING, member))
+	}
+	return tkns
+}
+
+func (s *SADDSpecs) Execute(e *executor, req Request) Response {
+	added, err := e.store.Set.Add(s.Key, s.Members)
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
+	}
+	return &response{data: NewEncoder().Integer(added)}
+}
+
+func (s *SREMSpecs) Execute(e *executor, req Request) Response {
+	removed, err := e.store.Set.Remove(s.Key, s.Members)
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
+	}
+	return &response{data: NewEncoder().Integer(removed)}
+}
+
+func (s *SMEMBERSSpecs) Execute(e *executor, req Request) Response {
+	members, err := e.store.Set.Members(s.Key)
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
+	}
+	return &response{data: NewEncoder().Array(bulkStrings(members)...)}
+}
+
+func (s *SISMEMBERSpecs) Execute(e *executor, req Request) Response {
+	found, err := e.store.Set.IsMember(s.Key, []string{s.Member})
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
+	}
+	if found[0] {
+		return &response{data: NewEncoder().Integer(1)}
+	}
+	return &response{data: NewEncoder().Integer(0)}
+}
+
+func (s *SMISMEMBERSpecs) Execute(e *executor, req Request) Response {
+	found, err := e.store.Set.IsMember(s.Key, s.Members)
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
+	}
+	tkns := make([]Token, 0, len(found))
+	for _, ok := range found {
+		if ok {
+			tkns = append(tkns, NewToken(INTEGER, 1))
+		} else {
+			tkns = append(tkns, NewToken(INTEGER, 0))
+		}
+	}
+	return &response{data: NewEncoder().Array(tkns...)}
+}
+
+func (s *SCARDSpecs) Execute(e *executor, req Request) Response {
+	card, err := e.store.Set.Card(s.Key)
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
+	}
+	return &response{data: NewEncoder().Integer(card)}
+}
+
+// SPOP picks members at random, so replicas are sent the SREM of what was
+// actually popped
+func (s *SPOPSpecs) Execute(e *executor, req Request) Response {
+	count := 1
+	if s.Count != nil {
+		if *s.Count < 0 {
+			return &response{data: NewEncoder().SimpleError("ERR value is out of range, must be positive")}
+		}
+		count = int(*s.Count)
+	}
+	popped, err := e.store.Set.Pop(s.Key, count)
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
+	}
+	res := &response{propagate: []Token{}}
+	if s.Count != nil {
+		res.data = NewEncoder().Array(bulkStrings(popped)...)
+	} else if len(popped) == 0 {
+		res.data = NewEncoder().BulkString(nil)
+	} else {
+		res.data = NewEncoder().BulkString(&popped[0])
+	}
+	if len(popped) > 0 {
+		res.propagate = append(res.propagate, NewToken(BULK_STRING, SREM), NewToken(BULK_STRING, s.Key))
+		for _, member := range popped {
+			res.propagate = append(res.propagate, NewToken(BULK_STRING, member))
+		}
+	}
+	return res
+}
+
+func (s *SRANDMEMBERSpecs) Execute(e *executor, req Request) Response {
+	count := 1
+	if s.Count != nil {
+		// Negating lower counts would overflow
+		if *s.Count < -math.MaxInt64/2 {
+			return &response{data: NewEncoder().SimpleError("ERR value is out of range")}
+		}
+		count = int(*s.Count)
+	}
+	members, err := e.store.Set.RandMember(s.Key, count)
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
+	}
+	if s.Count != nil {
+		return &response{data: NewEncoder().Array(bulkStrings(members)...)}
+	}
+	if len(members) == 0 {
+		return &response{data: NewEncoder().BulkString(nil)}
+	}
+	return &response{data: NewEncoder().BulkString(&members[0])}
+}
+
+func (s *SMOVESpecs) Execute(e *executor, req Request) Response {
+	moved, err := e.store.Set.Move(s.Source, s.Destination, s.Member)
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
+	}
+	if !moved {
+		return &response{data: NewEncoder().Integer(0), propagate: []Token{}}
+	}
+	return &response{data: NewEncoder().Integer(1)}
+}
+
+func (s *SSCANSpecs) Execute(e *executor, req Request) Response {
+	next, members, err := e.store.Set.Scan(s.Key, s.Cursor, s.Options)
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
+	}
+	return scanReply(next, members)
+}
+
+func combineSets(e *executor, op string, keys []string) Response {
+	members, err := e.store.Set.Combine(op, keys)
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
+	}
+	return &response{data: NewEncoder().Array(bulkStrings(members)...)}
+}
+
+func combineSetsStore(e *executor, op string, dest string, keys []string) Response {
+	size, err := e.store.Set.CombineStore(op, dest, keys)
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
+	}
+	return &response{data: NewEncoder().Integer(size)}
+}
+
+func (s *SINTERSpecs) Execute(e *executor, req Request) Response {
+	return combineSets(e, SET_OP_INTER, s.Keys)
+}
+
+func (s *SUNIONSpecs) Execute(e *executor, req Request) Response {
+	return combineSets(e, SET_OP_UNION, s.Keys)
+}
+
+func (s *SDIFFSpecs) Execute(e *executor, req Request) Response {
+	return combineSets(e, SET_OP_DIFF, s.Keys)
+}
+
+func (s *SINTERSTORESpecs) Execute(e *executor, req Request) Response {
+	return combineSetsStore(e, SET_OP_INTER, s.Destination, s.Keys)
+}
+
+func (s *SUNIONSTORESpecs) Execute(e *executor, req Request) Response {
+	return combineSetsStore(e, SET_OP_UNION, s.Destination, s.Keys)
+}
+
+func (s *SDIFFSTORESpecs) Execute(e *executor, req Request) Response {
+	return combineSetsStore(e, SET_OP_DIFF, s.Destination, s.Keys)
+}
+
+func (s *SINTERCARDSpecs) Execute(e *executor, req Request) Response {
+	count, err := e.store.Set.InterCard(s.Keys, int(s.Limit))
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
+	}
+	return &response{data: NewEncoder().Integer(count)}
+}
+
+func (s *RPOPSpecs) Execute(e *executor, req Request) Response {
+	return listPop(e, s.Key, s.Count, true)
+}
+
+func (s *LINDEXSpecs) Execute(e *executor, req Request) Response {
+	value, err := e.store.List.Index(s.Key, s.Index)
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
+	}
+	return &response{data: NewEncoder().BulkString(value)}
+}
+
+func (s *LSETSpecs) Execute(e *executor, req Request) Response {
+	err := e.store.List.Set(s.Key, s.Index, s.Element)
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
+	}
+	return &response{data: NewEncoder().Ok()}
+}
+
+func (s *LINSERTSpecs) Execute(e *executor, req Request) Response {
+	var before bool
+	switch strings.ToUpper(s.Where) {
+	case "BEFORE":
+		before = true
+	case "AFTER":
+	default:
+		return &response{data: NewEncoder().SimpleError("ERR syntax error")}
+	}
+	length, err := e.store.List.Insert(s.Key, s.Pivot, s.Element, before)
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
+	}
+	return &response{data: NewEncoder().Integer(length)}
+}
+
+func (s *LREMSpecs) Execute(e *executor, req Request) Response {
+	removed, err := e.store.List.Remove(s.Key, s.Count, s.Element)
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
+	}
+	return &response{data: NewEncoder().Integer(removed)}
+}
+
+func (s *LTRIMSpecs) Execute(e *executor, req Request) Response {
+	err := e.store.List.Trim(s.Key, s.Start, s.Stop)
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
+	}
+	return &response{data: NewEncoder().Ok()}
+}
+
+func (s *LPOSSpecs) Execute(e *executor, req Request) Response {
+	positions, err := e.store.List.Pos(s.Key, s.Element, s.Options)
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
+	}
+	if !s.WithCount {
+		if len(positions) == 0 {
+			return &response{data: NewEncoder().BulkString(nil)}
+		}
+		return &response{data: NewEncoder().Integer(positions[0])}
+	}
+	tkns := make([]Token, 0, len(positions))
+	for _, pos := range positions {
+		tkns = append(tkns, NewToken(INTEGER, pos))
+	}
+	return &response{data: NewEncoder().Array(tkns...)}
+}
+
+func (s *LPUSHXSpecs) Execute(e *executor, req Request) Response {
+	length, err := e.store.List.Prepend(s.Key, s.Elements, true)
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
+	}
+	return &response{data: NewEncoder().Integer(length)}
+}
+
+func (s *RPUSHXSpecs) Execute(e *executor, req Request) Response {
+	length, err := e.store.List.Push(s.Key, s.Elements, true)
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data}
+	}
+	return &response{data: NewEncoder().Integer(length)}
+}
+
+func (spec *BRPOPSpecs) Execute(e *executor, req Request) Response {
+	return block(e, req, spec)
+}
+
+func (spec *BRPOPSpecs) WaitKeys() []string {
+	return spec.Keys
+}
+
+func (spec *BRPOPSpecs) Timeout() *float64 {
+	return spec.Lifetime
+}
+
+func (spec *BRPOPSpecs) serve(e *executor, key string) Response {
+	return blockingPop(e, key, true)
+}
+
+func (spec *BRPOPSpecs) timedOut() []byte {
+	return NewEncoder().NullArray()
+}
+
+// listMove moves an element between the given sides of src and dest, nil
+// when src has none. Replicas are sent the equivalent LMOVE, which is
+// deterministic
+func listMove(e *executor, src string, dest string, from string, to string) Response {
+	moved, err := e.store.List.Move(src, dest, from == "RIGHT", to == "RIGHT")
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data, propagate: []Token{}}
+	}
+	if moved == nil {
+		return nil
+	}
+	return &response{
+		data:      NewEncoder().BulkString(moved),
+		propagate: bulkStrings([]string{LMOVE, src, dest, from, to}),
+	}
+}
+
+func (spec *LMOVESpecs) Execute(e *executor, req Request) Response {
+	res := listMove(e, spec.Source, spec.Destination, spec.WhereFrom, spec.WhereTo)
+	if res == nil {
+		return &response{data: NewEncoder().BulkString(nil), propagate: []Token{}}
+	}
+	return res
+}
+
+func (spec *BLMOVESpecs) Execute(e *executor, req Request) Response {
+	return block(e, req, spec)
+}
+
+func (spec *BLMOVESpecs) WaitKeys() []string {
+	return []string{spec.Source}
+}
+
+func (spec *BLMOVESpecs) Timeout() *float64 {
+	return spec.Lifetime
+}
+
+func (spec *BLMOVESpecs) serve(e *executor, key string) Response {
+	return listMove(e, spec.Source, spec.Destination, spec.WhereFrom, spec.WhereTo)
+}
+
+func (spec *BLMOVESpecs) timedOut() []byte {
+	return NewEncoder().BulkString(nil)
+}
+
+// BRPOPLPUSH is BLMOVE from the right of source to the left of destination
+func (spec *BRPOPLPUSHSpecs) Execute(e *executor, req Request) Response {
+	return block(e, req, spec)
+}
+
+func (spec *BRPOPLPUSHSpecs) WaitKeys() []string {
+	return []string{spec.Source}
+}
+
+func (spec *BRPOPLPUSHSpecs) Timeout() *float64 {
+	return spec.Lifetime
+}
+
+func (spec *BRPOPLPUSHSpecs) serve(e *executor, key string) Response {
+	return listMove(e, spec.Source, spec.Destination, "RIGHT", "LEFT")
+}
+
+func (spec *BRPOPLPUSHSpecs) timedOut() []byte {
+	return NewEncoder().BulkString(nil)
+}
+
+// multiPop pops up to count elements from the where side of key for LMPOP
+// and BLMPOP, nil when key has none. Replicas are sent the LPOP or RPOP of
+// key with the count
+func multiPop(e *executor, key string, where string, count int64) Response {
+	pop, cmd := e.store.List.Pop, LPOP
+	if where == "RIGHT" {
+		pop, cmd = e.store.List.PopTail, RPOP
+	}
+	popped, err := pop(key, int(count))
+	if hasErr, data := EncodeError(err, NewEncoder()); hasErr {
+		return &response{data: data, propagate: []Token{}}
+	}
+	if len(popped) == 0 {
+		return nil
+	}
+	return &response{
+		data: NewEncoder().ArrayRaw([][]byte{
+			NewEncoder().BulkString(&key),
+			NewEncoder().Array(bulkStrings(popped)...),
+		}),
+		propagate: bulkStrings([]string{cmd, key, strconv.Itoa(len(popped))}),
+	}
+}
+
+func (spec *LMPOPSpecs) Execute(e *executor, req Request) Response {
+	for _, key := range spec.Keys {
+		if res := multiPop(e, key, spec.Where, spec.Count); res != nil {
+			return res
+		}
+	}
+	return &response{data: NewEncoder().NullArray(), propagate: []Token{}}
+}
+
+func (spec *BLMPOPSpecs) Execute(e *executor, req Request) Response {
+	return block(e, req, spec)
+}
+
+func (spec *BLMPOPSpecs) WaitKeys() []string {
+	return spec.Keys
+}
+
+func (spec *BLMPOPSpecs) Timeout() *float64 {
+	return spec.Lifetime
+}
+
+func (spec *BLMPOPSpecs) serve(e *executor, key string) Response {
+	return multiPop(e, key, spec.Where, spec.Count)
+}
+
+func (spec *BLMPOPSpecs) timedOut() []byte {
+	return NewEncoder().NullArray()
+}
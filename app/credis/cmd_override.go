@@ -2,8 +2,10 @@ package credis
 
 import (
 	"fmt"
+	"math"
 	"strconv"
 	"strings"
+	"time"
 )
 
 func (s *ECHOSpecs) Parse(args ...Token) error {
@@ -38,25 +40,56 @@ func (s *REPLCONFSpecs) ParseFlags(args ...Token) error {
 }
 
 func (s *SETSpecs) ParseTail(args ...Token) error {
-	index := 0
-	for index < len(args)-1 {
+	if isAllString, invalidIndex := IsAllString(args); !isAllString {
+		return fmt.Errorf("ERR arg at index %v has invalid type", invalidIndex)
+	}
+	hasExpiry := false
+	for index := 0; index < len(args); index++ {
 		subCmd := strings.ToLower(args[index].Literal.(string))
 		switch subCmd {
-		case "px":
-			if len(args) <= index+1 {
-				return fmt.Errorf("no value for px option in SET command")
+		case "nx":
+			if s.Xx {
+				return &ErrSyntax{}
 			}
-			subCmdVal := args[index+1].Literal.(string)
-
-			pxVal, err := strconv.ParseUint(subCmdVal, 10, 64)
+			s.Nx = true
+		case "xx":
+			if s.Nx {
+				return &ErrSyntax{}
+			}
+			s.Xx = true
+		case "get":
+			s.Get = true
+		case "keepttl":
+			if hasExpiry {
+				return &ErrSyntax{}
+			}
+			s.KeepTtl = true
+		case "ex", "px", "exat", "pxat":
+			if hasExpiry || s.KeepTtl || len(args) <= index+1 {
+				return &ErrSyntax{}
+			}
+			hasExpiry = true
+			val, err := strconv.ParseInt(args[index+1].Literal.(string), 10, 64)
 			if err != nil {
-				return fmt.Errorf("invalid value for px option in SET command")
+				return &ErrNotInteger{data: args[index+1].Literal}
 			}
-			s.Px = &pxVal
+			if val <= 0 {
+				return &ErrInvalidExpireTime{cmd: SET}
+			}
+			switch subCmd {
+			case "ex":
+				s.Ex = &val
+			case "px":
+				s.Px = &val
+			case "exat":
+				s.Exat = &val
+			case "pxat":
+				s.Pxat = &val
+			}
+			index++
 		default:
-			return fmt.Errorf("invalid option %v passed for SET", subCmd)
+			return &ErrSyntax{}
 		}
-		index += 2
 	}
 	return nil
 }
@@ -72,54 +105,1158 @@ func (spec *XADDSpecs) Parse(args ...Token) error {
 	// Possible values
 	// - number-number
 	// - number-*
+	// - number, with a 0 sequence
 	// - *
 	spec.Key = args[0].Literal.(string)
 	streamId := args[1].Literal.(string)
-	if streamId == "*" {
-		return nil
-	}
-	ids := strings.Split(streamId, "-")
-	if len(ids) == 2 {
-		for index, id := range ids {
-			if index == 1 && id == "*" {
-				continue
-			}
-			if val, err := strconv.ParseInt(id, 10, 64); err != nil {
-				return fmt.Errorf("invalid stream id for command XADD")
-			} else {
-				if index == 0 {
-					spec.Id = &val
-				} else {
-					spec.Seq = &val
-				}
-			}
+	if ms, found := strings.CutSuffix(streamId, "-*"); found {
+		val, err := strconv.ParseUint(ms, 10, 64)
+		if err != nil {
+			return fmt.Errorf("ERR Invalid stream ID specified as stream command argument")
 		}
+		spec.Id = &val
+	} else if streamId != "*" {
+		id, err := parseStreamID(args[1], 0)
+		if err != nil {
+			return err
+		}
+		spec.Id = &id.Ms
+		spec.Seq = &id.Seq
 	}
-	i := 2
-	for i < len(args[2:])-1 {
+	for i := 2; i+1 < len(args); i += 2 {
 		key := args[i].Literal.(string)
 		value := args[i+1].Literal.(string)
 		spec.KVs = append(spec.KVs, KeyValue{
 			Key:   key,
 			Value: value,
 		})
-		i += 2
 	}
 	return nil
 }
 
-func (specs *BLPOPSpecs) Parse(args ...Token) error {
+// parseStreamID parses a <ms>-<seq> stream ID, missingSeq being the sequence
+// of an incomplete <ms> ID
+func parseStreamID(arg Token, missingSeq uint64) (StreamID, error) {
+	literal := arg.Literal.(string)
+	ms, seq, hasSeq := strings.Cut(literal, "-")
+	id := StreamID{Seq: missingSeq}
+	var err error
+	if id.Ms, err = strconv.ParseUint(ms, 10, 64); err != nil {
+		return id, fmt.Errorf("ERR Invalid stream ID specified as stream command argument")
+	}
+	if !hasSeq {
+		return id, nil
+	}
+	if id.Seq, err = strconv.ParseUint(seq, 10, 64); err != nil {
+		return id, fmt.Errorf("ERR Invalid stream ID specified as stream command argument")
+	}
+	return id, nil
+}
+
+// parseStreamRangeID parses a bound of XRANGE and XREVRANGE, "-" and "+"
+// being the smallest and largest IDs and a "(" prefix excluding the ID
+func parseStreamRangeID(arg Token, start bool) (StreamID, error) {
+	literal := arg.Literal.(string)
+	exclusive := len(literal) > 1 && literal[0] == '('
+	if exclusive {
+		literal = literal[1:]
+	}
+	var id StreamID
+	var err error
+	switch {
+	case literal == "-" && !exclusive:
+		id = MIN_STREAM_ID
+	case literal == "+" && !exclusive:
+		id = MAX_STREAM_ID
+	case start:
+		id, err = parseStreamID(NewToken(BULK_STRING, literal), 0)
+	default:
+		id, err = parseStreamID(NewToken(BULK_STRING, literal), math.MaxUint64)
+	}
+	if err != nil || !exclusive {
+		return id, err
+	}
+	var ok bool
+	if start {
+		if id, ok = id.Next(); !ok {
+			return id, fmt.Errorf("ERR invalid start ID for the interval")
+		}
+	} else if id, ok = id.Prev(); !ok {
+		return id, fmt.Errorf("ERR invalid end ID for the interval")
+	}
+	return id, nil
+}
+
+// parseStreamRange parses the start and end IDs and COUNT of XRANGE and
+// XREVRANGE, count being -1 without COUNT
+func parseStreamRange(args ...Token) (key string, start StreamID, end StreamID, count int64, err error) {
+	if isAllString, invalidIndex := IsAllString(args); !isAllString {
+		err = fmt.Errorf("ERR arg at index %v has invalid type", invalidIndex)
+		return
+	}
+	key = args[0].Literal.(string)
+	if start, err = parseStreamRangeID(args[1], true); err != nil {
+		return
+	}
+	if end, err = parseStreamRangeID(args[2], false); err != nil {
+		return
+	}
+	count = -1
+	if len(args) == 3 {
+		return
+	}
+	if len(args) != 5 || !strings.EqualFold(args[3].Literal.(string), "COUNT") {
+		err = &ErrSyntax{}
+		return
+	}
+	if count, err = strconv.ParseInt(args[4].Literal.(string), 10, 64); err != nil {
+		err = &ErrNotInteger{data: args[4].Literal}
+		return
+	}
+	count = max(count, 0)
+	return
+}
+
+func (spec *XRANGESpecs) Parse(args ...Token) (err error) {
+	spec.Key, spec.Start, spec.End, spec.Count, err = parseStreamRange(args...)
+	return
+}
+
+func (spec *XREVRANGESpecs) Parse(args ...Token) (err error) {
+	// The end comes first, swap it with the start
+	swapped := append([]Token{args[0], args[2], args[1]}, args[3:]...)
+	spec.Key, spec.Start, spec.End, spec.Count, err = parseStreamRange(swapped...)
+	return
+}
+
+func (spec *XREADSpecs) Parse(args ...Token) error {
+	if isAllString, invalidIndex := IsAllString(args); !isAllString {
+		return fmt.Errorf("ERR arg at index %v has invalid type", invalidIndex)
+	}
+	i := 0
+	for ; i < len(args); i++ {
+		option := strings.ToUpper(args[i].Literal.(string))
+		if option == "STREAMS" {
+			break
+		}
+		if (option != "COUNT" && option != "BLOCK") || i+1 == len(args) {
+			return &ErrSyntax{}
+		}
+		i++
+		val, err := strconv.ParseInt(args[i].Literal.(string), 10, 64)
+		if option == "BLOCK" {
+			if err != nil {
+				return fmt.Errorf("ERR timeout is not an integer or out of range")
+			}
+			if val < 0 {
+				return fmt.Errorf("ERR timeout is negative")
+			}
+			spec.Block = &val
+			continue
+		}
+		if err != nil {
+			return &ErrNotInteger{data: args[i].Literal}
+		}
+		spec.Count = max(val, 0)
+	}
+	streams := args[min(i+1, len(args)):]
+	if i == len(args) || len(streams) == 0 {
+		return &ErrSyntax{}
+	}
+	if len(streams)%2 != 0 {
+		return fmt.Errorf("ERR Unbalanced 'xread' list of streams: for each stream key an ID or '$' must be specified.")
+	}
+	keys, ids := streams[:len(streams)/2], streams[len(streams)/2:]
+	for j, key := range keys {
+		spec.Keys = append(spec.Keys, key.Literal.(string))
+		var cursor StreamCursor
+		switch ids[j].Literal.(string) {
+		case "$":
+			cursor.New = true
+		case "+":
+			cursor.Last = true
+		default:
+			id, err := parseStreamID(ids[j], 0)
+			if err != nil {
+				return err
+			}
+			cursor.ID = id
+		}
+		spec.Cursors = append(spec.Cursors, cursor)
+	}
+	return nil
+}
+
+// parseTimeout parses the timeout of blocking commands in seconds, nil for
+// 0 which blocks forever
+func parseTimeout(arg Token) (*float64, error) {
+	timeout, err := strconv.ParseFloat(arg.Literal.(string), 64)
+	if err != nil || math.IsNaN(timeout) || math.IsInf(timeout, 0) {
+		return nil, fmt.Errorf("ERR timeout is not a float or out of range")
+	}
+	if timeout < 0 {
+		return nil, fmt.Errorf("ERR timeout is negative")
+	}
+	if timeout == 0 {
+		return nil, nil
+	}
+	return &timeout, nil
+}
+
+// parseListSide checks that where is LEFT or RIGHT and returns it in upper
+// case
+func parseListSide(where Token) (string, error) {
+	side := strings.ToUpper(where.Literal.(string))
+	if side != "LEFT" && side != "RIGHT" {
+		return "", &ErrSyntax{}
+	}
+	return side, nil
+}
+
+// parseBlockingPop parses the keys and trailing timeout of BLPOP and BRPOP
+func parseBlockingPop(args ...Token) (keys []string, timeout *float64, err error) {
+	if isAllString, invalidIndex := IsAllString(args); !isAllString {
+		err = fmt.Errorf("ERR arg at index %v has invalid type", invalidIndex)
+		return
+	}
+	for _, key := range args[:len(args)-1] {
+		keys = append(keys, key.Literal.(string))
+	}
+	timeout, err = parseTimeout(args[len(args)-1])
+	return
+}
+
+func (specs *BLPOPSpecs) Parse(args ...Token) (err error) {
+	specs.Keys, specs.Lifetime, err = parseBlockingPop(args...)
+	return
+}
+
+func (specs *BRPOPSpecs) Parse(args ...Token) (err error) {
+	specs.Keys, specs.Lifetime, err = parseBlockingPop(args...)
+	return
+}
+
+// parseMove parses the source, destination and sides of LMOVE and BLMOVE
+func parseMove(args ...Token) (src string, dest string, from string, to string, err error) {
+	if isAllString, invalidIndex := IsAllString(args); !isAllString {
+		err = fmt.Errorf("ERR arg at index %v has invalid type", invalidIndex)
+		return
+	}
+	src, dest = args[0].Literal.(string), args[1].Literal.(string)
+	if from, err = parseListSide(args[2]); err != nil {
+		return
+	}
+	to, err = parseListSide(args[3])
+	return
+}
+
+func (specs *LMOVESpecs) Parse(args ...Token) (err error) {
+	specs.Source, specs.Destination, specs.WhereFrom, specs.WhereTo, err = parseMove(args...)
+	return
+}
+
+func (specs *BLMOVESpecs) Parse(args ...Token) (err error) {
+	specs.Source, specs.Destination, specs.WhereFrom, specs.WhereTo, err = parseMove(args[:4]...)
+	if err != nil {
+		return
+	}
+	specs.Lifetime, err = parseTimeout(args[4])
+	return
+}
+
+func (specs *BRPOPLPUSHSpecs) Parse(args ...Token) (err error) {
+	if isAllString, invalidIndex := IsAllString(args); !isAllString {
+		return fmt.Errorf("ERR arg at index %v has invalid type", invalidIndex)
+	}
+	specs.Source, specs.Destination = args[0].Literal.(string), args[1].Literal.(string)
+	specs.Lifetime, err = parseTimeout(args[2])
+	return
+}
+
+// parseZSetSide checks that where is MIN or MAX and returns it in upper case
+func parseZSetSide(where Token) (string, error) {
+	side := strings.ToUpper(where.Literal.(string))
+	if side != "MIN" && side != "MAX" {
+		return "", &ErrSyntax{}
+	}
+	return side, nil
+}
+
+// parseMultiPop parses the numkeys, keys, side and COUNT option shared by
+// LMPOP, BLMPOP, ZMPOP and BZMPOP, the side being checked by parseSide.
+// count defaults to 1
+func parseMultiPop(parseSide func(Token) (string, error), args ...Token) (keys []string, where string, count int64, err error) {
+	keys, rest, err := parseNumKeys(args...)
+	if err != nil {
+		return
+	}
+	if len(rest) == 0 {
+		err = &ErrSyntax{}
+		return
+	}
+	if where, err = parseSide(rest[0]); err != nil {
+		return
+	}
+	count = 1
+	switch {
+	case len(rest) == 1:
+	case len(rest) == 3 && strings.ToUpper(rest[1].Literal.(string)) == "COUNT":
+		count, err = strconv.ParseInt(rest[2].Literal.(string), 10, 64)
+		if err != nil || count <= 0 {
+			err = fmt.Errorf("ERR count should be greater than 0")
+		}
+	default:
+		err = &ErrSyntax{}
+	}
+	return
+}
+
+func (specs *LMPOPSpecs) Parse(args ...Token) (err error) {
+	specs.Keys, specs.Where, specs.Count, err = parseMultiPop(parseListSide, args...)
+	return
+}
+
+func (specs *BLMPOPSpecs) Parse(args ...Token) (err error) {
+	if isAllString, invalidIndex := IsAllString(args); !isAllString {
+		return fmt.Errorf("ERR arg at index %v has invalid type", invalidIndex)
+	}
+	if specs.Lifetime, err = parseTimeout(args[0]); err != nil {
+		return
+	}
+	specs.Keys, specs.Where, specs.Count, err = parseMultiPop(parseListSide, args[1:]...)
+	return
+}
+
+func (specs *BZPOPMINSpecs) Parse(args ...Token) (err error) {
+	specs.Keys, specs.Lifetime, err = parseBlockingPop(args...)
+	return
+}
+
+func (specs *BZPOPMAXSpecs) Parse(args ...Token) (err error) {
+	specs.Keys, specs.Lifetime, err = parseBlockingPop(args...)
+	return
+}
+
+func (specs *ZMPOPSpecs) Parse(args ...Token) (err error) {
+	specs.Keys, specs.Where, specs.Count, err = parseMultiPop(parseZSetSide, args...)
+	return
+}
+
+func (specs *BZMPOPSpecs) Parse(args ...Token) (err error) {
+	if isAllString, invalidIndex := IsAllString(args); !isAllString {
+		return fmt.Errorf("ERR arg at index %v has invalid type", invalidIndex)
+	}
+	if specs.Lifetime, err = parseTimeout(args[0]); err != nil {
+		return
+	}
+	specs.Keys, specs.Where, specs.Count, err = parseMultiPop(parseZSetSide, args[1:]...)
+	return
+}
+
+func parseExpireArgs(args ...Token) (key string, when int64, cond ExpireCondition, err error) {
+	if isAllString, invalidIndex := IsAllString(args); !isAllString {
+		err = fmt.Errorf("ERR arg at index %v has invalid type", invalidIndex)
+		return
+	}
+	key = args[0].Literal.(string)
+	when, err = strconv.ParseInt(args[1].Literal.(string), 10, 64)
+	if err != nil {
+		err = &ErrNotInteger{data: args[1].Literal}
+		return
+	}
+	for _, arg := range args[2:] {
+		switch strings.ToUpper(arg.Literal.(string)) {
+		case "NX":
+			cond.NX = true
+		case "XX":
+			cond.XX = true
+		case "GT":
+			cond.GT = true
+		case "LT":
+			cond.LT = true
+		default:
+			err = fmt.Errorf("ERR Unsupported option %v", arg.Literal)
+			return
+		}
+	}
+	if cond.NX && (cond.XX || cond.GT || cond.LT) {
+		err = fmt.Errorf("ERR NX and XX, GT or LT options at the same time are not compatible")
+	} else if cond.GT && cond.LT {
+		err = fmt.Errorf("ERR GT and LT options at the same time are not compatible")
+	}
+	return
+}
+
+func (s *EXPIRESpecs) Parse(args ...Token) (err error) {
+	s.Key, s.Seconds, s.Condition, err = parseExpireArgs(args...)
+	return
+}
+
+func (s *PEXPIRESpecs) Parse(args ...Token) (err error) {
+	s.Key, s.Milliseconds, s.Condition, err = parseExpireArgs(args...)
+	return
+}
+
+func (s *EXPIREATSpecs) Parse(args ...Token) (err error) {
+	s.Key, s.UnixTimeSeconds, s.Condition, err = parseExpireArgs(args...)
+	return
+}
+
+func (s *PEXPIREATSpecs) Parse(args ...Token) (err error) {
+	s.Key, s.UnixTimeMilliseconds, s.Condition, err = parseExpireArgs(args...)
+	return
+}
+
+// parseScanArgs parses `cursor [MATCH pattern] [COUNT count]`, plus TYPE for
+// SCAN and NOVALUES for HSCAN
+func parseScanArgs(cmd string, args ...Token) (cursor uint64, opts ScanOptions, err error) {
 	if isAllString, invalidIndex := IsAllString(args); !isAllString {
-		return fmt.Errorf("arg at index %v has invalid type for LLEN", invalidIndex)
+		err = fmt.Errorf("ERR arg at index %v has invalid type", invalidIndex)
+		return
+	}
+	cursor, err = strconv.ParseUint(args[0].Literal.(string), 10, 64)
+	if err != nil {
+		err = &ErrInvalidCursor{}
+		return
 	}
-	length := len(args)
-	for _, key := range args[:length-1] {
-		specs.Keys = append(specs.Keys, key.Literal.(string))
+	opts.Count = 10
+	for i := 1; i < len(args); i++ {
+		option := strings.ToUpper(args[i].Literal.(string))
+		switch {
+		case option == "NOVALUES" && cmd == HSCAN:
+			opts.NoValues = true
+			continue
+		case i+1 == len(args):
+			err = &ErrSyntax{}
+			return
+		case option == "MATCH":
+			opts.Pattern = args[i+1].Literal.(string)
+			if opts.Pattern == "*" {
+				opts.Pattern = ""
+			}
+		case option == "COUNT":
+			opts.Count, err = strconv.ParseInt(args[i+1].Literal.(string), 10, 64)
+			if err != nil {
+				err = &ErrNotInteger{data: args[i+1].Literal}
+				return
+			}
+			if opts.Count < 1 {
+				err = &ErrSyntax{}
+				return
+			}
+		case option == "TYPE" && cmd == SCAN:
+			opts.Type = args[i+1].Literal.(string)
+		default:
+			err = &ErrSyntax{}
+			return
+		}
+		i++
 	}
-	if parsed, err := strconv.ParseFloat(args[length-1].Literal.(string), 64); err != nil {
-		specs.Keys = append(specs.Keys, args[length-1].Literal.(string))
-	} else if parsed != 0 {
-		specs.Lifetime = &parsed
+	return
+}
+
+func (s *SCANSpecs) Parse(args ...Token) (err error) {
+	s.Cursor, s.Options, err = parseScanArgs(SCAN, args...)
+	s.CurrentTime = time.Now()
+	return
+}
+
+func (s *ZSCANSpecs) Parse(args ...Token) (err error) {
+	s.Key = args[0].Literal.(string)
+	s.Cursor, s.Options, err = parseScanArgs(ZSCAN, args[1:]...)
+	return
+}
+
+// parseScoreBound parses one end of a score range, excluded when prefixed
+// with "("
+func parseScoreBound(arg Token) (value float64, exclusive bool, err error) {
+	literal, exclusive := strings.CutPrefix(arg.Literal.(string), "(")
+	value, ok := parseFloat(literal)
+	if !ok {
+		err = fmt.Errorf("ERR min or max is not a float")
+	}
+	return
+}
+
+func parseScoreRange(min Token, max Token) (r ScoreRange, err error) {
+	if r.min, r.minExclusive, err = parseScoreBound(min); err != nil {
+		return
+	}
+	r.max, r.maxExclusive, err = parseScoreBound(max)
+	return
+}
+
+// parseLexBound parses one end of a lex range, "[a" includes a, "(a"
+// excludes it and "-" and "+" are the infinities
+func parseLexBound(arg Token) (lexBound, error) {
+	literal := arg.Literal.(string)
+	switch {
+	case literal == "-":
+		return lexBound{inf: -1}, nil
+	case literal == "+":
+		return lexBound{inf: 1}, nil
+	case strings.HasPrefix(literal, "["):
+		return lexBound{value: literal[1:]}, nil
+	case strings.HasPrefix(literal, "("):
+		return lexBound{value: literal[1:], exclusive: true}, nil
+	}
+	return lexBound{}, fmt.Errorf("ERR min or max not valid string range item")
+}
+
+func parseLexRange(min Token, max Token) (r LexRange, err error) {
+	if r.min, err = parseLexBound(min); err != nil {
+		return
+	}
+	r.max, err = parseLexBound(max)
+	return
+}
+
+// parseZRange parses the range and options of ZRANGE and ZRANGESTORE. The
+// legacy forms like ZRANGEBYSCORE imply By and Rev and accept fewer options
+func parseZRange(cmd string, args ...Token) (opts ZRangeOptions, withScores bool, err error) {
+	if isAllString, invalidIndex := IsAllString(args); !isAllString {
+		err = fmt.Errorf("ERR arg at index %v has invalid type", invalidIndex)
+		return
+	}
+	opts.By, opts.Count = ZRANGE_BY_RANK, -1
+	switch cmd {
+	case ZREVRANGE:
+		opts.Rev = true
+	case ZRANGEBYSCORE, ZREVRANGEBYSCORE, ZREMRANGEBYSCORE:
+		opts.By, opts.Rev = ZRANGE_BY_SCORE, cmd == ZREVRANGEBYSCORE
+	case ZRANGEBYLEX, ZREVRANGEBYLEX, ZREMRANGEBYLEX:
+		opts.By, opts.Rev = ZRANGE_BY_LEX, cmd == ZREVRANGEBYLEX
+	}
+	unified := cmd == ZRANGE || cmd == ZRANGESTORE
+	limit := false
+	for i := 2; i < len(args); i++ {
+		switch option := strings.ToUpper(args[i].Literal.(string)); {
+		case unified && option == "BYSCORE":
+			opts.By = ZRANGE_BY_SCORE
+		case unified && option == "BYLEX":
+			opts.By = ZRANGE_BY_LEX
+		case unified && option == "REV":
+			opts.Rev = true
+		case option == "LIMIT" && i+2 < len(args):
+			if opts.Offset, err = strconv.ParseInt(args[i+1].Literal.(string), 10, 64); err != nil {
+				err = &ErrNotInteger{data: args[i+1].Literal}
+				return
+			}
+			if opts.Count, err = strconv.ParseInt(args[i+2].Literal.(string), 10, 64); err != nil {
+				err = &ErrNotInteger{data: args[i+2].Literal}
+				return
+			}
+			limit = true
+			i += 2
+		case cmd != ZRANGESTORE && option == "WITHSCORES":
+			withScores = true
+		default:
+			err = &ErrSyntax{}
+			return
+		}
+	}
+	if limit && opts.By == ZRANGE_BY_RANK {
+		err = fmt.Errorf("ERR syntax error, LIMIT is only supported in combination with either BYSCORE or BYLEX")
+		return
+	}
+	if withScores && opts.By == ZRANGE_BY_LEX {
+		err = fmt.Errorf("ERR syntax error, WITHSCORES not supported in combination with BYLEX")
+		return
+	}
+	// Score and lex ranges are given from the max when reversed
+	min, max := args[0], args[1]
+	if opts.Rev && opts.By != ZRANGE_BY_RANK {
+		min, max = max, min
+	}
+	switch opts.By {
+	case ZRANGE_BY_SCORE:
+		opts.Scores, err = parseScoreRange(min, max)
+	case ZRANGE_BY_LEX:
+		opts.Lex, err = parseLexRange(min, max)
+	default:
+		if opts.Start, err = strconv.ParseInt(args[0].Literal.(string), 10, 64); err != nil {
+			err = &ErrNotInteger{data: args[0].Literal}
+			return
+		}
+		if opts.Stop, err = strconv.ParseInt(args[1].Literal.(string), 10, 64); err != nil {
+			err = &ErrNotInteger{data: args[1].Literal}
+		}
+	}
+	return
+}
+
+func (s *ZRANGESpecs) Parse(args ...Token) (err error) {
+	s.Key = args[0].Literal.(string)
+	s.Options, s.WithScores, err = parseZRange(ZRANGE, args[1:]...)
+	return
+}
+
+func (s *ZRANGESTORESpecs) Parse(args ...Token) (err error) {
+	s.Destination, s.Source = args[0].Literal.(string), args[1].Literal.(string)
+	s.Options, _, err = parseZRange(ZRANGESTORE, args[2:]...)
+	return
+}
+
+func (s *ZREVRANGESpecs) Parse(args ...Token) (err error) {
+	s.Key = args[0].Literal.(string)
+	s.Options, s.WithScores, err = parseZRange(ZREVRANGE, args[1:]...)
+	return
+}
+
+func (s *ZRANGEBYSCORESpecs) Parse(args ...Token) (err error) {
+	s.Key = args[0].Literal.(string)
+	s.Options, s.WithScores, err = parseZRange(ZRANGEBYSCORE, args[1:]...)
+	return
+}
+
+func (s *ZREVRANGEBYSCORESpecs) Parse(args ...Token) (err error) {
+	s.Key = args[0].Literal.(string)
+	s.Options, s.WithScores, err = parseZRange(ZREVRANGEBYSCORE, args[1:]...)
+	return
+}
+
+func (s *ZRANGEBYLEXSpecs) Parse(args ...Token) (err error) {
+	s.Key = args[0].Literal.(string)
+	s.Options, s.WithScores, err = parseZRange(ZRANGEBYLEX, args[1:]...)
+	return
+}
+
+func (s *ZREVRANGEBYLEXSpecs) Parse(args ...Token) (err error) {
+	s.Key = args[0].Literal.(string)
+	s.Options, s.WithScores, err = parseZRange(ZREVRANGEBYLEX, args[1:]...)
+	return
+}
+
+func (s *ZREMRANGEBYRANKSpecs) Parse(args ...Token) (err error) {
+	s.Key = args[0].Literal.(string)
+	s.Options, _, err = parseZRange(ZREMRANGEBYRANK, args[1:]...)
+	return
+}
+
+func (s *ZREMRANGEBYSCORESpecs) Parse(args ...Token) (err error) {
+	s.Key = args[0].Literal.(string)
+	s.Options, _, err = parseZRange(ZREMRANGEBYSCORE, args[1:]...)
+	return
+}
+
+func (s *ZREMRANGEBYLEXSpecs) Parse(args ...Token) (err error) {
+	s.Key = args[0].Literal.(string)
+	s.Options, _, err = parseZRange(ZREMRANGEBYLEX, args[1:]...)
+	return
+}
+
+// Parse reads the flags of ZADD up to the first score, then the score and
+// member pairs
+func (s *ZADDSpecs) Parse(args ...Token) error {
+	if isAllString, invalidIndex := IsAllString(args); !isAllString {
+		return fmt.Errorf("ERR arg at index %v has invalid type", invalidIndex)
+	}
+	s.Key = args[0].Literal.(string)
+	i := 1
+flags:
+	for ; i < len(args); i++ {
+		switch strings.ToUpper(args[i].Literal.(string)) {
+		case "NX":
+			s.Options.NX = true
+		case "XX":
+			s.Options.XX = true
+		case "GT":
+			s.Options.GT = true
+		case "LT":
+			s.Options.LT = true
+		case "CH":
+			s.Options.CH = true
+		case "INCR":
+			s.Options.Incr = true
+		default:
+			break flags
+		}
+	}
+	pairs := args[i:]
+	if len(pairs) == 0 || len(pairs)%2 != 0 {
+		return &ErrSyntax{}
+	}
+	opts := s.Options
+	if opts.NX && opts.XX {
+		return fmt.Errorf("ERR XX and NX options at the same time are not compatible")
+	}
+	if opts.GT && opts.LT || opts.NX && (opts.GT || opts.LT) {
+		return fmt.Errorf("ERR GT, LT, and/or NX options at the same time are not compatible")
+	}
+	if opts.Incr && len(pairs) > 2 {
+		return fmt.Errorf("ERR INCR option supports a single increment-element pair")
+	}
+	s.Members = nil
+	for j := 0; j < len(pairs); j += 2 {
+		score, ok := parseFloat(pairs[j].Literal.(string))
+		if !ok {
+			return &ErrNotFloat{}
+		}
+		s.Members = append(s.Members, ZMember{Member: pairs[j+1].Literal.(string), Score: score})
+	}
+	return nil
+}
+
+// Parse reads the optional count of ZRANDMEMBER, WITHSCORES only being
+// accepted along with it
+func (s *ZRANDMEMBERSpecs) Parse(args ...Token) error {
+	if isAllString, invalidIndex := IsAllString(args); !isAllString {
+		return fmt.Errorf("ERR arg at index %v has invalid type", invalidIndex)
+	}
+	s.Key = args[0].Literal.(string)
+	if len(args) == 1 {
+		return nil
+	}
+	count, err := strconv.ParseInt(args[1].Literal.(string), 10, 64)
+	if err != nil {
+		return &ErrNotInteger{data: args[1].Literal}
+	}
+	s.Count = &count
+	if len(args) == 3 {
+		if !strings.EqualFold(args[2].Literal.(string), "WITHSCORES") {
+			return &ErrSyntax{}
+		}
+		s.WithScores = true
+	}
+	return nil
+}
+
+func (s *ZCOUNTSpecs) Parse(args ...Token) (err error) {
+	if isAllString, invalidIndex := IsAllString(args); !isAllString {
+		return fmt.Errorf("ERR arg at index %v has invalid type", invalidIndex)
+	}
+	s.Key = args[0].Literal.(string)
+	s.Scores, err = parseScoreRange(args[1], args[2])
+	return
+}
+
+func (s *ZLEXCOUNTSpecs) Parse(args ...Token) (err error) {
+	if isAllString, invalidIndex := IsAllString(args); !isAllString {
+		return fmt.Errorf("ERR arg at index %v has invalid type", invalidIndex)
+	}
+	s.Key = args[0].Literal.(string)
+	s.Lex, err = parseLexRange(args[1], args[2])
+	return
+}
+
+func parseKeyValues(cmd string, args ...Token) ([]KeyValue, error) {
+	if isAllString, invalidIndex := IsAllString(args); !isAllString {
+		return nil, fmt.Errorf("ERR arg at index %v has invalid type", invalidIndex)
+	}
+	if len(args)%2 != 0 {
+		return nil, fmt.Errorf("ERR wrong number of arguments for '%s' command", cmd)
+	}
+	kvs := make([]KeyValue, 0, len(args)/2)
+	for i := 0; i < len(args); i += 2 {
+		kvs = append(kvs, KeyValue{
+			Key:   args[i].Literal.(string),
+			Value: args[i+1].Literal.(string),
+		})
+	}
+	return kvs, nil
+}
+
+func (s *MSETSpecs) Parse(args ...Token) (err error) {
+	s.KVs, err = parseKeyValues(MSET, args...)
+	return
+}
+
+func (s *MSETNXSpecs) Parse(args ...Token) (err error) {
+	s.KVs, err = parseKeyValues(MSETNX, args...)
+	return
+}
+
+func (s *GETEXSpecs) ParseTail(args ...Token) error {
+	if isAllString, invalidIndex := IsAllString(args); !isAllString {
+		return fmt.Errorf("ERR arg at index %v has invalid type", invalidIndex)
+	}
+	if len(args) == 0 {
+		return nil
+	}
+	option := strings.ToLower(args[0].Literal.(string))
+	if option == "persist" && len(args) == 1 {
+		s.Persist = true
+		return nil
+	}
+	if len(args) != 2 {
+		return &ErrSyntax{}
+	}
+	val, err := strconv.ParseInt(args[1].Literal.(string), 10, 64)
+	if err != nil {
+		return &ErrNotInteger{data: args[1].Literal}
+	}
+	if val <= 0 {
+		return &ErrInvalidExpireTime{cmd: GETEX}
+	}
+	switch option {
+	case "ex":
+		s.Ex = &val
+	case "px":
+		s.Px = &val
+	case "exat":
+		s.Exat = &val
+	case "pxat":
+		s.Pxat = &val
+	default:
+		return &ErrSyntax{}
+	}
+	return nil
+}
+
+func (s *HSCANSpecs) Parse(args ...Token) (err error) {
+	s.Key = args[0].Literal.(string)
+	s.Cursor, s.Options, err = parseScanArgs(HSCAN, args[1:]...)
+	return
+}
+
+func (s *HSETSpecs) Parse(args ...Token) (err error) {
+	s.Key = args[0].Literal.(string)
+	s.Fields, err = parseKeyValues(HSET, args[1:]...)
+	return
+}
+
+// parseBitRange parses `[start [end [BYTE|BIT]]]`. withoutEnd allows start
+// alone, as BITPOS does
+func parseBitRange(withoutEnd bool, args ...Token) (r BitRange, err error) {
+	if isAllString, invalidIndex := IsAllString(args); !isAllString {
+		err = fmt.Errorf("ERR arg at index %v has invalid type", invalidIndex)
+		return
+	}
+	if len(args) > 3 || (len(args) == 1 && !withoutEnd) {
+		err = &ErrSyntax{}
+		return
+	}
+	bounds := []**int64{&r.Start, &r.End}
+	for i := 0; i < len(args) && i < 2; i++ {
+		val, parseErr := strconv.ParseInt(args[i].Literal.(string), 10, 64)
+		if parseErr != nil {
+			err = &ErrNotInteger{data: args[i].Literal}
+			return
+		}
+		*bounds[i] = &val
+	}
+	if len(args) == 3 {
+		switch strings.ToUpper(args[2].Literal.(string)) {
+		case "BYTE":
+		case "BIT":
+			r.Bit = true
+		default:
+			err = &ErrSyntax{}
+		}
+	}
+	return
+}
+
+func (s *BITCOUNTSpecs) Parse(args ...Token) (err error) {
+	s.Key = args[0].Literal.(string)
+	s.Range, err = parseBitRange(false, args[1:]...)
+	return
+}
+
+func (s *BITPOSSpecs) Parse(args ...Token) (err error) {
+	if isAllString, invalidIndex := IsAllString(args); !isAllString {
+		return fmt.Errorf("ERR arg at index %v has invalid type", invalidIndex)
+	}
+	s.Key = args[0].Literal.(string)
+	s.Bit, err = strconv.ParseInt(args[1].Literal.(string), 10, 64)
+	if err != nil || (s.Bit != 0 && s.Bit != 1) {
+		return &ErrBitArgument{}
+	}
+	s.Range, err = parseBitRange(true, args[2:]...)
+	return
+}
+
+func (s *SSCANSpecs) Parse(args ...Token) (err error) {
+	s.Key = args[0].Literal.(string)
+	s.Cursor, s.Options, err = parseScanArgs(SSCAN, args[1:]...)
+	return
+}
+
+// parseBitFieldType parses a field type like i16 or u8. Unsigned fields
+// are at most 63 bits so that their values fit in a signed reply
+func parseBitFieldType(arg Token) (signed bool, bits int, err error) {
+	err = fmt.Errorf("ERR Invalid bitfield type. Use something like i16 u8. Note that u64 is not supported but i64 is.")
+	literal := strings.ToLower(arg.Literal.(string))
+	if len(literal) < 2 || literal[0] != 'i' && literal[0] != 'u' {
+		return
+	}
+	signed = literal[0] == 'i'
+	bits, convErr := strconv.Atoi(literal[1:])
+	if convErr != nil || bits < 1 || bits > 64 || !signed && bits == 64 {
+		return
+	}
+	return signed, bits, nil
+}
+
+// parseBitFieldOffset parses the offset of a field, counted in fields of
+// bits bits when prefixed with "#"
+func parseBitFieldOffset(arg Token, bits int) (int64, error) {
+	literal, positional := strings.CutPrefix(arg.Literal.(string), "#")
+	offset, err := strconv.ParseInt(literal, 10, 64)
+	if err != nil || offset < 0 {
+		return 0, &ErrBitOffset{}
+	}
+	if positional {
+		if offset > MAX_BIT_OFFSET/int64(bits) {
+			return 0, &ErrBitOffset{}
+		}
+		offset *= int64(bits)
+	}
+	if offset > MAX_BIT_OFFSET {
+		return 0, &ErrBitOffset{}
+	}
+	return offset, nil
+}
+
+// parseBitField parses the subcommands of BITFIELD, only GET being allowed
+// with readOnly as for BITFIELD_RO. OVERFLOW applies to the writes
+// following it
+func parseBitField(readOnly bool, args ...Token) ([]BitFieldOp, error) {
+	if isAllString, invalidIndex := IsAllString(args); !isAllString {
+		return nil, fmt.Errorf("ERR arg at index %v has invalid type", invalidIndex)
+	}
+	ops := []BitFieldOp{}
+	overflow := BITFIELD_OVERFLOW_WRAP
+	for i := 0; i < len(args); {
+		sub := strings.ToLower(args[i].Literal.(string))
+		if readOnly && sub != BITFIELD_GET {
+			return nil, fmt.Errorf("ERR BITFIELD_RO only supports the GET subcommand")
+		}
+		switch {
+		case sub == "overflow" && i+1 < len(args):
+			switch mode := strings.ToLower(args[i+1].Literal.(string)); mode {
+			case BITFIELD_OVERFLOW_WRAP, BITFIELD_OVERFLOW_SAT, BITFIELD_OVERFLOW_FAIL:
+				overflow = mode
+			default:
+				return nil, fmt.Errorf("ERR Invalid OVERFLOW type specified")
+			}
+			i += 2
+		case sub == BITFIELD_GET && i+2 < len(args),
+			(sub == BITFIELD_SET || sub == BITFIELD_INCRBY) && i+3 < len(args):
+			op := BitFieldOp{Op: sub, Overflow: overflow}
+			var err error
+			if op.Signed, op.Bits, err = parseBitFieldType(args[i+1]); err != nil {
+				return nil, err
+			}
+			if op.Offset, err = parseBitFieldOffset(args[i+2], op.Bits); err != nil {
+				return nil, err
+			}
+			i += 3
+			if sub != BITFIELD_GET {
+				if op.Value, err = strconv.ParseInt(args[i].Literal.(string), 10, 64); err != nil {
+					return nil, &ErrNotInteger{data: args[i].Literal}
+				}
+				i++
+			}
+			ops = append(ops, op)
+		default:
+			return nil, &ErrSyntax{}
+		}
+	}
+	return ops, nil
+}
+
+func (s *BITFIELDSpecs) Parse(args ...Token) (err error) {
+	s.Key = args[0].Literal.(string)
+	s.Ops, err = parseBitField(false, args[1:]...)
+	return
+}
+
+func (s *BITFIELD_ROSpecs) Parse(args ...Token) (err error) {
+	s.Key = args[0].Literal.(string)
+	s.Ops, err = parseBitField(true, args[1:]...)
+	return
+}
+
+// parseNumKeys parses the `numkeys key [key ...]` prefix of multi key
+// commands and returns the arguments following the keys
+func parseNumKeys(args ...Token) (keys []string, rest []Token, err error) {
+	if isAllString, invalidIndex := IsAllString(args); !isAllString {
+		err = fmt.Errorf("ERR arg at index %v has invalid type", invalidIndex)
+		return
+	}
+	numKeys, err := strconv.ParseInt(args[0].Literal.(string), 10, 64)
+	if err != nil {
+		err = &ErrNotInteger{data: args[0].Literal}
+		return
+	}
+	if numKeys <= 0 {
+		err = fmt.Errorf("ERR numkeys should be greater than 0")
+		return
+	}
+	if numKeys > int64(len(args)-1) {
+		err = fmt.Errorf("ERR Number of keys can't be greater than number of args")
+		return
+	}
+	for _, arg := range args[1 : numKeys+1] {
+		keys = append(keys, arg.Literal.(string))
+	}
+	return keys, args[numKeys+1:], nil
+}
+
+// parseInterCard parses the numkeys, keys and LIMIT option shared by
+// SINTERCARD and ZINTERCARD
+func parseInterCard(args ...Token) (keys []string, limit int64, err error) {
+	keys, rest, err := parseNumKeys(args...)
+	if err != nil {
+		return
+	}
+	if len(rest) == 0 {
+		return
+	}
+	if len(rest) != 2 || !strings.EqualFold(rest[0].Literal.(string), "LIMIT") {
+		err = &ErrSyntax{}
+		return
+	}
+	limit, err = strconv.ParseInt(rest[1].Literal.(string), 10, 64)
+	if err != nil {
+		err = &ErrNotInteger{data: rest[1].Literal}
+		return
+	}
+	if limit < 0 {
+		err = fmt.Errorf("ERR LIMIT can't be negative")
+	}
+	return
+}
+
+func (s *SINTERCARDSpecs) Parse(args ...Token) (err error) {
+	s.Keys, s.Limit, err = parseInterCard(args...)
+	return
+}
+
+func (s *ZINTERCARDSpecs) Parse(args ...Token) (err error) {
+	s.Keys, s.Limit, err = parseInterCard(args...)
+	return
+}
+
+// parseZCombine parses the numkeys, keys and options of ZUNION, ZINTER,
+// ZDIFF and their STORE forms. Only ZUNION and ZINTER take WEIGHTS and
+// AGGREGATE, and only the forms replying with members take WITHSCORES
+func parseZCombine(cmd string, args ...Token) (keys []string, opts ZCombineOptions, withScores bool, err error) {
+	if isAllString, invalidIndex := IsAllString(args); !isAllString {
+		err = fmt.Errorf("ERR arg at index %v has invalid type", invalidIndex)
+		return
+	}
+	numKeys, err := strconv.ParseInt(args[0].Literal.(string), 10, 64)
+	if err != nil {
+		err = &ErrNotInteger{data: args[0].Literal}
+		return
+	}
+	if numKeys < 1 {
+		err = fmt.Errorf("ERR at least 1 input key is needed for '%s' command", cmd)
+		return
+	}
+	if numKeys > int64(len(args)-1) {
+		err = &ErrSyntax{}
+		return
+	}
+	for _, arg := range args[1 : numKeys+1] {
+		keys = append(keys, arg.Literal.(string))
+	}
+	opts.Aggregate = ZAGGREGATE_SUM
+	weighted := cmd != ZDIFF && cmd != ZDIFFSTORE
+	store := cmd == ZUNIONSTORE || cmd == ZINTERSTORE || cmd == ZDIFFSTORE
+	rest := args[numKeys+1:]
+	for i := 0; i < len(rest); i++ {
+		switch option := strings.ToUpper(rest[i].Literal.(string)); {
+		case weighted && option == "WEIGHTS" && i+len(keys) < len(rest):
+			opts.Weights = make([]float64, len(keys))
+			for j := range keys {
+				weight, ok := parseFloat(rest[i+1+j].Literal.(string))
+				if !ok {
+					err = fmt.Errorf("ERR weight value is not a float")
+					return
+				}
+				opts.Weights[j] = weight
+			}
+			i += len(keys)
+		case weighted && option == "AGGREGATE" && i+1 < len(rest):
+			switch aggregate := strings.ToLower(rest[i+1].Literal.(string)); aggregate {
+			case ZAGGREGATE_SUM, ZAGGREGATE_MIN, ZAGGREGATE_MAX:
+				opts.Aggregate = aggregate
+			default:
+				err = &ErrSyntax{}
+				return
+			}
+			i++
+		case !store && option == "WITHSCORES":
+			withScores = true
+		default:
+			err = &ErrSyntax{}
+			return
+		}
+	}
+	return
+}
+
+func (s *ZUNIONSTORESpecs) Parse(args ...Token) (err error) {
+	s.Destination = args[0].Literal.(string)
+	s.Keys, s.Options, _, err = parseZCombine(ZUNIONSTORE, args[1:]...)
+	return
+}
+
+func (s *ZINTERSTORESpecs) Parse(args ...Token) (err error) {
+	s.Destination = args[0].Literal.(string)
+	s.Keys, s.Options, _, err = parseZCombine(ZINTERSTORE, args[1:]...)
+	return
+}
+
+func (s *ZDIFFSTORESpecs) Parse(args ...Token) (err error) {
+	s.Destination = args[0].Literal.(string)
+	s.Keys, _, _, err = parseZCombine(ZDIFFSTORE, args[1:]...)
+	return
+}
+
+func (s *ZUNIONSpecs) Parse(args ...Token) (err error) {
+	s.Keys, s.Options, s.WithScores, err = parseZCombine(ZUNION, args...)
+	return
+}
+
+func (s *ZINTERSpecs) Parse(args ...Token) (err error) {
+	s.Keys, s.Options, s.WithScores, err = parseZCombine(ZINTER, args...)
+	return
+}
+
+func (s *ZDIFFSpecs) Parse(args ...Token) (err error) {
+	s.Keys, _, s.WithScores, err = parseZCombine(ZDIFF, args...)
+	return
+}
+
+func (s *LPOSSpecs) Parse(args ...Token) error {
+	if isAllString, invalidIndex := IsAllString(args); !isAllString {
+		return fmt.Errorf("ERR arg at index %v has invalid type", invalidIndex)
+	}
+	s.Key = args[0].Literal.(string)
+	s.Element = args[1].Literal.(string)
+	s.Options = ListPosOptions{Rank: 1, Count: 1}
+	for i := 2; i < len(args); i += 2 {
+		if i+1 == len(args) {
+			return &ErrSyntax{}
+		}
+		val, err := strconv.ParseInt(args[i+1].Literal.(string), 10, 64)
+		if err != nil {
+			return &ErrNotInteger{data: args[i+1].Literal}
+		}
+		switch strings.ToUpper(args[i].Literal.(string)) {
+		case "RANK":
+			if val == 0 {
+				return fmt.Errorf("ERR RANK can't be zero: use 1 to start from the first match, 2 from the second ... or use negative to start from the end of the list")
+			}
+			s.Options.Rank = val
+		case "COUNT":
+			if val < 0 {
+				return fmt.Errorf("ERR COUNT can't be negative")
+			}
+			s.Options.Count = val
+			s.WithCount = true
+		case "MAXLEN":
+			if val < 0 {
+				return fmt.Errorf("ERR MAXLEN can't be negative")
+			}
+			s.Options.MaxLen = val
+		default:
+			return &ErrSyntax{}
+		}
 	}
 	return nil
 }
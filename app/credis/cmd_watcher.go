@@ -5,77 +5,42 @@ import (
 )
 
 type Watcher interface {
-	Add(clientId string) *CmdNotifier
-	Start()
-	Stop()
-	Send() chan<- string
+	Add(client Client)
+	Notify(key string)
 	Cancel(clientId string)
 }
 
-type CmdNotifier struct {
-	C <-chan string
-}
-
 type cmdWatcher struct {
-	mu   sync.RWMutex
-	recv chan string
+	mu sync.RWMutex
 
 	// Clients will be added here
-	listeners map[string]chan string
+	listeners map[string]Client
 }
 
 func NewWatcher() Watcher {
-	c := make(chan string)
 	return &cmdWatcher{
-		recv:      c,
-		listeners: make(map[string]chan string),
+		listeners: make(map[string]Client),
 	}
 }
 
-func (w *cmdWatcher) Send() chan<- string {
-	return w.recv
-}
-
-func (w *cmdWatcher) Start() {
-	for cmd := range w.recv {
-		go func() {
-			w.mu.RLock()
-			listeners := w.listeners
-			w.mu.RUnlock()
-			for _, l := range listeners {
-				l <- cmd
-			}
-		}()
+// Notify marks key as written for the clients watching it. It returns once
+// they all know, so a transaction started after the write fails
+func (w *cmdWatcher) Notify(key string) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	for _, c := range w.listeners {
+		c.MakeDirty(key)
 	}
 }
 
-func (w *cmdWatcher) Stop() {
+func (w *cmdWatcher) Add(client Client) {
 	w.mu.Lock()
 	defer w.mu.Unlock()
-	close(w.recv)
-}
-
-func (w *cmdWatcher) Add(clientId string) *CmdNotifier {
-	w.mu.Lock()
-	defer w.mu.Unlock()
-	c := w.listeners[clientId]
-	if c == nil {
-		c = make(chan string)
-		w.listeners[clientId] = c
-	}
-	notifier := &CmdNotifier{
-		C: c,
-	}
-	return notifier
+	w.listeners[client.Id()] = client
 }
 
 func (w *cmdWatcher) Cancel(clientId string) {
 	w.mu.Lock()
 	defer w.mu.Unlock()
-	c := w.listeners[clientId]
-	if c == nil {
-		return
-	}
 	delete(w.listeners, clientId)
-	close(c)
 }
@@ -12,47 +12,164 @@ import (
 type Cmd string
 
 const (
-	ECHO        = "echo"
-	COMMAND     = "command"
-	PING        = "ping"
-	SET         = "set"
-	GET         = "get"
-	INCR        = "incr"
-	MULTI       = "multi"
-	EXEC        = "exec"
-	DISCARD     = "discard"
-	INFO        = "info"
-	REPLCONF    = "replconf"
-	PSYNC       = "psync"
-	CONFIG      = "config"
-	KEYS        = "keys"
-	XADD        = "xadd"
-	TYPE        = "type"
-	RPUSH       = "rpush"
-	LRANGE      = "lrange"
-	LPUSH       = "lpush"
-	LLEN        = "llen"
-	LPOP        = "lpop"
-	BLPOP       = "blpop"
-	WAIT        = "wait"
-	SUBSCRIBE   = "subscribe"
-	UNSUBSCRIBE = "unsubscribe"
-	QUIT        = "quit"
-	PUBLISH     = "publish"
-	ACL_WHOAMI  = "acl_whoami"
-	ACL_GETUSER = "acl_getuser"
-	ACL_SETUSER = "acl_setuser"
-	AUTH        = "auth"
-	ZADD        = "zadd"
-	ZRANK       = "zrank"
-	ZRANGE      = "zrange"
-	ZCARD       = "zcard"
-	ZSCORE      = "zscore"
-	ZREM        = "zrem"
-	WATCH       = "watch"
-	UNWATCH     = "unwatch"
-	GEOADD      = "geoadd"
-	GEOPOS      = "geopos"
+	ECHO             = "echo"
+	COMMAND          = "command"
+	PING             = "ping"
+	SET              = "set"
+	GET              = "get"
+	INCR             = "incr"
+	INCRBY           = "incrby"
+	DECR             = "decr"
+	DECRBY           = "decrby"
+	INCRBYFLOAT      = "incrbyfloat"
+	MULTI            = "multi"
+	EXEC             = "exec"
+	DISCARD          = "discard"
+	INFO             = "info"
+	REPLCONF         = "replconf"
+	PSYNC            = "psync"
+	CONFIG           = "config"
+	KEYS             = "keys"
+	XADD             = "xadd"
+	XLEN             = "xlen"
+	XRANGE           = "xrange"
+	XREVRANGE        = "xrevrange"
+	XREAD            = "xread"
+	XINFO_STREAM     = "xinfo_stream"
+	TYPE             = "type"
+	RPUSH            = "rpush"
+	LRANGE           = "lrange"
+	LPUSH            = "lpush"
+	LLEN             = "llen"
+	LPOP             = "lpop"
+	BLPOP            = "blpop"
+	WAIT             = "wait"
+	SUBSCRIBE        = "subscribe"
+	UNSUBSCRIBE      = "unsubscribe"
+	QUIT             = "quit"
+	PUBLISH          = "publish"
+	ACL_WHOAMI       = "acl_whoami"
+	ACL_GETUSER      = "acl_getuser"
+	ACL_SETUSER      = "acl_setuser"
+	AUTH             = "auth"
+	ZADD             = "zadd"
+	ZRANK            = "zrank"
+	ZRANGE           = "zrange"
+	ZCARD            = "zcard"
+	ZSCORE           = "zscore"
+	ZREM             = "zrem"
+	WATCH            = "watch"
+	UNWATCH          = "unwatch"
+	GEOADD           = "geoadd"
+	GEOPOS           = "geopos"
+	DEL              = "del"
+	UNLINK           = "unlink"
+	EXISTS           = "exists"
+	EXPIRE           = "expire"
+	PEXPIRE          = "pexpire"
+	EXPIREAT         = "expireat"
+	PEXPIREAT        = "pexpireat"
+	TTL              = "ttl"
+	PTTL             = "pttl"
+	EXPIRETIME       = "expiretime"
+	PEXPIRETIME      = "pexpiretime"
+	PERSIST          = "persist"
+	SCAN             = "scan"
+	ZSCAN            = "zscan"
+	APPEND           = "append"
+	STRLEN           = "strlen"
+	GETRANGE         = "getrange"
+	SETRANGE         = "setrange"
+	MGET             = "mget"
+	MSET             = "mset"
+	MSETNX           = "msetnx"
+	GETDEL           = "getdel"
+	GETEX            = "getex"
+	GETSET           = "getset"
+	HSET             = "hset"
+	HSETNX           = "hsetnx"
+	HGET             = "hget"
+	HMGET            = "hmget"
+	HDEL             = "hdel"
+	HGETALL          = "hgetall"
+	HINCRBY          = "hincrby"
+	HINCRBYFLOAT     = "hincrbyfloat"
+	HLEN             = "hlen"
+	HEXISTS          = "hexists"
+	HKEYS            = "hkeys"
+	HVALS            = "hvals"
+	HSTRLEN          = "hstrlen"
+	HSCAN            = "hscan"
+	SETBIT           = "setbit"
+	GETBIT           = "getbit"
+	BITCOUNT         = "bitcount"
+	BITPOS           = "bitpos"
+	BITOP            = "bitop"
+	BITFIELD         = "bitfield"
+	BITFIELD_RO      = "bitfield_ro"
+	SADD             = "sadd"
+	SREM             = "srem"
+	SMEMBERS         = "smembers"
+	SISMEMBER        = "sismember"
+	SMISMEMBER       = "smismember"
+	SCARD            = "scard"
+	SPOP             = "spop"
+	SRANDMEMBER      = "srandmember"
+	SMOVE            = "smove"
+	SSCAN            = "sscan"
+	SINTER           = "sinter"
+	SUNION           = "sunion"
+	SDIFF            = "sdiff"
+	SINTERSTORE      = "sinterstore"
+	SUNIONSTORE      = "sunionstore"
+	SDIFFSTORE       = "sdiffstore"
+	SINTERCARD       = "sintercard"
+	RPOP             = "rpop"
+	LINDEX           = "lindex"
+	LSET             = "lset"
+	LINSERT          = "linsert"
+	LREM             = "lrem"
+	LTRIM            = "ltrim"
+	LPOS             = "lpos"
+	LPUSHX           = "lpushx"
+	RPUSHX           = "rpushx"
+	BRPOP            = "brpop"
+	LMOVE            = "lmove"
+	BLMOVE           = "blmove"
+	BRPOPLPUSH       = "brpoplpush"
+	LMPOP            = "lmpop"
+	BLMPOP           = "blmpop"
+	ZRANGESTORE      = "zrangestore"
+	ZREVRANGE        = "zrevrange"
+	ZRANGEBYSCORE    = "zrangebyscore"
+	ZREVRANGEBYSCORE = "zrevrangebyscore"
+	ZRANGEBYLEX      = "zrangebylex"
+	ZREVRANGEBYLEX   = "zrevrangebylex"
+	ZREVRANK         = "zrevrank"
+	ZCOUNT           = "zcount"
+	ZLEXCOUNT        = "zlexcount"
+	ZINCRBY          = "zincrby"
+	ZMSCORE          = "zmscore"
+	ZPOPMIN          = "zpopmin"
+	ZPOPMAX          = "zpopmax"
+	BZPOPMIN         = "bzpopmin"
+	BZPOPMAX         = "bzpopmax"
+	ZMPOP            = "zmpop"
+	BZMPOP           = "bzmpop"
+	ZRANDMEMBER      = "zrandmember"
+	ZREMRANGEBYRANK  = "zremrangebyrank"
+	ZREMRANGEBYSCORE = "zremrangebyscore"
+	ZREMRANGEBYLEX   = "zremrangebylex"
+	ZUNIONSTORE      = "zunionstore"
+	ZINTERSTORE      = "zinterstore"
+	ZDIFFSTORE       = "zdiffstore"
+	ZUNION           = "zunion"
+	ZINTER           = "zinter"
+	ZDIFF            = "zdiff"
+	ZINTERCARD       = "zintercard"
+	PFADD            = "pfadd"
+	PFCOUNT          = "pfcount"
+	PFMERGE          = "pfmerge"
 )
 
 var commandRegistry = map[string]GenericSpec{
@@ -60,907 +177,3624 @@ var commandRegistry = map[string]GenericSpec{
 		MinArgs:   1,
 		MaxArgs:   -1,
 		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
 	},
 	COMMAND: {
 		MinArgs:   1,
 		MaxArgs:   -1,
 		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
 	},
 	PING: {
 		MinArgs:   0,
 		MaxArgs:   0,
 		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
 	},
 	SET: {
 		MinArgs:   2,
-		MaxArgs:   4,
+		MaxArgs:   -1,
 		Supported: true,
+		Propagate: true,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 1},
 	},
 	GET: {
 		MinArgs:   1,
 		MaxArgs:   1,
 		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
 	},
 	INCR: {
 		MinArgs:   1,
 		MaxArgs:   1,
 		Supported: true,
+		Propagate: true,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 1},
+	},
+	INCRBY: {
+		MinArgs:   2,
+		MaxArgs:   2,
+		Supported: true,
+		Propagate: true,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 1},
+	},
+	DECR: {
+		MinArgs:   1,
+		MaxArgs:   1,
+		Supported: true,
+		Propagate: true,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 1},
+	},
+	DECRBY: {
+		MinArgs:   2,
+		MaxArgs:   2,
+		Supported: true,
+		Propagate: true,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 1},
+	},
+	INCRBYFLOAT: {
+		MinArgs:   2,
+		MaxArgs:   2,
+		Supported: true,
+		Propagate: true,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 1},
 	},
 	MULTI: {
 		MinArgs:   0,
 		MaxArgs:   0,
 		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
 	},
 	EXEC: {
 		MinArgs:   0,
 		MaxArgs:   0,
 		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
 	},
 	DISCARD: {
 		MinArgs:   0,
 		MaxArgs:   0,
 		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
 	},
 	INFO: {
 		MinArgs:   1,
 		MaxArgs:   1,
 		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
 	},
 	REPLCONF: {
 		MinArgs:   2,
 		MaxArgs:   2,
 		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
 	},
 	PSYNC: {
 		MinArgs:   2,
 		MaxArgs:   2,
 		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
 	},
 	CONFIG: {
 		MinArgs:   2,
 		MaxArgs:   2,
 		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
 	},
 	KEYS: {
 		MinArgs:   1,
 		MaxArgs:   1,
 		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
 	},
 	XADD: {
 		MinArgs:   4,
 		MaxArgs:   -1,
 		Supported: true,
+		Propagate: true,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 1},
+	},
+	XLEN: {
+		MinArgs:   1,
+		MaxArgs:   1,
+		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
+	},
+	XRANGE: {
+		MinArgs:   3,
+		MaxArgs:   5,
+		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
+	},
+	XREVRANGE: {
+		MinArgs:   3,
+		MaxArgs:   5,
+		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
+	},
+	XREAD: {
+		MinArgs:   3,
+		MaxArgs:   -1,
+		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
+	},
+	XINFO_STREAM: {
+		MinArgs:   1,
+		MaxArgs:   1,
+		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
 	},
 	TYPE: {
 		MinArgs:   1,
 		MaxArgs:   1,
 		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
 	},
 	RPUSH: {
 		MinArgs:   2,
 		MaxArgs:   -1,
 		Supported: true,
+		Propagate: true,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 1},
 	},
 	LRANGE: {
 		MinArgs:   3,
 		MaxArgs:   3,
 		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
 	},
 	LPUSH: {
 		MinArgs:   2,
 		MaxArgs:   -1,
 		Supported: true,
+		Propagate: true,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 1},
 	},
 	LLEN: {
 		MinArgs:   1,
 		MaxArgs:   1,
 		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
 	},
 	LPOP: {
 		MinArgs:   1,
 		MaxArgs:   2,
 		Supported: true,
+		Propagate: true,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 1},
 	},
 	BLPOP: {
-		MinArgs:   1,
+		MinArgs:   2,
 		MaxArgs:   -1,
 		Supported: true,
+		Propagate: true,
+		Keys:      KeySpec{First: 0, Last: -2, Step: 1},
 	},
 	WAIT: {
 		MinArgs:   2,
 		MaxArgs:   2,
 		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
 	},
 	SUBSCRIBE: {
 		MinArgs:   1,
 		MaxArgs:   1,
 		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
 	},
 	UNSUBSCRIBE: {
 		MinArgs:   1,
 		MaxArgs:   1,
 		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
 	},
 	QUIT: {
 		MinArgs:   0,
 		MaxArgs:   0,
 		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
 	},
 	PUBLISH: {
 		MinArgs:   2,
 		MaxArgs:   2,
 		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
 	},
 	ACL_WHOAMI: {
 		MinArgs:   0,
 		MaxArgs:   0,
 		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
 	},
 	ACL_GETUSER: {
 		MinArgs:   1,
 		MaxArgs:   1,
 		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
 	},
 	ACL_SETUSER: {
 		MinArgs:   2,
 		MaxArgs:   -1,
 		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
 	},
 	AUTH: {
 		MinArgs:   2,
 		MaxArgs:   2,
 		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
 	},
 	ZADD: {
 		MinArgs:   3,
-		MaxArgs:   3,
+		MaxArgs:   -1,
 		Supported: true,
+		Propagate: true,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 1},
 	},
 	ZRANK: {
 		MinArgs:   2,
 		MaxArgs:   2,
 		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
 	},
 	ZRANGE: {
 		MinArgs:   3,
-		MaxArgs:   3,
+		MaxArgs:   -1,
 		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
 	},
 	ZCARD: {
 		MinArgs:   1,
 		MaxArgs:   1,
 		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
 	},
 	ZSCORE: {
 		MinArgs:   2,
 		MaxArgs:   2,
 		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
 	},
 	ZREM: {
 		MinArgs:   2,
 		MaxArgs:   2,
 		Supported: true,
+		Propagate: true,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 1},
 	},
 	WATCH: {
 		MinArgs:   1,
 		MaxArgs:   -1,
 		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
 	},
 	UNWATCH: {
 		MinArgs:   0,
 		MaxArgs:   0,
 		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
 	},
 	GEOADD: {
 		MinArgs:   4,
 		MaxArgs:   4,
 		Supported: true,
+		Propagate: true,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 1},
 	},
 	GEOPOS: {
 		MinArgs:   1,
 		MaxArgs:   -1,
 		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
 	},
-}
-
-type FullParser interface {
-	Parse(args ...Token) error
-}
-
-type ScalerParser interface {
-	ParseScaler(args ...Token) (int, error)
-}
-
-type FlagParser interface {
-	ParseFlags(args ...Token) error
-}
-
-type VariadicTailParser interface {
-	ParseTail(args ...Token) error
-}
-
-func notImplemented(cmd string) Response {
-	return &response{
-		data: NewEncoder().SimpleError(fmt.Sprintf("ERR %v: Not Implemented", cmd)),
-	}
-}
-
-type Specs interface {
-	String() string
-}
-
-type GenericSpec struct {
-	MinArgs   int
-	MaxArgs   int
-	Supported bool
-}
-
-func GetGenericSpec(cmd string) GenericSpec {
-	return commandRegistry[cmd]
-}
-
-func ParseCmd(tkns ...Token) (int, string, error) {
-	argsIndex := 1
-	var c string
-	c = strings.ToLower(tkns[0].Literal.(string))
-	if c == "acl" && len(tkns) >= 2 {
-		subcmd := strings.ToLower(tkns[1].Literal.(string))
-		c = c + "_" + subcmd
-		argsIndex = 2
-	}
-	if !commandRegistry[c].Supported {
-		return 0, "", fmt.Errorf("ERR unsupported command")
-	}
-	return argsIndex, c, nil
-}
-
-type ECHOSpecs struct {
-	Data string
-}
-
-func (s *ECHOSpecs) String() string {
-	return ECHO
-}
-
-type COMMANDSpecs struct {
-}
-
-func (s *COMMANDSpecs) String() string {
-	return COMMAND
-}
-
-type PINGSpecs struct {
-}
-
-func (s *PINGSpecs) String() string {
-	return PING
-}
-
-type SETSpecs struct {
-	Key   string
-	Value Token
-	Px    *uint64
-}
-
-func (s *SETSpecs) String() string {
-	return SET
-}
-func (s *SETSpecs) ParseScaler(args ...Token) (int, error) {
-	strVal0 := args[0].Literal.(string)
-	s.Key = strVal0
-
-	s.Value = args[1]
-
-	return 2, nil
-}
-
-type GETSpecs struct {
-	Key         string
-	CurrentTime time.Time
-}
-
-func (s *GETSpecs) String() string {
-	return GET
-}
-func (s *GETSpecs) ParseScaler(args ...Token) (int, error) {
-	strVal0 := args[0].Literal.(string)
-	s.Key = strVal0
-
-	s.CurrentTime = time.Now()
-	return 1, nil
-}
-
-type INCRSpecs struct {
-	Key         string
-	CurrentTime time.Time
-}
-
-func (s *INCRSpecs) String() string {
-	return INCR
-}
-func (s *INCRSpecs) ParseScaler(args ...Token) (int, error) {
-	strVal0 := args[0].Literal.(string)
-	s.Key = strVal0
-
-	s.CurrentTime = time.Now()
-	return 1, nil
-}
-
-type MULTISpecs struct {
-}
-
-func (s *MULTISpecs) String() string {
-	return MULTI
-}
-
-type EXECSpecs struct {
-}
-
-func (s *EXECSpecs) String() string {
-	return EXEC
-}
-
-type DISCARDSpecs struct {
-}
-
-func (s *DISCARDSpecs) String() string {
-	return DISCARD
-}
-
-type INFOSpecs struct {
-	Section string
-}
-
-func (s *INFOSpecs) String() string {
-	return INFO
-}
-func (s *INFOSpecs) ParseScaler(args ...Token) (int, error) {
-	strVal0 := args[0].Literal.(string)
-	s.Section = strVal0
-
-	return 1, nil
-}
-
-type REPLCONFSpecs struct {
-	ListeningPort *string
-	Capability    *string
-	GetAck        *string
-}
-
-func (s *REPLCONFSpecs) String() string {
-	return REPLCONF
-}
-
-type PSYNCSpecs struct {
-	ReplicaId string
-	Offset    string
-}
-
-func (s *PSYNCSpecs) String() string {
-	return PSYNC
-}
-func (s *PSYNCSpecs) ParseScaler(args ...Token) (int, error) {
-	strVal0 := args[0].Literal.(string)
-	s.ReplicaId = strVal0
-
-	strVal1 := args[1].Literal.(string)
-	s.Offset = strVal1
-
-	return 2, nil
-}
-
-type CONFIGSpecs struct {
-	Action string
-	Key    string
-}
-
-func (s *CONFIGSpecs) String() string {
-	return CONFIG
-}
-func (s *CONFIGSpecs) ParseScaler(args ...Token) (int, error) {
-	strVal0 := args[0].Literal.(string)
-	s.Action = strVal0
-
-	strVal1 := args[1].Literal.(string)
-	s.Key = strVal1
-
-	return 2, nil
-}
-
-type KEYSSpecs struct {
-	Filter string
-}
-
-func (s *KEYSSpecs) String() string {
-	return KEYS
-}
-func (s *KEYSSpecs) ParseScaler(args ...Token) (int, error) {
-	strVal0 := args[0].Literal.(string)
-	s.Filter = strVal0
+	DEL: {
+		MinArgs:   1,
+		MaxArgs:   -1,
+		Supported: true,
+		Propagate: true,
+		Keys:      KeySpec{First: 0, Last: -1, Step: 1},
+	},
+	UNLINK: {
+		MinArgs:   1,
+		MaxArgs:   -1,
+		Supported: true,
+		Propagate: true,
+		Keys:      KeySpec{First: 0, Last: -1, Step: 1},
+	},
+	EXISTS: {
+		MinArgs:   1,
+		MaxArgs:   -1,
+		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
+	},
+	EXPIRE: {
+		MinArgs:   2,
+		MaxArgs:   -1,
+		Supported: true,
+		Propagate: true,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 1},
+	},
+	PEXPIRE: {
+		MinArgs:   2,
+		MaxArgs:   -1,
+		Supported: true,
+		Propagate: true,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 1},
+	},
+	EXPIREAT: {
+		MinArgs:   2,
+		MaxArgs:   -1,
+		Supported: true,
+		Propagate: true,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 1},
+	},
+	PEXPIREAT: {
+		MinArgs:   2,
+		MaxArgs:   -1,
+		Supported: true,
+		Propagate: true,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 1},
+	},
+	TTL: {
+		MinArgs:   1,
+		MaxArgs:   1,
+		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
+	},
+	PTTL: {
+		MinArgs:   1,
+		MaxArgs:   1,
+		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
+	},
+	EXPIRETIME: {
+		MinArgs:   1,
+		MaxArgs:   1,
+		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
+	},
+	PEXPIRETIME: {
+		MinArgs:   1,
+		MaxArgs:   1,
+		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
+	},
+	PERSIST: {
+		MinArgs:   1,
+		MaxArgs:   1,
+		Supported: true,
+		Propagate: true,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 1},
+	},
+	SCAN: {
+		MinArgs:   1,
+		MaxArgs:   -1,
+		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
+	},
+	ZSCAN: {
+		MinArgs:   2,
+		MaxArgs:   -1,
+		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
+	},
+	APPEND: {
+		MinArgs:   2,
+		MaxArgs:   2,
+		Supported: true,
+		Propagate: true,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 1},
+	},
+	STRLEN: {
+		MinArgs:   1,
+		MaxArgs:   1,
+		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
+	},
+	GETRANGE: {
+		MinArgs:   3,
+		MaxArgs:   3,
+		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
+	},
+	SETRANGE: {
+		MinArgs:   3,
+		MaxArgs:   3,
+		Supported: true,
+		Propagate: true,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 1},
+	},
+	MGET: {
+		MinArgs:   1,
+		MaxArgs:   -1,
+		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
+	},
+	MSET: {
+		MinArgs:   2,
+		MaxArgs:   -1,
+		Supported: true,
+		Propagate: true,
+		Keys:      KeySpec{First: 0, Last: -1, Step: 2},
+	},
+	MSETNX: {
+		MinArgs:   2,
+		MaxArgs:   -1,
+		Supported: true,
+		Propagate: true,
+		Keys:      KeySpec{First: 0, Last: -1, Step: 2},
+	},
+	GETDEL: {
+		MinArgs:   1,
+		MaxArgs:   1,
+		Supported: true,
+		Propagate: true,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 1},
+	},
+	GETEX: {
+		MinArgs:   1,
+		MaxArgs:   3,
+		Supported: true,
+		Propagate: true,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 1},
+	},
+	GETSET: {
+		MinArgs:   2,
+		MaxArgs:   2,
+		Supported: true,
+		Propagate: true,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 1},
+	},
+	HSET: {
+		MinArgs:   3,
+		MaxArgs:   -1,
+		Supported: true,
+		Propagate: true,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 1},
+	},
+	HSETNX: {
+		MinArgs:   3,
+		MaxArgs:   3,
+		Supported: true,
+		Propagate: true,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 1},
+	},
+	HGET: {
+		MinArgs:   2,
+		MaxArgs:   2,
+		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
+	},
+	HMGET: {
+		MinArgs:   2,
+		MaxArgs:   -1,
+		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
+	},
+	HDEL: {
+		MinArgs:   2,
+		MaxArgs:   -1,
+		Supported: true,
+		Propagate: true,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 1},
+	},
+	HGETALL: {
+		MinArgs:   1,
+		MaxArgs:   1,
+		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
+	},
+	HINCRBY: {
+		MinArgs:   3,
+		MaxArgs:   3,
+		Supported: true,
+		Propagate: true,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 1},
+	},
+	HINCRBYFLOAT: {
+		MinArgs:   3,
+		MaxArgs:   3,
+		Supported: true,
+		Propagate: true,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 1},
+	},
+	HLEN: {
+		MinArgs:   1,
+		MaxArgs:   1,
+		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
+	},
+	HEXISTS: {
+		MinArgs:   2,
+		MaxArgs:   2,
+		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
+	},
+	HKEYS: {
+		MinArgs:   1,
+		MaxArgs:   1,
+		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
+	},
+	HVALS: {
+		MinArgs:   1,
+		MaxArgs:   1,
+		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
+	},
+	HSTRLEN: {
+		MinArgs:   2,
+		MaxArgs:   2,
+		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
+	},
+	HSCAN: {
+		MinArgs:   2,
+		MaxArgs:   -1,
+		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
+	},
+	SETBIT: {
+		MinArgs:   3,
+		MaxArgs:   3,
+		Supported: true,
+		Propagate: true,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 1},
+	},
+	GETBIT: {
+		MinArgs:   2,
+		MaxArgs:   2,
+		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
+	},
+	BITCOUNT: {
+		MinArgs:   1,
+		MaxArgs:   4,
+		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
+	},
+	BITPOS: {
+		MinArgs:   2,
+		MaxArgs:   5,
+		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
+	},
+	BITOP: {
+		MinArgs:   3,
+		MaxArgs:   -1,
+		Supported: true,
+		Propagate: true,
+		Keys:      KeySpec{First: 1, Last: 1, Step: 1},
+	},
+	BITFIELD: {
+		MinArgs:   1,
+		MaxArgs:   -1,
+		Supported: true,
+		Propagate: true,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 1},
+	},
+	BITFIELD_RO: {
+		MinArgs:   1,
+		MaxArgs:   -1,
+		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
+	},
+	SADD: {
+		MinArgs:   2,
+		MaxArgs:   -1,
+		Supported: true,
+		Propagate: true,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 1},
+	},
+	SREM: {
+		MinArgs:   2,
+		MaxArgs:   -1,
+		Supported: true,
+		Propagate: true,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 1},
+	},
+	SMEMBERS: {
+		MinArgs:   1,
+		MaxArgs:   1,
+		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
+	},
+	SISMEMBER: {
+		MinArgs:   2,
+		MaxArgs:   2,
+		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
+	},
+	SMISMEMBER: {
+		MinArgs:   2,
+		MaxArgs:   -1,
+		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
+	},
+	SCARD: {
+		MinArgs:   1,
+		MaxArgs:   1,
+		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
+	},
+	SPOP: {
+		MinArgs:   1,
+		MaxArgs:   2,
+		Supported: true,
+		Propagate: true,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 1},
+	},
+	SRANDMEMBER: {
+		MinArgs:   1,
+		MaxArgs:   2,
+		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
+	},
+	SMOVE: {
+		MinArgs:   3,
+		MaxArgs:   3,
+		Supported: true,
+		Propagate: true,
+		Keys:      KeySpec{First: 0, Last: 1, Step: 1},
+	},
+	SSCAN: {
+		MinArgs:   2,
+		MaxArgs:   -1,
+		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
+	},
+	SINTER: {
+		MinArgs:   1,
+		MaxArgs:   -1,
+		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
+	},
+	SUNION: {
+		MinArgs:   1,
+		MaxArgs:   -1,
+		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
+	},
+	SDIFF: {
+		MinArgs:   1,
+		MaxArgs:   -1,
+		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
+	},
+	SINTERSTORE: {
+		MinArgs:   2,
+		MaxArgs:   -1,
+		Supported: true,
+		Propagate: true,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 1},
+	},
+	SUNIONSTORE: {
+		MinArgs:   2,
+		MaxArgs:   -1,
+		Supported: true,
+		Propagate: true,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 1},
+	},
+	SDIFFSTORE: {
+		MinArgs:   2,
+		MaxArgs:   -1,
+		Supported: true,
+		Propagate: true,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 1},
+	},
+	SINTERCARD: {
+		MinArgs:   2,
+		MaxArgs:   -1,
+		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
+	},
+	RPOP: {
+		MinArgs:   1,
+		MaxArgs:   2,
+		Supported: true,
+		Propagate: true,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 1},
+	},
+	LINDEX: {
+		MinArgs:   2,
+		MaxArgs:   2,
+		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
+	},
+	LSET: {
+		MinArgs:   3,
+		MaxArgs:   3,
+		Supported: true,
+		Propagate: true,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 1},
+	},
+	LINSERT: {
+		MinArgs:   4,
+		MaxArgs:   4,
+		Supported: true,
+		Propagate: true,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 1},
+	},
+	LREM: {
+		MinArgs:   3,
+		MaxArgs:   3,
+		Supported: true,
+		Propagate: true,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 1},
+	},
+	LTRIM: {
+		MinArgs:   3,
+		MaxArgs:   3,
+		Supported: true,
+		Propagate: true,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 1},
+	},
+	LPOS: {
+		MinArgs:   2,
+		MaxArgs:   8,
+		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
+	},
+	LPUSHX: {
+		MinArgs:   2,
+		MaxArgs:   -1,
+		Supported: true,
+		Propagate: true,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 1},
+	},
+	RPUSHX: {
+		MinArgs:   2,
+		MaxArgs:   -1,
+		Supported: true,
+		Propagate: true,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 1},
+	},
+	BRPOP: {
+		MinArgs:   2,
+		MaxArgs:   -1,
+		Supported: true,
+		Propagate: true,
+		Keys:      KeySpec{First: 0, Last: -2, Step: 1},
+	},
+	LMOVE: {
+		MinArgs:   4,
+		MaxArgs:   4,
+		Supported: true,
+		Propagate: true,
+		Keys:      KeySpec{First: 0, Last: 1, Step: 1},
+	},
+	BLMOVE: {
+		MinArgs:   5,
+		MaxArgs:   5,
+		Supported: true,
+		Propagate: true,
+		Keys:      KeySpec{First: 0, Last: 1, Step: 1},
+	},
+	BRPOPLPUSH: {
+		MinArgs:   3,
+		MaxArgs:   3,
+		Supported: true,
+		Propagate: true,
+		Keys:      KeySpec{First: 0, Last: 1, Step: 1},
+	},
+	LMPOP: {
+		MinArgs:   3,
+		MaxArgs:   -1,
+		Supported: true,
+		Propagate: true,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
+	},
+	BLMPOP: {
+		MinArgs:   4,
+		MaxArgs:   -1,
+		Supported: true,
+		Propagate: true,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
+	},
+	ZRANGESTORE: {
+		MinArgs:   4,
+		MaxArgs:   -1,
+		Supported: true,
+		Propagate: true,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 1},
+	},
+	ZREVRANGE: {
+		MinArgs:   3,
+		MaxArgs:   -1,
+		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
+	},
+	ZRANGEBYSCORE: {
+		MinArgs:   3,
+		MaxArgs:   -1,
+		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
+	},
+	ZREVRANGEBYSCORE: {
+		MinArgs:   3,
+		MaxArgs:   -1,
+		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
+	},
+	ZRANGEBYLEX: {
+		MinArgs:   3,
+		MaxArgs:   -1,
+		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
+	},
+	ZREVRANGEBYLEX: {
+		MinArgs:   3,
+		MaxArgs:   -1,
+		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
+	},
+	ZREVRANK: {
+		MinArgs:   2,
+		MaxArgs:   2,
+		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
+	},
+	ZCOUNT: {
+		MinArgs:   3,
+		MaxArgs:   3,
+		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
+	},
+	ZLEXCOUNT: {
+		MinArgs:   3,
+		MaxArgs:   3,
+		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
+	},
+	ZINCRBY: {
+		MinArgs:   3,
+		MaxArgs:   3,
+		Supported: true,
+		Propagate: true,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 1},
+	},
+	ZMSCORE: {
+		MinArgs:   2,
+		MaxArgs:   -1,
+		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
+	},
+	ZPOPMIN: {
+		MinArgs:   1,
+		MaxArgs:   2,
+		Supported: true,
+		Propagate: true,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 1},
+	},
+	ZPOPMAX: {
+		MinArgs:   1,
+		MaxArgs:   2,
+		Supported: true,
+		Propagate: true,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 1},
+	},
+	BZPOPMIN: {
+		MinArgs:   2,
+		MaxArgs:   -1,
+		Supported: true,
+		Propagate: true,
+		Keys:      KeySpec{First: 0, Last: -2, Step: 1},
+	},
+	BZPOPMAX: {
+		MinArgs:   2,
+		MaxArgs:   -1,
+		Supported: true,
+		Propagate: true,
+		Keys:      KeySpec{First: 0, Last: -2, Step: 1},
+	},
+	ZMPOP: {
+		MinArgs:   3,
+		MaxArgs:   -1,
+		Supported: true,
+		Propagate: true,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
+	},
+	BZMPOP: {
+		MinArgs:   4,
+		MaxArgs:   -1,
+		Supported: true,
+		Propagate: true,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
+	},
+	ZRANDMEMBER: {
+		MinArgs:   1,
+		MaxArgs:   3,
+		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
+	},
+	ZREMRANGEBYRANK: {
+		MinArgs:   3,
+		MaxArgs:   3,
+		Supported: true,
+		Propagate: true,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 1},
+	},
+	ZREMRANGEBYSCORE: {
+		MinArgs:   3,
+		MaxArgs:   3,
+		Supported: true,
+		Propagate: true,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 1},
+	},
+	ZREMRANGEBYLEX: {
+		MinArgs:   3,
+		MaxArgs:   3,
+		Supported: true,
+		Propagate: true,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 1},
+	},
+	ZUNIONSTORE: {
+		MinArgs:   3,
+		MaxArgs:   -1,
+		Supported: true,
+		Propagate: true,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 1},
+	},
+	ZINTERSTORE: {
+		MinArgs:   3,
+		MaxArgs:   -1,
+		Supported: true,
+		Propagate: true,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 1},
+	},
+	ZDIFFSTORE: {
+		MinArgs:   3,
+		MaxArgs:   -1,
+		Supported: true,
+		Propagate: true,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 1},
+	},
+	ZUNION: {
+		MinArgs:   2,
+		MaxArgs:   -1,
+		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
+	},
+	ZINTER: {
+		MinArgs:   2,
+		MaxArgs:   -1,
+		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
+	},
+	ZDIFF: {
+		MinArgs:   2,
+		MaxArgs:   -1,
+		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
+	},
+	ZINTERCARD: {
+		MinArgs:   2,
+		MaxArgs:   -1,
+		Supported: true,
+		Propagate: false,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 0},
+	},
+	PFADD: {
+		MinArgs:   1,
+		MaxArgs:   -1,
+		Supported: true,
+		Propagate: true,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 1},
+	},
+	PFCOUNT: {
+		MinArgs:   1,
+		MaxArgs:   -1,
+		Supported: true,
+		Propagate: true,
+		Keys:      KeySpec{First: 0, Last: -1, Step: 1},
+	},
+	PFMERGE: {
+		MinArgs:   1,
+		MaxArgs:   -1,
+		Supported: true,
+		Propagate: true,
+		Keys:      KeySpec{First: 0, Last: 0, Step: 1},
+	},
+}
+
+type FullParser interface {
+	Parse(args ...Token) error
+}
+
+type ScalerParser interface {
+	ParseScaler(args ...Token) (int, error)
+}
+
+type FlagParser interface {
+	ParseFlags(args ...Token) error
+}
+
+type VariadicTailParser interface {
+	ParseTail(args ...Token) error
+}
+
+func notImplemented(cmd string) Response {
+	return &response{
+		data: NewEncoder().SimpleError(fmt.Sprintf("ERR %v: Not Implemented", cmd)),
+	}
+}
+
+type Specs interface {
+	String() string
+}
+
+type GenericSpec struct {
+	MinArgs   int
+	MaxArgs   int
+	Supported bool
+	// Writes that have to be replayed on replicas
+	Propagate bool
+	// Keys written by the command. Commands always propagated as other
+	// commands, like LMPOP, need none
+	Keys KeySpec
+}
+
+// KeySpec locates keys in the args of a command, from First to Last every
+// Step args. Negative positions count from the end, a zero Step means none
+type KeySpec struct {
+	First int
+	Last  int
+	Step  int
+}
+
+// Find returns the keys among args
+func (ks KeySpec) Find(args []Token) []string {
+	if ks.Step <= 0 {
+		return nil
+	}
+	first, last := ks.First, ks.Last
+	if first < 0 {
+		first += len(args)
+	}
+	if last < 0 {
+		last += len(args)
+	}
+	keys := []string{}
+	for i := max(first, 0); i <= last && i < len(args); i += ks.Step {
+		if key, ok := args[i].Literal.(string); ok {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func GetGenericSpec(cmd string) GenericSpec {
+	return commandRegistry[cmd]
+}
+
+func ParseCmd(tkns ...Token) (int, string, error) {
+	argsIndex := 1
+	var c string
+	c = strings.ToLower(tkns[0].Literal.(string))
+	if (c == "acl" || c == "xinfo") && len(tkns) >= 2 {
+		subcmd := strings.ToLower(tkns[1].Literal.(string))
+		c = c + "_" + subcmd
+		argsIndex = 2
+	}
+	if !commandRegistry[c].Supported {
+		return 0, "", fmt.Errorf("ERR unsupported command")
+	}
+	return argsIndex, c, nil
+}
+
+type ECHOSpecs struct {
+	Data string
+}
+
+func (s *ECHOSpecs) String() string {
+	return ECHO
+}
+
+type COMMANDSpecs struct {
+}
+
+func (s *COMMANDSpecs) String() string {
+	return COMMAND
+}
+
+type PINGSpecs struct {
+}
+
+func (s *PINGSpecs) String() string {
+	return PING
+}
+
+type SETSpecs struct {
+	Key     string
+	Value   Token
+	Ex      *int64
+	Px      *int64
+	Exat    *int64
+	Pxat    *int64
+	Nx      bool
+	Xx      bool
+	KeepTtl bool
+	Get     bool
+}
+
+func (s *SETSpecs) String() string {
+	return SET
+}
+func (s *SETSpecs) ParseScaler(args ...Token) (int, error) {
+	strVal0 := args[0].Literal.(string)
+	s.Key = strVal0
+
+	s.Value = args[1]
+
+	return 2, nil
+}
+
+type GETSpecs struct {
+	Key         string
+	CurrentTime time.Time
+}
+
+func (s *GETSpecs) String() string {
+	return GET
+}
+func (s *GETSpecs) ParseScaler(args ...Token) (int, error) {
+	strVal0 := args[0].Literal.(string)
+	s.Key = strVal0
+
+	s.CurrentTime = time.Now()
+	return 1, nil
+}
+
+type INCRSpecs struct {
+	Key         string
+	CurrentTime time.Time
+}
+
+func (s *INCRSpecs) String() string {
+	return INCR
+}
+func (s *INCRSpecs) ParseScaler(args ...Token) (int, error) {
+	strVal0 := args[0].Literal.(string)
+	s.Key = strVal0
+
+	s.CurrentTime = time.Now()
+	return 1, nil
+}
+
+type INCRBYSpecs struct {
+	Key       string
+	Increment int64
+}
+
+func (s *INCRBYSpecs) String() string {
+	return INCRBY
+}
+func (s *INCRBYSpecs) ParseScaler(args ...Token) (int, error) {
+	strVal0 := args[0].Literal.(string)
+	s.Key = strVal0
+
+	if parsed, err := strconv.ParseInt(args[1].Literal.(string), 10, 64); err != nil {
+		return 0, &ErrNotInteger{data: args[1].Literal}
+	} else {
+		intVal1 := parsed
+		s.Increment = intVal1
+	}
+
+	return 2, nil
+}
+
+type DECRSpecs struct {
+	Key string
+}
+
+func (s *DECRSpecs) String() string {
+	return DECR
+}
+func (s *DECRSpecs) ParseScaler(args ...Token) (int, error) {
+	strVal0 := args[0].Literal.(string)
+	s.Key = strVal0
+
+	return 1, nil
+}
+
+type DECRBYSpecs struct {
+	Key       string
+	Decrement int64
+}
+
+func (s *DECRBYSpecs) String() string {
+	return DECRBY
+}
+func (s *DECRBYSpecs) ParseScaler(args ...Token) (int, error) {
+	strVal0 := args[0].Literal.(string)
+	s.Key = strVal0
+
+	if parsed, err := strconv.ParseInt(args[1].Literal.(string), 10, 64); err != nil {
+		return 0, &ErrNotInteger{data: args[1].Literal}
+	} else {
+		intVal1 := parsed
+		s.Decrement = intVal1
+	}
+
+	return 2, nil
+}
+
+type INCRBYFLOATSpecs struct {
+	Key       string
+	Increment float64
+}
+
+func (s *INCRBYFLOATSpecs) String() string {
+	return INCRBYFLOAT
+}
+func (s *INCRBYFLOATSpecs) ParseScaler(args ...Token) (int, error) {
+	strVal0 := args[0].Literal.(string)
+	s.Key = strVal0
+
+	if parsed, err := strconv.ParseFloat(args[1].Literal.(string), 64); err != nil {
+		return 0, &ErrNotFloat{}
+	} else {
+		floatVal1 := parsed
+		s.Increment = floatVal1
+	}
+
+	return 2, nil
+}
+
+type MULTISpecs struct {
+}
+
+func (s *MULTISpecs) String() string {
+	return MULTI
+}
+
+type EXECSpecs struct {
+}
+
+func (s *EXECSpecs) String() string {
+	return EXEC
+}
+
+type DISCARDSpecs struct {
+}
+
+func (s *DISCARDSpecs) String() string {
+	return DISCARD
+}
+
+type INFOSpecs struct {
+	Section string
+}
+
+func (s *INFOSpecs) String() string {
+	return INFO
+}
+func (s *INFOSpecs) ParseScaler(args ...Token) (int, error) {
+	strVal0 := args[0].Literal.(string)
+	s.Section = strVal0
+
+	return 1, nil
+}
+
+type REPLCONFSpecs struct {
+	ListeningPort *string
+	Capability    *string
+	GetAck        *string
+}
+
+func (s *REPLCONFSpecs) String() string {
+	return REPLCONF
+}
+
+type PSYNCSpecs struct {
+	ReplicaId string
+	Offset    string
+}
+
+func (s *PSYNCSpecs) String() string {
+	return PSYNC
+}
+func (s *PSYNCSpecs) ParseScaler(args ...Token) (int, error) {
+	strVal0 := args[0].Literal.(string)
+	s.ReplicaId = strVal0
+
+	strVal1 := args[1].Literal.(string)
+	s.Offset = strVal1
+
+	return 2, nil
+}
+
+type CONFIGSpecs struct {
+	Action string
+	Key    string
+}
+
+func (s *CONFIGSpecs) String() string {
+	return CONFIG
+}
+func (s *CONFIGSpecs) ParseScaler(args ...Token) (int, error) {
+	strVal0 := args[0].Literal.(string)
+	s.Action = strVal0
+
+	strVal1 := args[1].Literal.(string)
+	s.Key = strVal1
+
+	return 2, nil
+}
+
+type KEYSSpecs struct {
+	Filter string
+}
+
+func (s *KEYSSpecs) String() string {
+	return KEYS
+}
+func (s *KEYSSpecs) ParseScaler(args ...Token) (int, error) {
+	strVal0 := args[0].Literal.(string)
+	s.Filter = strVal0
+
+	return 1, nil
+}
+
+type XADDSpecs struct {
+	Key string
+	Id  *uint64
+	Seq *uint64
+	KVs []KeyValue
+}
+
+func (s *XADDSpecs) String() string {
+	return XADD
+}
+
+type XLENSpecs struct {
+	Key string
+}
+
+func (s *XLENSpecs) String() string {
+	return XLEN
+}
+func (s *XLENSpecs) ParseScaler(args ...Token) (int, error) {
+	strVal0 := args[0].Literal.(string)
+	s.Key = strVal0
+
+	return 1, nil
+}
+
+type XRANGESpecs struct {
+	Key   string
+	Start StreamID
+	End   StreamID
+	Count int64
+}
+
+func (s *XRANGESpecs) String() string {
+	return XRANGE
+}
+
+type XREVRANGESpecs struct {
+	Key   string
+	End   StreamID
+	Start StreamID
+	Count int64
+}
+
+func (s *XREVRANGESpecs) String() string {
+	return XREVRANGE
+}
+
+type XREADSpecs struct {
+	Count   int64
+	Block   *int64
+	Keys    []string
+	Cursors []StreamCursor
+}
+
+func (s *XREADSpecs) String() string {
+	return XREAD
+}
+
+type XINFO_STREAMSpecs struct {
+	Key string
+}
+
+func (s *XINFO_STREAMSpecs) String() string {
+	return XINFO_STREAM
+}
+func (s *XINFO_STREAMSpecs) ParseScaler(args ...Token) (int, error) {
+	strVal0 := args[0].Literal.(string)
+	s.Key = strVal0
+
+	return 1, nil
+}
+
+type TYPESpecs struct {
+	Key         string
+	CurrentTime time.Time
+}
+
+func (s *TYPESpecs) String() string {
+	return TYPE
+}
+func (s *TYPESpecs) ParseScaler(args ...Token) (int, error) {
+	strVal0 := args[0].Literal.(string)
+	s.Key = strVal0
+
+	s.CurrentTime = time.Now()
+	return 1, nil
+}
+
+type RPUSHSpecs struct {
+	Key      string
+	Elements []string
+}
+
+func (s *RPUSHSpecs) String() string {
+	return RPUSH
+}
+func (s *RPUSHSpecs) ParseScaler(args ...Token) (int, error) {
+	strVal0 := args[0].Literal.(string)
+	s.Key = strVal0
+
+	s.Elements = make([]string, 0)
+	for _, el := range args[1:] {
+		s.Elements = append(s.Elements, el.Literal.(string))
+	}
+
+	return 2, nil
+}
+
+type LRANGESpecs struct {
+	Key   string
+	Start int64
+	End   int64
+}
+
+func (s *LRANGESpecs) String() string {
+	return LRANGE
+}
+func (s *LRANGESpecs) ParseScaler(args ...Token) (int, error) {
+	strVal0 := args[0].Literal.(string)
+	s.Key = strVal0
+
+	if parsed, err := strconv.ParseInt(args[1].Literal.(string), 10, 64); err != nil {
+		return 0, &ErrNotInteger{data: args[1].Literal}
+	} else {
+		intVal1 := parsed
+		s.Start = intVal1
+	}
+
+	if parsed, err := strconv.ParseInt(args[2].Literal.(string), 10, 64); err != nil {
+		return 0, &ErrNotInteger{data: args[2].Literal}
+	} else {
+		intVal2 := parsed
+		s.End = intVal2
+	}
+
+	return 3, nil
+}
+
+type LPUSHSpecs struct {
+	Key      string
+	Elements []string
+}
+
+func (s *LPUSHSpecs) String() string {
+	return LPUSH
+}
+func (s *LPUSHSpecs) ParseScaler(args ...Token) (int, error) {
+	strVal0 := args[0].Literal.(string)
+	s.Key = strVal0
+
+	s.Elements = make([]string, 0)
+	for _, el := range args[1:] {
+		s.Elements = append(s.Elements, el.Literal.(string))
+	}
+
+	return 2, nil
+}
+
+type LLENSpecs struct {
+	Key string
+}
+
+func (s *LLENSpecs) String() string {
+	return LLEN
+}
+func (s *LLENSpecs) ParseScaler(args ...Token) (int, error) {
+	strVal0 := args[0].Literal.(string)
+	s.Key = strVal0
+
+	return 1, nil
+}
+
+type LPOPSpecs struct {
+	Key            string
+	AmountToRemove *int64
+}
+
+func (s *LPOPSpecs) String() string {
+	return LPOP
+}
+func (s *LPOPSpecs) ParseScaler(args ...Token) (int, error) {
+	strVal0 := args[0].Literal.(string)
+	s.Key = strVal0
+
+	if len(args) > 1 {
+		if parsed, err := strconv.ParseInt(args[1].Literal.(string), 10, 64); err != nil {
+			return 0, &ErrNotInteger{data: args[1].Literal}
+		} else {
+			intVal1 := parsed
+			s.AmountToRemove = &intVal1
+		}
+
+	}
+
+	return 2, nil
+}
+
+type BLPOPSpecs struct {
+	Keys     []string
+	Lifetime *float64
+}
+
+func (s *BLPOPSpecs) String() string {
+	return BLPOP
+}
+
+type WAITSpecs struct {
+	NumReplicas uint64
+	Timeout     uint64
+}
+
+func (s *WAITSpecs) String() string {
+	return WAIT
+}
+func (s *WAITSpecs) ParseScaler(args ...Token) (int, error) {
+	if parsed, err := strconv.ParseUint(args[0].Literal.(string), 10, 64); err != nil {
+		return 0, &ErrNotInteger{data: args[0].Literal}
+	} else {
+		uintVal0 := parsed
+		s.NumReplicas = uintVal0
+	}
+
+	if parsed, err := strconv.ParseUint(args[1].Literal.(string), 10, 64); err != nil {
+		return 0, &ErrNotInteger{data: args[1].Literal}
+	} else {
+		uintVal1 := parsed
+		s.Timeout = uintVal1
+	}
+
+	return 2, nil
+}
+
+type SUBSCRIBESpecs struct {
+	Key string
+}
+
+func (s *SUBSCRIBESpecs) String() string {
+	return SUBSCRIBE
+}
+func (s *SUBSCRIBESpecs) ParseScaler(args ...Token) (int, error) {
+	strVal0 := args[0].Literal.(string)
+	s.Key = strVal0
+
+	return 1, nil
+}
+
+type UNSUBSCRIBESpecs struct {
+	Key string
+}
+
+func (s *UNSUBSCRIBESpecs) String() string {
+	return UNSUBSCRIBE
+}
+func (s *UNSUBSCRIBESpecs) ParseScaler(args ...Token) (int, error) {
+	strVal0 := args[0].Literal.(string)
+	s.Key = strVal0
+
+	return 1, nil
+}
+
+type QUITSpecs struct {
+}
+
+func (s *QUITSpecs) String() string {
+	return QUIT
+}
+
+type PUBLISHSpecs struct {
+	Key     string
+	Message string
+}
+
+func (s *PUBLISHSpecs) String() string {
+	return PUBLISH
+}
+func (s *PUBLISHSpecs) ParseScaler(args ...Token) (int, error) {
+	strVal0 := args[0].Literal.(string)
+	s.Key = strVal0
+
+	strVal1 := args[1].Literal.(string)
+	s.Message = strVal1
+
+	return 2, nil
+}
+
+type ACL_WHOAMISpecs struct {
+}
+
+func (s *ACL_WHOAMISpecs) String() string {
+	return ACL_WHOAMI
+}
+
+type ACL_GETUSERSpecs struct {
+	User string
+}
+
+func (s *ACL_GETUSERSpecs) String() string {
+	return ACL_GETUSER
+}
+func (s *ACL_GETUSERSpecs) ParseScaler(args ...Token) (int, error) {
+	strVal0 := args[0].Literal.(string)
+	s.User = strVal0
+
+	return 1, nil
+}
+
+type ACL_SETUSERSpecs struct {
+	Username string
+	Rules    []string
+}
+
+func (s *ACL_SETUSERSpecs) String() string {
+	return ACL_SETUSER
+}
+func (s *ACL_SETUSERSpecs) ParseScaler(args ...Token) (int, error) {
+	strVal0 := args[0].Literal.(string)
+	s.Username = strVal0
+
+	s.Rules = make([]string, 0)
+	for _, el := range args[1:] {
+		s.Rules = append(s.Rules, el.Literal.(string))
+	}
+
+	return 2, nil
+}
+
+type AUTHSpecs struct {
+	Username string
+	Password string
+}
+
+func (s *AUTHSpecs) String() string {
+	return AUTH
+}
+func (s *AUTHSpecs) ParseScaler(args ...Token) (int, error) {
+	strVal0 := args[0].Literal.(string)
+	s.Username = strVal0
+
+	strVal1 := args[1].Literal.(string)
+	s.Password = strVal1
+
+	return 2, nil
+}
+
+type ZADDSpecs struct {
+	Key     string
+	Options ZAddOptions
+	Members []ZMember
+}
+
+func (s *ZADDSpecs) String() string {
+	return ZADD
+}
+
+type ZRANKSpecs struct {
+	Key   string
+	Value string
+}
+
+func (s *ZRANKSpecs) String() string {
+	return ZRANK
+}
+func (s *ZRANKSpecs) ParseScaler(args ...Token) (int, error) {
+	strVal0 := args[0].Literal.(string)
+	s.Key = strVal0
+
+	strVal1 := args[1].Literal.(string)
+	s.Value = strVal1
+
+	return 2, nil
+}
+
+type ZRANGESpecs struct {
+	Key        string
+	Options    ZRangeOptions
+	WithScores bool
+}
+
+func (s *ZRANGESpecs) String() string {
+	return ZRANGE
+}
+
+type ZCARDSpecs struct {
+	Key string
+}
+
+func (s *ZCARDSpecs) String() string {
+	return ZCARD
+}
+func (s *ZCARDSpecs) ParseScaler(args ...Token) (int, error) {
+	strVal0 := args[0].Literal.(string)
+	s.Key = strVal0
+
+	return 1, nil
+}
+
+type ZSCORESpecs struct {
+	Key   string
+	Value string
+}
+
+func (s *ZSCORESpecs) String() string {
+	return ZSCORE
+}
+func (s *ZSCORESpecs) ParseScaler(args ...Token) (int, error) {
+	strVal0 := args[0].Literal.(string)
+	s.Key = strVal0
+
+	strVal1 := args[1].Literal.(string)
+	s.Value = strVal1
+
+	return 2, nil
+}
+
+type ZREMSpecs struct {
+	Key   string
+	Value string
+}
+
+func (s *ZREMSpecs) String() string {
+	return ZREM
+}
+func (s *ZREMSpecs) ParseScaler(args ...Token) (int, error) {
+	strVal0 := args[0].Literal.(string)
+	s.Key = strVal0
+
+	strVal1 := args[1].Literal.(string)
+	s.Value = strVal1
+
+	return 2, nil
+}
+
+type WATCHSpecs struct {
+	Keys []string
+}
+
+func (s *WATCHSpecs) String() string {
+	return WATCH
+}
+func (s *WATCHSpecs) ParseScaler(args ...Token) (int, error) {
+	s.Keys = make([]string, 0)
+	for _, el := range args[0:] {
+		s.Keys = append(s.Keys, el.Literal.(string))
+	}
+
+	return 1, nil
+}
+
+type UNWATCHSpecs struct {
+}
+
+func (s *UNWATCHSpecs) String() string {
+	return UNWATCH
+}
+
+type GEOADDSpecs struct {
+	Key    string
+	Lng    float64
+	Lat    float64
+	Member string
+}
+
+func (s *GEOADDSpecs) String() string {
+	return GEOADD
+}
+func (s *GEOADDSpecs) ParseScaler(args ...Token) (int, error) {
+	strVal0 := args[0].Literal.(string)
+	s.Key = strVal0
+
+	if parsed, err := strconv.ParseFloat(args[1].Literal.(string), 64); err != nil {
+		return 0, &ErrNotFloat{}
+	} else {
+		floatVal1 := parsed
+		s.Lng = floatVal1
+	}
+
+	if parsed, err := strconv.ParseFloat(args[2].Literal.(string), 64); err != nil {
+		return 0, &ErrNotFloat{}
+	} else {
+		floatVal2 := parsed
+		s.Lat = floatVal2
+	}
+
+	strVal3 := args[3].Literal.(string)
+	s.Member = strVal3
+
+	return 4, nil
+}
+
+type GEOPOSSpecs struct {
+	Key  string
+	Locs []string
+}
+
+func (s *GEOPOSSpecs) String() string {
+	return GEOPOS
+}
+func (s *GEOPOSSpecs) ParseScaler(args ...Token) (int, error) {
+	strVal0 := args[0].Literal.(string)
+	s.Key = strVal0
+
+	s.Locs = make([]string, 0)
+	for _, el := range args[1:] {
+		s.Locs = append(s.Locs, el.Literal.(string))
+	}
+
+	return 2, nil
+}
+
+type DELSpecs struct {
+	Keys []string
+}
+
+func (s *DELSpecs) String() string {
+	return DEL
+}
+func (s *DELSpecs) ParseScaler(args ...Token) (int, error) {
+	s.Keys = make([]string, 0)
+	for _, el := range args[0:] {
+		s.Keys = append(s.Keys, el.Literal.(string))
+	}
+
+	return 1, nil
+}
+
+type UNLINKSpecs struct {
+	Keys []string
+}
+
+func (s *UNLINKSpecs) String() string {
+	return UNLINK
+}
+func (s *UNLINKSpecs) ParseScaler(args ...Token) (int, error) {
+	s.Keys = make([]string, 0)
+	for _, el := range args[0:] {
+		s.Keys = append(s.Keys, el.Literal.(string))
+	}
+
+	return 1, nil
+}
+
+type EXISTSSpecs struct {
+	Keys []string
+}
+
+func (s *EXISTSSpecs) String() string {
+	return EXISTS
+}
+func (s *EXISTSSpecs) ParseScaler(args ...Token) (int, error) {
+	s.Keys = make([]string, 0)
+	for _, el := range args[0:] {
+		s.Keys = append(s.Keys, el.Literal.(string))
+	}
+
+	return 1, nil
+}
+
+type EXPIRESpecs struct {
+	Key       string
+	Seconds   int64
+	Condition ExpireCondition
+}
+
+func (s *EXPIRESpecs) String() string {
+	return EXPIRE
+}
+
+type PEXPIRESpecs struct {
+	Key          string
+	Milliseconds int64
+	Condition    ExpireCondition
+}
+
+func (s *PEXPIRESpecs) String() string {
+	return PEXPIRE
+}
+
+type EXPIREATSpecs struct {
+	Key             string
+	UnixTimeSeconds int64
+	Condition       ExpireCondition
+}
+
+func (s *EXPIREATSpecs) String() string {
+	return EXPIREAT
+}
+
+type PEXPIREATSpecs struct {
+	Key                  string
+	UnixTimeMilliseconds int64
+	Condition            ExpireCondition
+}
+
+func (s *PEXPIREATSpecs) String() string {
+	return PEXPIREAT
+}
+
+type TTLSpecs struct {
+	Key         string
+	CurrentTime time.Time
+}
+
+func (s *TTLSpecs) String() string {
+	return TTL
+}
+func (s *TTLSpecs) ParseScaler(args ...Token) (int, error) {
+	strVal0 := args[0].Literal.(string)
+	s.Key = strVal0
+
+	s.CurrentTime = time.Now()
+	return 1, nil
+}
+
+type PTTLSpecs struct {
+	Key         string
+	CurrentTime time.Time
+}
+
+func (s *PTTLSpecs) String() string {
+	return PTTL
+}
+func (s *PTTLSpecs) ParseScaler(args ...Token) (int, error) {
+	strVal0 := args[0].Literal.(string)
+	s.Key = strVal0
+
+	s.CurrentTime = time.Now()
+	return 1, nil
+}
+
+type EXPIRETIMESpecs struct {
+	Key         string
+	CurrentTime time.Time
+}
+
+func (s *EXPIRETIMESpecs) String() string {
+	return EXPIRETIME
+}
+func (s *EXPIRETIMESpecs) ParseScaler(args ...Token) (int, error) {
+	strVal0 := args[0].Literal.(string)
+	s.Key = strVal0
+
+	s.CurrentTime = time.Now()
+	return 1, nil
+}
+
+type PEXPIRETIMESpecs struct {
+	Key         string
+	CurrentTime time.Time
+}
+
+func (s *PEXPIRETIMESpecs) String() string {
+	return PEXPIRETIME
+}
+func (s *PEXPIRETIMESpecs) ParseScaler(args ...Token) (int, error) {
+	strVal0 := args[0].Literal.(string)
+	s.Key = strVal0
+
+	s.CurrentTime = time.Now()
+	return 1, nil
+}
+
+type PERSISTSpecs struct {
+	Key string
+}
+
+func (s *PERSISTSpecs) String() string {
+	return PERSIST
+}
+func (s *PERSISTSpecs) ParseScaler(args ...Token) (int, error) {
+	strVal0 := args[0].Literal.(string)
+	s.Key = strVal0
+
+	return 1, nil
+}
+
+type SCANSpecs struct {
+	Cursor      uint64
+	Options     ScanOptions
+	CurrentTime time.Time
+}
+
+func (s *SCANSpecs) String() string {
+	return SCAN
+}
+
+type ZSCANSpecs struct {
+	Key     string
+	Cursor  uint64
+	Options ScanOptions
+}
+
+func (s *ZSCANSpecs) String() string {
+	return ZSCAN
+}
+
+type APPENDSpecs struct {
+	Key   string
+	Value string
+}
+
+func (s *APPENDSpecs) String() string {
+	return APPEND
+}
+func (s *APPENDSpecs) ParseScaler(args ...Token) (int, error) {
+	strVal0 := args[0].Literal.(string)
+	s.Key = strVal0
+
+	strVal1 := args[1].Literal.(string)
+	s.Value = strVal1
+
+	return 2, nil
+}
+
+type STRLENSpecs struct {
+	Key         string
+	CurrentTime time.Time
+}
+
+func (s *STRLENSpecs) String() string {
+	return STRLEN
+}
+func (s *STRLENSpecs) ParseScaler(args ...Token) (int, error) {
+	strVal0 := args[0].Literal.(string)
+	s.Key = strVal0
+
+	s.CurrentTime = time.Now()
+	return 1, nil
+}
+
+type GETRANGESpecs struct {
+	Key         string
+	Start       int64
+	End         int64
+	CurrentTime time.Time
+}
+
+func (s *GETRANGESpecs) String() string {
+	return GETRANGE
+}
+func (s *GETRANGESpecs) ParseScaler(args ...Token) (int, error) {
+	strVal0 := args[0].Literal.(string)
+	s.Key = strVal0
+
+	if parsed, err := strconv.ParseInt(args[1].Literal.(string), 10, 64); err != nil {
+		return 0, &ErrNotInteger{data: args[1].Literal}
+	} else {
+		intVal1 := parsed
+		s.Start = intVal1
+	}
+
+	if parsed, err := strconv.ParseInt(args[2].Literal.(string), 10, 64); err != nil {
+		return 0, &ErrNotInteger{data: args[2].Literal}
+	} else {
+		intVal2 := parsed
+		s.End = intVal2
+	}
+
+	s.CurrentTime = time.Now()
+	return 3, nil
+}
+
+type SETRANGESpecs struct {
+	Key    string
+	Offset int64
+	Value  string
+}
+
+func (s *SETRANGESpecs) String() string {
+	return SETRANGE
+}
+func (s *SETRANGESpecs) ParseScaler(args ...Token) (int, error) {
+	strVal0 := args[0].Literal.(string)
+	s.Key = strVal0
+
+	if parsed, err := strconv.ParseInt(args[1].Literal.(string), 10, 64); err != nil {
+		return 0, &ErrNotInteger{data: args[1].Literal}
+	} else {
+		intVal1 := parsed
+		s.Offset = intVal1
+	}
+
+	strVal2 := args[2].Literal.(string)
+	s.Value = strVal2
+
+	return 3, nil
+}
+
+type MGETSpecs struct {
+	Keys        []string
+	CurrentTime time.Time
+}
+
+func (s *MGETSpecs) String() string {
+	return MGET
+}
+func (s *MGETSpecs) ParseScaler(args ...Token) (int, error) {
+	s.Keys = make([]string, 0)
+	for _, el := range args[0:] {
+		s.Keys = append(s.Keys, el.Literal.(string))
+	}
+
+	s.CurrentTime = time.Now()
+	return 1, nil
+}
+
+type MSETSpecs struct {
+	KVs []KeyValue
+}
+
+func (s *MSETSpecs) String() string {
+	return MSET
+}
+
+type MSETNXSpecs struct {
+	KVs []KeyValue
+}
+
+func (s *MSETNXSpecs) String() string {
+	return MSETNX
+}
+
+type GETDELSpecs struct {
+	Key string
+}
+
+func (s *GETDELSpecs) String() string {
+	return GETDEL
+}
+func (s *GETDELSpecs) ParseScaler(args ...Token) (int, error) {
+	strVal0 := args[0].Literal.(string)
+	s.Key = strVal0
+
+	return 1, nil
+}
+
+type GETEXSpecs struct {
+	Key     string
+	Ex      *int64
+	Px      *int64
+	Exat    *int64
+	Pxat    *int64
+	Persist bool
+}
+
+func (s *GETEXSpecs) String() string {
+	return GETEX
+}
+func (s *GETEXSpecs) ParseScaler(args ...Token) (int, error) {
+	strVal0 := args[0].Literal.(string)
+	s.Key = strVal0
+
+	return 1, nil
+}
+
+type GETSETSpecs struct {
+	Key   string
+	Value Token
+}
+
+func (s *GETSETSpecs) String() string {
+	return GETSET
+}
+func (s *GETSETSpecs) ParseScaler(args ...Token) (int, error) {
+	strVal0 := args[0].Literal.(string)
+	s.Key = strVal0
+
+	s.Value = args[1]
+
+	return 2, nil
+}
+
+type HSETSpecs struct {
+	Key    string
+	Fields []KeyValue
+}
+
+func (s *HSETSpecs) String() string {
+	return HSET
+}
+
+type HSETNXSpecs struct {
+	Key   string
+	Field string
+	Value string
+}
+
+func (s *HSETNXSpecs) String() string {
+	return HSETNX
+}
+func (s *HSETNXSpecs) ParseScaler(args ...Token) (int, error) {
+	strVal0 := args[0].Literal.(string)
+	s.Key = strVal0
+
+	strVal1 := args[1].Literal.(string)
+	s.Field = strVal1
+
+	strVal2 := args[2].Literal.(string)
+	s.Value = strVal2
+
+	return 3, nil
+}
+
+type HGETSpecs struct {
+	Key   string
+	Field string
+}
+
+func (s *HGETSpecs) String() string {
+	return HGET
+}
+func (s *HGETSpecs) ParseScaler(args ...Token) (int, error) {
+	strVal0 := args[0].Literal.(string)
+	s.Key = strVal0
+
+	strVal1 := args[1].Literal.(string)
+	s.Field = strVal1
+
+	return 2, nil
+}
+
+type HMGETSpecs struct {
+	Key    string
+	Fields []string
+}
+
+func (s *HMGETSpecs) String() string {
+	return HMGET
+}
+func (s *HMGETSpecs) ParseScaler(args ...Token) (int, error) {
+	strVal0 := args[0].Literal.(string)
+	s.Key = strVal0
+
+	s.Fields = make([]string, 0)
+	for _, el := range args[1:] {
+		s.Fields = append(s.Fields, el.Literal.(string))
+	}
+
+	return 2, nil
+}
+
+type HDELSpecs struct {
+	Key    string
+	Fields []string
+}
+
+func (s *HDELSpecs) String() string {
+	return HDEL
+}
+func (s *HDELSpecs) ParseScaler(args ...Token) (int, error) {
+	strVal0 := args[0].Literal.(string)
+	s.Key = strVal0
+
+	s.Fields = make([]string, 0)
+	for _, el := range args[1:] {
+		s.Fields = append(s.Fields, el.Literal.(string))
+	}
+
+	return 2, nil
+}
+
+type HGETALLSpecs struct {
+	Key string
+}
+
+func (s *HGETALLSpecs) String() string {
+	return HGETALL
+}
+func (s *HGETALLSpecs) ParseScaler(args ...Token) (int, error) {
+	strVal0 := args[0].Literal.(string)
+	s.Key = strVal0
+
+	return 1, nil
+}
+
+type HINCRBYSpecs struct {
+	Key       string
+	Field     string
+	Increment int64
+}
+
+func (s *HINCRBYSpecs) String() string {
+	return HINCRBY
+}
+func (s *HINCRBYSpecs) ParseScaler(args ...Token) (int, error) {
+	strVal0 := args[0].Literal.(string)
+	s.Key = strVal0
+
+	strVal1 := args[1].Literal.(string)
+	s.Field = strVal1
+
+	if parsed, err := strconv.ParseInt(args[2].Literal.(string), 10, 64); err != nil {
+		return 0, &ErrNotInteger{data: args[2].Literal}
+	} else {
+		intVal2 := parsed
+		s.Increment = intVal2
+	}
+
+	return 3, nil
+}
+
+type HINCRBYFLOATSpecs struct {
+	Key       string
+	Field     string
+	Increment float64
+}
+
+func (s *HINCRBYFLOATSpecs) String() string {
+	return HINCRBYFLOAT
+}
+func (s *HINCRBYFLOATSpecs) ParseScaler(args ...Token) (int, error) {
+	strVal0 := args[0].Literal.(string)
+	s.Key = strVal0
+
+	strVal1 := args[1].Literal.(string)
+	s.Field = strVal1
+
+	if parsed, err := strconv.ParseFloat(args[2].Literal.(string), 64); err != nil {
+		return 0, &ErrNotFloat{}
+	} else {
+		floatVal2 := parsed
+		s.Increment = floatVal2
+	}
+
+	return 3, nil
+}
+
+type HLENSpecs struct {
+	Key string
+}
+
+func (s *HLENSpecs) String() string {
+	return HLEN
+}
+func (s *HLENSpecs) ParseScaler(args ...Token) (int, error) {
+	strVal0 := args[0].Literal.(string)
+	s.Key = strVal0
+
+	return 1, nil
+}
+
+type HEXISTSSpecs struct {
+	Key   string
+	Field string
+}
+
+func (s *HEXISTSSpecs) String() string {
+	return HEXISTS
+}
+func (s *HEXISTSSpecs) ParseScaler(args ...Token) (int, error) {
+	strVal0 := args[0].Literal.(string)
+	s.Key = strVal0
+
+	strVal1 := args[1].Literal.(string)
+	s.Field = strVal1
+
+	return 2, nil
+}
+
+type HKEYSSpecs struct {
+	Key string
+}
+
+func (s *HKEYSSpecs) String() string {
+	return HKEYS
+}
+func (s *HKEYSSpecs) ParseScaler(args ...Token) (int, error) {
+	strVal0 := args[0].Literal.(string)
+	s.Key = strVal0
+
+	return 1, nil
+}
+
+type HVALSSpecs struct {
+	Key string
+}
+
+func (s *HVALSSpecs) String() string {
+	return HVALS
+}
+func (s *HVALSSpecs) ParseScaler(args ...Token) (int, error) {
+	strVal0 := args[0].Literal.(string)
+	s.Key = strVal0
+
+	return 1, nil
+}
+
+type HSTRLENSpecs struct {
+	Key   string
+	Field string
+}
+
+func (s *HSTRLENSpecs) String() string {
+	return HSTRLEN
+}
+func (s *HSTRLENSpecs) ParseScaler(args ...Token) (int, error) {
+	strVal0 := args[0].Literal.(string)
+	s.Key = strVal0
+
+	strVal1 := args[1].Literal.(string)
+	s.Field = strVal1
+
+	return 2, nil
+}
+
+type HSCANSpecs struct {
+	Key     string
+	Cursor  uint64
+	Options ScanOptions
+}
+
+func (s *HSCANSpecs) String() string {
+	return HSCAN
+}
+
+type SETBITSpecs struct {
+	Key    string
+	Offset string
+	Value  string
+}
+
+func (s *SETBITSpecs) String() string {
+	return SETBIT
+}
+func (s *SETBITSpecs) ParseScaler(args ...Token) (int, error) {
+	strVal0 := args[0].Literal.(string)
+	s.Key = strVal0
+
+	strVal1 := args[1].Literal.(string)
+	s.Offset = strVal1
+
+	strVal2 := args[2].Literal.(string)
+	s.Value = strVal2
+
+	return 3, nil
+}
+
+type GETBITSpecs struct {
+	Key    string
+	Offset string
+}
+
+func (s *GETBITSpecs) String() string {
+	return GETBIT
+}
+func (s *GETBITSpecs) ParseScaler(args ...Token) (int, error) {
+	strVal0 := args[0].Literal.(string)
+	s.Key = strVal0
+
+	strVal1 := args[1].Literal.(string)
+	s.Offset = strVal1
+
+	return 2, nil
+}
+
+type BITCOUNTSpecs struct {
+	Key   string
+	Range BitRange
+}
+
+func (s *BITCOUNTSpecs) String() string {
+	return BITCOUNT
+}
+
+type BITPOSSpecs struct {
+	Key   string
+	Bit   int64
+	Range BitRange
+}
+
+func (s *BITPOSSpecs) String() string {
+	return BITPOS
+}
+
+type BITOPSpecs struct {
+	Operation string
+	DestKey   string
+	Keys      []string
+}
+
+func (s *BITOPSpecs) String() string {
+	return BITOP
+}
+func (s *BITOPSpecs) ParseScaler(args ...Token) (int, error) {
+	strVal0 := args[0].Literal.(string)
+	s.Operation = strVal0
+
+	strVal1 := args[1].Literal.(string)
+	s.DestKey = strVal1
+
+	s.Keys = make([]string, 0)
+	for _, el := range args[2:] {
+		s.Keys = append(s.Keys, el.Literal.(string))
+	}
+
+	return 3, nil
+}
+
+type BITFIELDSpecs struct {
+	Key string
+	Ops []BitFieldOp
+}
+
+func (s *BITFIELDSpecs) String() string {
+	return BITFIELD
+}
+
+type BITFIELD_ROSpecs struct {
+	Key string
+	Ops []BitFieldOp
+}
+
+func (s *BITFIELD_ROSpecs) String() string {
+	return BITFIELD_RO
+}
+
+type SADDSpecs struct {
+	Key     string
+	Members []string
+}
+
+func (s *SADDSpecs) String() string {
+	return SADD
+}
+func (s *SADDSpecs) ParseScaler(args ...Token) (int, error) {
+	strVal0 := args[0].Literal.(string)
+	s.Key = strVal0
+
+	s.Members = make([]string, 0)
+	for _, el := range args[1:] {
+		s.Members = append(s.Members, el.Literal.(string))
+	}
+
+	return 2, nil
+}
+
+type SREMSpecs struct {
+	Key     string
+	Members []string
+}
+
+func (s *SREMSpecs) String() string {
+	return SREM
+}
+func (s *SREMSpecs) ParseScaler(args ...Token) (int, error) {
+	strVal0 := args[0].Literal.(string)
+	s.Key = strVal0
+
+	s.Members = make([]string, 0)
+	for _, el := range args[1:] {
+		s.Members = append(s.Members, el.Literal.(string))
+	}
+
+	return 2, nil
+}
+
+type SMEMBERSSpecs struct {
+	Key string
+}
+
+func (s *SMEMBERSSpecs) String() string {
+	return SMEMBERS
+}
+func (s *SMEMBERSSpecs) ParseScaler(args ...Token) (int, error) {
+	strVal0 := args[0].Literal.(string)
+	s.Key = strVal0
+
+	return 1, nil
+}
+
+type SISMEMBERSpecs struct {
+	Key    string
+	Member string
+}
+
+func (s *SISMEMBERSpecs) String() string {
+	return SISMEMBER
+}
+func (s *SISMEMBERSpecs) ParseScaler(args ...Token) (int, error) {
+	strVal0 := args[0].Literal.(string)
+	s.Key = strVal0
+
+	strVal1 := args[1].Literal.(string)
+	s.Member = strVal1
+
+	return 2, nil
+}
+
+type SMISMEMBERSpecs struct {
+	Key     string
+	Members []string
+}
+
+func (s *SMISMEMBERSpecs) String() string {
+	return SMISMEMBER
+}
+func (s *SMISMEMBERSpecs) ParseScaler(args ...Token) (int, error) {
+	strVal0 := args[0].Literal.(string)
+	s.Key = strVal0
+
+	s.Members = make([]string, 0)
+	for _, el := range args[1:] {
+		s.Members = append(s.Members, el.Literal.(string))
+	}
+
+	return 2, nil
+}
+
+type SCARDSpecs struct {
+	Key string
+}
+
+func (s *SCARDSpecs) String() string {
+	return SCARD
+}
+func (s *SCARDSpecs) ParseScaler(args ...Token) (int, error) {
+	strVal0 := args[0].Literal.(string)
+	s.Key = strVal0
+
+	return 1, nil
+}
+
+type SPOPSpecs struct {
+	Key   string
+	Count *int64
+}
+
+func (s *SPOPSpecs) String() string {
+	return SPOP
+}
+func (s *SPOPSpecs) ParseScaler(args ...Token) (int, error) {
+	strVal0 := args[0].Literal.(string)
+	s.Key = strVal0
+
+	if len(args) > 1 {
+		if parsed, err := strconv.ParseInt(args[1].Literal.(string), 10, 64); err != nil {
+			return 0, &ErrNotInteger{data: args[1].Literal}
+		} else {
+			intVal1 := parsed
+			s.Count = &intVal1
+		}
+
+	}
+
+	return 2, nil
+}
+
+type SRANDMEMBERSpecs struct {
+	Key   string
+	Count *int64
+}
+
+func (s *SRANDMEMBERSpecs) String() string {
+	return SRANDMEMBER
+}
+func (s *SRANDMEMBERSpecs) ParseScaler(args ...Token) (int, error) {
+	strVal0 := args[0].Literal.(string)
+	s.Key = strVal0
+
+	if len(args) > 1 {
+		if parsed, err := strconv.ParseInt(args[1].Literal.(string), 10, 64); err != nil {
+			return 0, &ErrNotInteger{data: args[1].Literal}
+		} else {
+			intVal1 := parsed
+			s.Count = &intVal1
+		}
+
+	}
+
+	return 2, nil
+}
+
+type SMOVESpecs struct {
+	Source      string
+	Destination string
+	Member      string
+}
+
+func (s *SMOVESpecs) String() string {
+	return SMOVE
+}
+func (s *SMOVESpecs) ParseScaler(args ...Token) (int, error) {
+	strVal0 := args[0].Literal.(string)
+	s.Source = strVal0
+
+	strVal1 := args[1].Literal.(string)
+	s.Destination = strVal1
+
+	strVal2 := args[2].Literal.(string)
+	s.Member = strVal2
+
+	return 3, nil
+}
+
+type SSCANSpecs struct {
+	Key     string
+	Cursor  uint64
+	Options ScanOptions
+}
+
+func (s *SSCANSpecs) String() string {
+	return SSCAN
+}
+
+type SINTERSpecs struct {
+	Keys []string
+}
+
+func (s *SINTERSpecs) String() string {
+	return SINTER
+}
+func (s *SINTERSpecs) ParseScaler(args ...Token) (int, error) {
+	s.Keys = make([]string, 0)
+	for _, el := range args[0:] {
+		s.Keys = append(s.Keys, el.Literal.(string))
+	}
 
 	return 1, nil
 }
 
-type XADDSpecs struct {
-	Key string
-	Id  *int64
-	Seq *int64
-	KVs []KeyValue
+type SUNIONSpecs struct {
+	Keys []string
 }
 
-func (s *XADDSpecs) String() string {
-	return XADD
+func (s *SUNIONSpecs) String() string {
+	return SUNION
 }
+func (s *SUNIONSpecs) ParseScaler(args ...Token) (int, error) {
+	s.Keys = make([]string, 0)
+	for _, el := range args[0:] {
+		s.Keys = append(s.Keys, el.Literal.(string))
+	}
 
-type TYPESpecs struct {
-	Key         string
-	CurrentTime time.Time
+	return 1, nil
 }
 
-func (s *TYPESpecs) String() string {
-	return TYPE
+type SDIFFSpecs struct {
+	Keys []string
 }
-func (s *TYPESpecs) ParseScaler(args ...Token) (int, error) {
-	strVal0 := args[0].Literal.(string)
-	s.Key = strVal0
 
-	s.CurrentTime = time.Now()
+func (s *SDIFFSpecs) String() string {
+	return SDIFF
+}
+func (s *SDIFFSpecs) ParseScaler(args ...Token) (int, error) {
+	s.Keys = make([]string, 0)
+	for _, el := range args[0:] {
+		s.Keys = append(s.Keys, el.Literal.(string))
+	}
+
 	return 1, nil
 }
 
-type RPUSHSpecs struct {
-	Key      string
-	Elements []string
+type SINTERSTORESpecs struct {
+	Destination string
+	Keys        []string
 }
 
-func (s *RPUSHSpecs) String() string {
-	return RPUSH
+func (s *SINTERSTORESpecs) String() string {
+	return SINTERSTORE
 }
-func (s *RPUSHSpecs) ParseScaler(args ...Token) (int, error) {
+func (s *SINTERSTORESpecs) ParseScaler(args ...Token) (int, error) {
 	strVal0 := args[0].Literal.(string)
-	s.Key = strVal0
+	s.Destination = strVal0
 
-	s.Elements = make([]string, 0)
+	s.Keys = make([]string, 0)
 	for _, el := range args[1:] {
-		s.Elements = append(s.Elements, el.Literal.(string))
+		s.Keys = append(s.Keys, el.Literal.(string))
 	}
 
 	return 2, nil
 }
 
-type LRANGESpecs struct {
+type SUNIONSTORESpecs struct {
+	Destination string
+	Keys        []string
+}
+
+func (s *SUNIONSTORESpecs) String() string {
+	return SUNIONSTORE
+}
+func (s *SUNIONSTORESpecs) ParseScaler(args ...Token) (int, error) {
+	strVal0 := args[0].Literal.(string)
+	s.Destination = strVal0
+
+	s.Keys = make([]string, 0)
+	for _, el := range args[1:] {
+		s.Keys = append(s.Keys, el.Literal.(string))
+	}
+
+	return 2, nil
+}
+
+type SDIFFSTORESpecs struct {
+	Destination string
+	Keys        []string
+}
+
+func (s *SDIFFSTORESpecs) String() string {
+	return SDIFFSTORE
+}
+func (s *SDIFFSTORESpecs) ParseScaler(args ...Token) (int, error) {
+	strVal0 := args[0].Literal.(string)
+	s.Destination = strVal0
+
+	s.Keys = make([]string, 0)
+	for _, el := range args[1:] {
+		s.Keys = append(s.Keys, el.Literal.(string))
+	}
+
+	return 2, nil
+}
+
+type SINTERCARDSpecs struct {
+	Keys  []string
+	Limit int64
+}
+
+func (s *SINTERCARDSpecs) String() string {
+	return SINTERCARD
+}
+
+type RPOPSpecs struct {
 	Key   string
-	Start int64
-	End   int64
+	Count *int64
 }
 
-func (s *LRANGESpecs) String() string {
-	return LRANGE
+func (s *RPOPSpecs) String() string {
+	return RPOP
 }
-func (s *LRANGESpecs) ParseScaler(args ...Token) (int, error) {
+func (s *RPOPSpecs) ParseScaler(args ...Token) (int, error) {
+	strVal0 := args[0].Literal.(string)
+	s.Key = strVal0
+
+	if len(args) > 1 {
+		if parsed, err := strconv.ParseInt(args[1].Literal.(string), 10, 64); err != nil {
+			return 0, &ErrNotInteger{data: args[1].Literal}
+		} else {
+			intVal1 := parsed
+			s.Count = &intVal1
+		}
+
+	}
+
+	return 2, nil
+}
+
+type LINDEXSpecs struct {
+	Key   string
+	Index int64
+}
+
+func (s *LINDEXSpecs) String() string {
+	return LINDEX
+}
+func (s *LINDEXSpecs) ParseScaler(args ...Token) (int, error) {
 	strVal0 := args[0].Literal.(string)
 	s.Key = strVal0
 
 	if parsed, err := strconv.ParseInt(args[1].Literal.(string), 10, 64); err != nil {
-		return 0, err
+		return 0, &ErrNotInteger{data: args[1].Literal}
 	} else {
 		intVal1 := parsed
-		s.Start = intVal1
+		s.Index = intVal1
 	}
 
-	if parsed, err := strconv.ParseInt(args[2].Literal.(string), 10, 64); err != nil {
-		return 0, err
+	return 2, nil
+}
+
+type LSETSpecs struct {
+	Key     string
+	Index   int64
+	Element string
+}
+
+func (s *LSETSpecs) String() string {
+	return LSET
+}
+func (s *LSETSpecs) ParseScaler(args ...Token) (int, error) {
+	strVal0 := args[0].Literal.(string)
+	s.Key = strVal0
+
+	if parsed, err := strconv.ParseInt(args[1].Literal.(string), 10, 64); err != nil {
+		return 0, &ErrNotInteger{data: args[1].Literal}
 	} else {
-		intVal2 := parsed
-		s.End = intVal2
+		intVal1 := parsed
+		s.Index = intVal1
 	}
 
+	strVal2 := args[2].Literal.(string)
+	s.Element = strVal2
+
 	return 3, nil
 }
 
-type LPUSHSpecs struct {
-	Key      string
-	Elements []string
+type LINSERTSpecs struct {
+	Key     string
+	Where   string
+	Pivot   string
+	Element string
 }
 
-func (s *LPUSHSpecs) String() string {
-	return LPUSH
+func (s *LINSERTSpecs) String() string {
+	return LINSERT
 }
-func (s *LPUSHSpecs) ParseScaler(args ...Token) (int, error) {
+func (s *LINSERTSpecs) ParseScaler(args ...Token) (int, error) {
 	strVal0 := args[0].Literal.(string)
 	s.Key = strVal0
 
-	s.Elements = make([]string, 0)
-	for _, el := range args[1:] {
-		s.Elements = append(s.Elements, el.Literal.(string))
-	}
+	strVal1 := args[1].Literal.(string)
+	s.Where = strVal1
 
-	return 2, nil
+	strVal2 := args[2].Literal.(string)
+	s.Pivot = strVal2
+
+	strVal3 := args[3].Literal.(string)
+	s.Element = strVal3
+
+	return 4, nil
 }
 
-type LLENSpecs struct {
-	Key string
+type LREMSpecs struct {
+	Key     string
+	Count   int64
+	Element string
 }
 
-func (s *LLENSpecs) String() string {
-	return LLEN
+func (s *LREMSpecs) String() string {
+	return LREM
 }
-func (s *LLENSpecs) ParseScaler(args ...Token) (int, error) {
+func (s *LREMSpecs) ParseScaler(args ...Token) (int, error) {
 	strVal0 := args[0].Literal.(string)
 	s.Key = strVal0
 
-	return 1, nil
+	if parsed, err := strconv.ParseInt(args[1].Literal.(string), 10, 64); err != nil {
+		return 0, &ErrNotInteger{data: args[1].Literal}
+	} else {
+		intVal1 := parsed
+		s.Count = intVal1
+	}
+
+	strVal2 := args[2].Literal.(string)
+	s.Element = strVal2
+
+	return 3, nil
 }
 
-type LPOPSpecs struct {
-	Key            string
-	AmountToRemove *int64
+type LTRIMSpecs struct {
+	Key   string
+	Start int64
+	Stop  int64
 }
 
-func (s *LPOPSpecs) String() string {
-	return LPOP
+func (s *LTRIMSpecs) String() string {
+	return LTRIM
 }
-func (s *LPOPSpecs) ParseScaler(args ...Token) (int, error) {
+func (s *LTRIMSpecs) ParseScaler(args ...Token) (int, error) {
 	strVal0 := args[0].Literal.(string)
 	s.Key = strVal0
 
-	if len(args) > 1 {
-		if parsed, err := strconv.ParseInt(args[1].Literal.(string), 10, 64); err != nil {
-			return 0, err
-		} else {
-			intVal1 := parsed
-			s.AmountToRemove = &intVal1
-		}
+	if parsed, err := strconv.ParseInt(args[1].Literal.(string), 10, 64); err != nil {
+		return 0, &ErrNotInteger{data: args[1].Literal}
+	} else {
+		intVal1 := parsed
+		s.Start = intVal1
+	}
 
+	if parsed, err := strconv.ParseInt(args[2].Literal.(string), 10, 64); err != nil {
+		return 0, &ErrNotInteger{data: args[2].Literal}
+	} else {
+		intVal2 := parsed
+		s.Stop = intVal2
 	}
 
-	return 2, nil
+	return 3, nil
 }
 
-type BLPOPSpecs struct {
-	Keys      []string
-	Lifetime  *float64
-	Concluded bool
+type LPOSSpecs struct {
+	Key       string
+	Element   string
+	Options   ListPosOptions
+	WithCount bool
 }
 
-func (s *BLPOPSpecs) String() string {
-	return BLPOP
+func (s *LPOSSpecs) String() string {
+	return LPOS
 }
 
-type WAITSpecs struct {
-	NumReplicas uint64
-	Timeout     uint64
+type LPUSHXSpecs struct {
+	Key      string
+	Elements []string
 }
 
-func (s *WAITSpecs) String() string {
-	return WAIT
+func (s *LPUSHXSpecs) String() string {
+	return LPUSHX
 }
-func (s *WAITSpecs) ParseScaler(args ...Token) (int, error) {
-	if parsed, err := strconv.ParseUint(args[0].Literal.(string), 10, 64); err != nil {
-		return 0, err
-	} else {
-		uintVal0 := parsed
-		s.NumReplicas = uintVal0
+func (s *LPUSHXSpecs) ParseScaler(args ...Token) (int, error) {
+	strVal0 := args[0].Literal.(string)
+	s.Key = strVal0
+
+	s.Elements = make([]string, 0)
+	for _, el := range args[1:] {
+		s.Elements = append(s.Elements, el.Literal.(string))
 	}
 
-	if parsed, err := strconv.ParseUint(args[1].Literal.(string), 10, 64); err != nil {
-		return 0, err
-	} else {
-		uintVal1 := parsed
-		s.Timeout = uintVal1
+	return 2, nil
+}
+
+type RPUSHXSpecs struct {
+	Key      string
+	Elements []string
+}
+
+func (s *RPUSHXSpecs) String() string {
+	return RPUSHX
+}
+func (s *RPUSHXSpecs) ParseScaler(args ...Token) (int, error) {
+	strVal0 := args[0].Literal.(string)
+	s.Key = strVal0
+
+	s.Elements = make([]string, 0)
+	for _, el := range args[1:] {
+		s.Elements = append(s.Elements, el.Literal.(string))
 	}
 
 	return 2, nil
 }
 
-type SUBSCRIBESpecs struct {
-	Key string
+type BRPOPSpecs struct {
+	Keys     []string
+	Lifetime *float64
+}
+
+func (s *BRPOPSpecs) String() string {
+	return BRPOP
+}
+
+type LMOVESpecs struct {
+	Source      string
+	Destination string
+	WhereFrom   string
+	WhereTo     string
+}
+
+func (s *LMOVESpecs) String() string {
+	return LMOVE
 }
 
-func (s *SUBSCRIBESpecs) String() string {
-	return SUBSCRIBE
+type BLMOVESpecs struct {
+	Source      string
+	Destination string
+	WhereFrom   string
+	WhereTo     string
+	Lifetime    *float64
 }
-func (s *SUBSCRIBESpecs) ParseScaler(args ...Token) (int, error) {
-	strVal0 := args[0].Literal.(string)
-	s.Key = strVal0
 
-	return 1, nil
+func (s *BLMOVESpecs) String() string {
+	return BLMOVE
 }
 
-type UNSUBSCRIBESpecs struct {
-	Key string
+type BRPOPLPUSHSpecs struct {
+	Source      string
+	Destination string
+	Lifetime    *float64
 }
 
-func (s *UNSUBSCRIBESpecs) String() string {
-	return UNSUBSCRIBE
+func (s *BRPOPLPUSHSpecs) String() string {
+	return BRPOPLPUSH
 }
-func (s *UNSUBSCRIBESpecs) ParseScaler(args ...Token) (int, error) {
-	strVal0 := args[0].Literal.(string)
-	s.Key = strVal0
 
-	return 1, nil
+type LMPOPSpecs struct {
+	Keys  []string
+	Where string
+	Count int64
 }
 
-type QUITSpecs struct {
+func (s *LMPOPSpecs) String() string {
+	return LMPOP
 }
 
-func (s *QUITSpecs) String() string {
-	return QUIT
+type BLMPOPSpecs struct {
+	Lifetime *float64
+	Keys     []string
+	Where    string
+	Count    int64
 }
 
-type PUBLISHSpecs struct {
-	Key     string
-	Message string
+func (s *BLMPOPSpecs) String() string {
+	return BLMPOP
 }
 
-func (s *PUBLISHSpecs) String() string {
-	return PUBLISH
+type ZRANGESTORESpecs struct {
+	Destination string
+	Source      string
+	Options     ZRangeOptions
 }
-func (s *PUBLISHSpecs) ParseScaler(args ...Token) (int, error) {
-	strVal0 := args[0].Literal.(string)
-	s.Key = strVal0
 
-	strVal1 := args[1].Literal.(string)
-	s.Message = strVal1
+func (s *ZRANGESTORESpecs) String() string {
+	return ZRANGESTORE
+}
 
-	return 2, nil
+type ZREVRANGESpecs struct {
+	Key        string
+	Options    ZRangeOptions
+	WithScores bool
 }
 
-type ACL_WHOAMISpecs struct {
+func (s *ZREVRANGESpecs) String() string {
+	return ZREVRANGE
 }
 
-func (s *ACL_WHOAMISpecs) String() string {
-	return ACL_WHOAMI
+type ZRANGEBYSCORESpecs struct {
+	Key        string
+	Options    ZRangeOptions
+	WithScores bool
 }
 
-type ACL_GETUSERSpecs struct {
-	User string
+func (s *ZRANGEBYSCORESpecs) String() string {
+	return ZRANGEBYSCORE
 }
 
-func (s *ACL_GETUSERSpecs) String() string {
-	return ACL_GETUSER
+type ZREVRANGEBYSCORESpecs struct {
+	Key        string
+	Options    ZRangeOptions
+	WithScores bool
 }
-func (s *ACL_GETUSERSpecs) ParseScaler(args ...Token) (int, error) {
-	strVal0 := args[0].Literal.(string)
-	s.User = strVal0
 
-	return 1, nil
+func (s *ZREVRANGEBYSCORESpecs) String() string {
+	return ZREVRANGEBYSCORE
 }
 
-type ACL_SETUSERSpecs struct {
-	Username string
-	Rules    []string
+type ZRANGEBYLEXSpecs struct {
+	Key        string
+	Options    ZRangeOptions
+	WithScores bool
 }
 
-func (s *ACL_SETUSERSpecs) String() string {
-	return ACL_SETUSER
+func (s *ZRANGEBYLEXSpecs) String() string {
+	return ZRANGEBYLEX
 }
-func (s *ACL_SETUSERSpecs) ParseScaler(args ...Token) (int, error) {
-	strVal0 := args[0].Literal.(string)
-	s.Username = strVal0
 
-	s.Rules = make([]string, 0)
-	for _, el := range args[1:] {
-		s.Rules = append(s.Rules, el.Literal.(string))
-	}
+type ZREVRANGEBYLEXSpecs struct {
+	Key        string
+	Options    ZRangeOptions
+	WithScores bool
+}
 
-	return 2, nil
+func (s *ZREVRANGEBYLEXSpecs) String() string {
+	return ZREVRANGEBYLEX
 }
 
-type AUTHSpecs struct {
-	Username string
-	Password string
+type ZREVRANKSpecs struct {
+	Key   string
+	Value string
 }
 
-func (s *AUTHSpecs) String() string {
-	return AUTH
+func (s *ZREVRANKSpecs) String() string {
+	return ZREVRANK
 }
-func (s *AUTHSpecs) ParseScaler(args ...Token) (int, error) {
+func (s *ZREVRANKSpecs) ParseScaler(args ...Token) (int, error) {
 	strVal0 := args[0].Literal.(string)
-	s.Username = strVal0
+	s.Key = strVal0
 
 	strVal1 := args[1].Literal.(string)
-	s.Password = strVal1
+	s.Value = strVal1
 
 	return 2, nil
 }
 
-type ZADDSpecs struct {
-	Key   string
-	Score float64
-	Value string
+type ZCOUNTSpecs struct {
+	Key    string
+	Scores ScoreRange
 }
 
-func (s *ZADDSpecs) String() string {
-	return ZADD
+func (s *ZCOUNTSpecs) String() string {
+	return ZCOUNT
+}
+
+type ZLEXCOUNTSpecs struct {
+	Key string
+	Lex LexRange
+}
+
+func (s *ZLEXCOUNTSpecs) String() string {
+	return ZLEXCOUNT
+}
+
+type ZINCRBYSpecs struct {
+	Key       string
+	Increment float64
+	Member    string
+}
+
+func (s *ZINCRBYSpecs) String() string {
+	return ZINCRBY
 }
-func (s *ZADDSpecs) ParseScaler(args ...Token) (int, error) {
+func (s *ZINCRBYSpecs) ParseScaler(args ...Token) (int, error) {
 	strVal0 := args[0].Literal.(string)
 	s.Key = strVal0
 
 	if parsed, err := strconv.ParseFloat(args[1].Literal.(string), 64); err != nil {
-		return 0, err
+		return 0, &ErrNotFloat{}
 	} else {
 		floatVal1 := parsed
-		s.Score = floatVal1
+		s.Increment = floatVal1
 	}
 
 	strVal2 := args[2].Literal.(string)
-	s.Value = strVal2
+	s.Member = strVal2
 
 	return 3, nil
 }
 
-type ZRANKSpecs struct {
-	Key   string
-	Value string
+type ZMSCORESpecs struct {
+	Key     string
+	Members []string
 }
 
-func (s *ZRANKSpecs) String() string {
-	return ZRANK
+func (s *ZMSCORESpecs) String() string {
+	return ZMSCORE
 }
-func (s *ZRANKSpecs) ParseScaler(args ...Token) (int, error) {
+func (s *ZMSCORESpecs) ParseScaler(args ...Token) (int, error) {
 	strVal0 := args[0].Literal.(string)
 	s.Key = strVal0
 
-	strVal1 := args[1].Literal.(string)
-	s.Value = strVal1
+	s.Members = make([]string, 0)
+	for _, el := range args[1:] {
+		s.Members = append(s.Members, el.Literal.(string))
+	}
 
 	return 2, nil
 }
 
-type ZRANGESpecs struct {
+type ZPOPMINSpecs struct {
 	Key   string
-	Start int64
-	End   int64
+	Count *int64
 }
 
-func (s *ZRANGESpecs) String() string {
-	return ZRANGE
+func (s *ZPOPMINSpecs) String() string {
+	return ZPOPMIN
 }
-func (s *ZRANGESpecs) ParseScaler(args ...Token) (int, error) {
+func (s *ZPOPMINSpecs) ParseScaler(args ...Token) (int, error) {
 	strVal0 := args[0].Literal.(string)
 	s.Key = strVal0
 
-	if parsed, err := strconv.ParseInt(args[1].Literal.(string), 10, 64); err != nil {
-		return 0, err
-	} else {
-		intVal1 := parsed
-		s.Start = intVal1
-	}
+	if len(args) > 1 {
+		if parsed, err := strconv.ParseInt(args[1].Literal.(string), 10, 64); err != nil {
+			return 0, &ErrNotInteger{data: args[1].Literal}
+		} else {
+			intVal1 := parsed
+			s.Count = &intVal1
+		}
 
-	if parsed, err := strconv.ParseInt(args[2].Literal.(string), 10, 64); err != nil {
-		return 0, err
-	} else {
-		intVal2 := parsed
-		s.End = intVal2
 	}
 
-	return 3, nil
+	return 2, nil
 }
 
-type ZCARDSpecs struct {
-	Key string
+type ZPOPMAXSpecs struct {
+	Key   string
+	Count *int64
 }
 
-func (s *ZCARDSpecs) String() string {
-	return ZCARD
+func (s *ZPOPMAXSpecs) String() string {
+	return ZPOPMAX
 }
-func (s *ZCARDSpecs) ParseScaler(args ...Token) (int, error) {
+func (s *ZPOPMAXSpecs) ParseScaler(args ...Token) (int, error) {
 	strVal0 := args[0].Literal.(string)
 	s.Key = strVal0
 
-	return 1, nil
+	if len(args) > 1 {
+		if parsed, err := strconv.ParseInt(args[1].Literal.(string), 10, 64); err != nil {
+			return 0, &ErrNotInteger{data: args[1].Literal}
+		} else {
+			intVal1 := parsed
+			s.Count = &intVal1
+		}
+
+	}
+
+	return 2, nil
 }
 
-type ZSCORESpecs struct {
-	Key   string
-	Value string
+type BZPOPMINSpecs struct {
+	Keys     []string
+	Lifetime *float64
 }
 
-func (s *ZSCORESpecs) String() string {
-	return ZSCORE
+func (s *BZPOPMINSpecs) String() string {
+	return BZPOPMIN
 }
-func (s *ZSCORESpecs) ParseScaler(args ...Token) (int, error) {
-	strVal0 := args[0].Literal.(string)
-	s.Key = strVal0
 
-	strVal1 := args[1].Literal.(string)
-	s.Value = strVal1
+type BZPOPMAXSpecs struct {
+	Keys     []string
+	Lifetime *float64
+}
 
-	return 2, nil
+func (s *BZPOPMAXSpecs) String() string {
+	return BZPOPMAX
 }
 
-type ZREMSpecs struct {
-	Key   string
-	Value string
+type ZMPOPSpecs struct {
+	Keys  []string
+	Where string
+	Count int64
 }
 
-func (s *ZREMSpecs) String() string {
-	return ZREM
+func (s *ZMPOPSpecs) String() string {
+	return ZMPOP
 }
-func (s *ZREMSpecs) ParseScaler(args ...Token) (int, error) {
-	strVal0 := args[0].Literal.(string)
-	s.Key = strVal0
 
-	strVal1 := args[1].Literal.(string)
-	s.Value = strVal1
+type BZMPOPSpecs struct {
+	Lifetime *float64
+	Keys     []string
+	Where    string
+	Count    int64
+}
 
-	return 2, nil
+func (s *BZMPOPSpecs) String() string {
+	return BZMPOP
 }
 
-type WATCHSpecs struct {
-	Keys []string
+type ZRANDMEMBERSpecs struct {
+	Key        string
+	Count      *int64
+	WithScores bool
 }
 
-func (s *WATCHSpecs) String() string {
-	return WATCH
+func (s *ZRANDMEMBERSpecs) String() string {
+	return ZRANDMEMBER
 }
-func (s *WATCHSpecs) ParseScaler(args ...Token) (int, error) {
-	s.Keys = make([]string, 0)
-	for _, el := range args[0:] {
-		s.Keys = append(s.Keys, el.Literal.(string))
-	}
 
-	return 1, nil
+type ZREMRANGEBYRANKSpecs struct {
+	Key     string
+	Options ZRangeOptions
 }
 
-type UNWATCHSpecs struct {
+func (s *ZREMRANGEBYRANKSpecs) String() string {
+	return ZREMRANGEBYRANK
 }
 
-func (s *UNWATCHSpecs) String() string {
-	return UNWATCH
+type ZREMRANGEBYSCORESpecs struct {
+	Key     string
+	Options ZRangeOptions
 }
 
-type GEOADDSpecs struct {
-	Key    string
-	Lng    float64
-	Lat    float64
-	Member string
+func (s *ZREMRANGEBYSCORESpecs) String() string {
+	return ZREMRANGEBYSCORE
 }
 
-func (s *GEOADDSpecs) String() string {
-	return GEOADD
+type ZREMRANGEBYLEXSpecs struct {
+	Key     string
+	Options ZRangeOptions
 }
-func (s *GEOADDSpecs) ParseScaler(args ...Token) (int, error) {
+
+func (s *ZREMRANGEBYLEXSpecs) String() string {
+	return ZREMRANGEBYLEX
+}
+
+type ZUNIONSTORESpecs struct {
+	Destination string
+	Keys        []string
+	Options     ZCombineOptions
+}
+
+func (s *ZUNIONSTORESpecs) String() string {
+	return ZUNIONSTORE
+}
+
+type ZINTERSTORESpecs struct {
+	Destination string
+	Keys        []string
+	Options     ZCombineOptions
+}
+
+func (s *ZINTERSTORESpecs) String() string {
+	return ZINTERSTORE
+}
+
+type ZDIFFSTORESpecs struct {
+	Destination string
+	Keys        []string
+}
+
+func (s *ZDIFFSTORESpecs) String() string {
+	return ZDIFFSTORE
+}
+
+type ZUNIONSpecs struct {
+	Keys       []string
+	Options    ZCombineOptions
+	WithScores bool
+}
+
+func (s *ZUNIONSpecs) String() string {
+	return ZUNION
+}
+
+type ZINTERSpecs struct {
+	Keys       []string
+	Options    ZCombineOptions
+	WithScores bool
+}
+
+func (s *ZINTERSpecs) String() string {
+	return ZINTER
+}
+
+type ZDIFFSpecs struct {
+	Keys       []string
+	WithScores bool
+}
+
+func (s *ZDIFFSpecs) String() string {
+	return ZDIFF
+}
+
+type ZINTERCARDSpecs struct {
+	Keys  []string
+	Limit int64
+}
+
+func (s *ZINTERCARDSpecs) String() string {
+	return ZINTERCARD
+}
+
+type PFADDSpecs struct {
+	Key      string
+	Elements []string
+}
+
+func (s *PFADDSpecs) String() string {
+	return PFADD
+}
+func (s *PFADDSpecs) ParseScaler(args ...Token) (int, error) {
 	strVal0 := args[0].Literal.(string)
 	s.Key = strVal0
 
-	if parsed, err := strconv.ParseFloat(args[1].Literal.(string), 64); err != nil {
-		return 0, err
-	} else {
-		floatVal1 := parsed
-		s.Lng = floatVal1
+	s.Elements = make([]string, 0)
+	for _, el := range args[1:] {
+		s.Elements = append(s.Elements, el.Literal.(string))
 	}
 
-	if parsed, err := strconv.ParseFloat(args[2].Literal.(string), 64); err != nil {
-		return 0, err
-	} else {
-		floatVal2 := parsed
-		s.Lat = floatVal2
-	}
+	return 2, nil
+}
 
-	strVal3 := args[3].Literal.(string)
-	s.Member = strVal3
+type PFCOUNTSpecs struct {
+	Keys []string
+}
 
-	return 4, nil
+func (s *PFCOUNTSpecs) String() string {
+	return PFCOUNT
 }
+func (s *PFCOUNTSpecs) ParseScaler(args ...Token) (int, error) {
+	s.Keys = make([]string, 0)
+	for _, el := range args[0:] {
+		s.Keys = append(s.Keys, el.Literal.(string))
+	}
 
-type GEOPOSSpecs struct {
-	Key  string
-	Locs []string
+	return 1, nil
 }
 
-func (s *GEOPOSSpecs) String() string {
-	return GEOPOS
+type PFMERGESpecs struct {
+	Destination string
+	Keys        []string
 }
-func (s *GEOPOSSpecs) ParseScaler(args ...Token) (int, error) {
+
+func (s *PFMERGESpecs) String() string {
+	return PFMERGE
+}
+func (s *PFMERGESpecs) ParseScaler(args ...Token) (int, error) {
 	strVal0 := args[0].Literal.(string)
-	s.Key = strVal0
+	s.Destination = strVal0
 
-	s.Locs = make([]string, 0)
+	s.Keys = make([]string, 0)
 	for _, el := range args[1:] {
-		s.Locs = append(s.Locs, el.Literal.(string))
+		s.Keys = append(s.Keys, el.Literal.(string))
 	}
 
 	return 2, nil
@@ -985,6 +3819,14 @@ func ParseSpec(cmd string, args ...Token) (specs Specs, err error) {
 		specs = &GETSpecs{}
 	case INCR:
 		specs = &INCRSpecs{}
+	case INCRBY:
+		specs = &INCRBYSpecs{}
+	case DECR:
+		specs = &DECRSpecs{}
+	case DECRBY:
+		specs = &DECRBYSpecs{}
+	case INCRBYFLOAT:
+		specs = &INCRBYFLOATSpecs{}
 	case MULTI:
 		specs = &MULTISpecs{}
 	case EXEC:
@@ -1003,6 +3845,16 @@ func ParseSpec(cmd string, args ...Token) (specs Specs, err error) {
 		specs = &KEYSSpecs{}
 	case XADD:
 		specs = &XADDSpecs{}
+	case XLEN:
+		specs = &XLENSpecs{}
+	case XRANGE:
+		specs = &XRANGESpecs{}
+	case XREVRANGE:
+		specs = &XREVRANGESpecs{}
+	case XREAD:
+		specs = &XREADSpecs{}
+	case XINFO_STREAM:
+		specs = &XINFO_STREAMSpecs{}
 	case TYPE:
 		specs = &TYPESpecs{}
 	case RPUSH:
@@ -1055,12 +3907,231 @@ func ParseSpec(cmd string, args ...Token) (specs Specs, err error) {
 		specs = &GEOADDSpecs{}
 	case GEOPOS:
 		specs = &GEOPOSSpecs{}
+	case DEL:
+		specs = &DELSpecs{}
+	case UNLINK:
+		specs = &UNLINKSpecs{}
+	case EXISTS:
+		specs = &EXISTSSpecs{}
+	case EXPIRE:
+		specs = &EXPIRESpecs{}
+	case PEXPIRE:
+		specs = &PEXPIRESpecs{}
+	case EXPIREAT:
+		specs = &EXPIREATSpecs{}
+	case PEXPIREAT:
+		specs = &PEXPIREATSpecs{}
+	case TTL:
+		specs = &TTLSpecs{}
+	case PTTL:
+		specs = &PTTLSpecs{}
+	case EXPIRETIME:
+		specs = &EXPIRETIMESpecs{}
+	case PEXPIRETIME:
+		specs = &PEXPIRETIMESpecs{}
+	case PERSIST:
+		specs = &PERSISTSpecs{}
+	case SCAN:
+		specs = &SCANSpecs{}
+	case ZSCAN:
+		specs = &ZSCANSpecs{}
+	case APPEND:
+		specs = &APPENDSpecs{}
+	case STRLEN:
+		specs = &STRLENSpecs{}
+	case GETRANGE:
+		specs = &GETRANGESpecs{}
+	case SETRANGE:
+		specs = &SETRANGESpecs{}
+	case MGET:
+		specs = &MGETSpecs{}
+	case MSET:
+		specs = &MSETSpecs{}
+	case MSETNX:
+		specs = &MSETNXSpecs{}
+	case GETDEL:
+		specs = &GETDELSpecs{}
+	case GETEX:
+		specs = &GETEXSpecs{}
+	case GETSET:
+		specs = &GETSETSpecs{}
+	case HSET:
+		specs = &HSETSpecs{}
+	case HSETNX:
+		specs = &HSETNXSpecs{}
+	case HGET:
+		specs = &HGETSpecs{}
+	case HMGET:
+		specs = &HMGETSpecs{}
+	case HDEL:
+		specs = &HDELSpecs{}
+	case HGETALL:
+		specs = &HGETALLSpecs{}
+	case HINCRBY:
+		specs = &HINCRBYSpecs{}
+	case HINCRBYFLOAT:
+		specs = &HINCRBYFLOATSpecs{}
+	case HLEN:
+		specs = &HLENSpecs{}
+	case HEXISTS:
+		specs = &HEXISTSSpecs{}
+	case HKEYS:
+		specs = &HKEYSSpecs{}
+	case HVALS:
+		specs = &HVALSSpecs{}
+	case HSTRLEN:
+		specs = &HSTRLENSpecs{}
+	case HSCAN:
+		specs = &HSCANSpecs{}
+	case SETBIT:
+		specs = &SETBITSpecs{}
+	case GETBIT:
+		specs = &GETBITSpecs{}
+	case BITCOUNT:
+		specs = &BITCOUNTSpecs{}
+	case BITPOS:
+		specs = &BITPOSSpecs{}
+	case BITOP:
+		specs = &BITOPSpecs{}
+	case BITFIELD:
+		specs = &BITFIELDSpecs{}
+	case BITFIELD_RO:
+		specs = &BITFIELD_ROSpecs{}
+	case SADD:
+		specs = &SADDSpecs{}
+	case SREM:
+		specs = &SREMSpecs{}
+	case SMEMBERS:
+		specs = &SMEMBERSSpecs{}
+	case SISMEMBER:
+		specs = &SISMEMBERSpecs{}
+	case SMISMEMBER:
+		specs = &SMISMEMBERSpecs{}
+	case SCARD:
+		specs = &SCARDSpecs{}
+	case SPOP:
+		specs = &SPOPSpecs{}
+	case SRANDMEMBER:
+		specs = &SRANDMEMBERSpecs{}
+	case SMOVE:
+		specs = &SMOVESpecs{}
+	case SSCAN:
+		specs = &SSCANSpecs{}
+	case SINTER:
+		specs = &SINTERSpecs{}
+	case SUNION:
+		specs = &SUNIONSpecs{}
+	case SDIFF:
+		specs = &SDIFFSpecs{}
+	case SINTERSTORE:
+		specs = &SINTERSTORESpecs{}
+	case SUNIONSTORE:
+		specs = &SUNIONSTORESpecs{}
+	case SDIFFSTORE:
+		specs = &SDIFFSTORESpecs{}
+	case SINTERCARD:
+		specs = &SINTERCARDSpecs{}
+	case RPOP:
+		specs = &RPOPSpecs{}
+	case LINDEX:
+		specs = &LINDEXSpecs{}
+	case LSET:
+		specs = &LSETSpecs{}
+	case LINSERT:
+		specs = &LINSERTSpecs{}
+	case LREM:
+		specs = &LREMSpecs{}
+	case LTRIM:
+		specs = &LTRIMSpecs{}
+	case LPOS:
+		specs = &LPOSSpecs{}
+	case LPUSHX:
+		specs = &LPUSHXSpecs{}
+	case RPUSHX:
+		specs = &RPUSHXSpecs{}
+	case BRPOP:
+		specs = &BRPOPSpecs{}
+	case LMOVE:
+		specs = &LMOVESpecs{}
+	case BLMOVE:
+		specs = &BLMOVESpecs{}
+	case BRPOPLPUSH:
+		specs = &BRPOPLPUSHSpecs{}
+	case LMPOP:
+		specs = &LMPOPSpecs{}
+	case BLMPOP:
+		specs = &BLMPOPSpecs{}
+	case ZRANGESTORE:
+		specs = &ZRANGESTORESpecs{}
+	case ZREVRANGE:
+		specs = &ZREVRANGESpecs{}
+	case ZRANGEBYSCORE:
+		specs = &ZRANGEBYSCORESpecs{}
+	case ZREVRANGEBYSCORE:
+		specs = &ZREVRANGEBYSCORESpecs{}
+	case ZRANGEBYLEX:
+		specs = &ZRANGEBYLEXSpecs{}
+	case ZREVRANGEBYLEX:
+		specs = &ZREVRANGEBYLEXSpecs{}
+	case ZREVRANK:
+		specs = &ZREVRANKSpecs{}
+	case ZCOUNT:
+		specs = &ZCOUNTSpecs{}
+	case ZLEXCOUNT:
+		specs = &ZLEXCOUNTSpecs{}
+	case ZINCRBY:
+		specs = &ZINCRBYSpecs{}
+	case ZMSCORE:
+		specs = &ZMSCORESpecs{}
+	case ZPOPMIN:
+		specs = &ZPOPMINSpecs{}
+	case ZPOPMAX:
+		specs = &ZPOPMAXSpecs{}
+	case BZPOPMIN:
+		specs = &BZPOPMINSpecs{}
+	case BZPOPMAX:
+		specs = &BZPOPMAXSpecs{}
+	case ZMPOP:
+		specs = &ZMPOPSpecs{}
+	case BZMPOP:
+		specs = &BZMPOPSpecs{}
+	case ZRANDMEMBER:
+		specs = &ZRANDMEMBERSpecs{}
+	case ZREMRANGEBYRANK:
+		specs = &ZREMRANGEBYRANKSpecs{}
+	case ZREMRANGEBYSCORE:
+		specs = &ZREMRANGEBYSCORESpecs{}
+	case ZREMRANGEBYLEX:
+		specs = &ZREMRANGEBYLEXSpecs{}
+	case ZUNIONSTORE:
+		specs = &ZUNIONSTORESpecs{}
+	case ZINTERSTORE:
+		specs = &ZINTERSTORESpecs{}
+	case ZDIFFSTORE:
+		specs = &ZDIFFSTORESpecs{}
+	case ZUNION:
+		specs = &ZUNIONSpecs{}
+	case ZINTER:
+		specs = &ZINTERSpecs{}
+	case ZDIFF:
+		specs = &ZDIFFSpecs{}
+	case ZINTERCARD:
+		specs = &ZINTERCARDSpecs{}
+	case PFADD:
+		specs = &PFADDSpecs{}
+	case PFCOUNT:
+		specs = &PFCOUNTSpecs{}
+	case PFMERGE:
+		specs = &PFMERGESpecs{}
 	}
 	if specs == nil {
 		return
 	}
 	if vp, ok := specs.(FullParser); ok {
-		vp.Parse(args...)
+		err = vp.Parse(args...)
+		if err != nil {
+			return nil, err
+		}
 		return
 	}
 	var consumed int
@@ -70,6 +70,11 @@ func (e *encoder) EncodeToken(t Token) {
 	}
 	switch t.Type {
 	case BULK_STRING:
+		if t.Literal == nil {
+			// Null bulk string
+			e.bulkString(nil)
+			break
+		}
 		t, _ := t.Literal.(string)
 		e.bulkString(&t)
 	case SIMPLE_STRING:
@@ -148,12 +153,8 @@ func (e *encoder) integer(data int) *encoder {
 		e.err = fmt.Errorf("encoding process is already commited")
 		return nil
 	}
-	// :[<+|->]<value>\r\n
-	sign := ""
-	if data < 0 {
-		sign = "-"
-	}
-	_, e.err = fmt.Fprintf(e.writer, "%v%v%v\r\n", INTEGER, sign, data)
+	// :[<+|->]<value>\r\n, the sign comes with the formatted value
+	_, e.err = fmt.Fprintf(e.writer, "%v%v\r\n", INTEGER, data)
 	return e
 }
 
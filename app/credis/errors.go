@@ -26,6 +26,12 @@ func (e *ErrIdLessThenStreamTop) Error() string {
 	return "ERR The ID specified in XADD is equal or smaller than the target stream top item"
 }
 
+type ErrStreamExhausted struct{}
+
+func (e *ErrStreamExhausted) Error() string {
+	return "ERR The stream has exhausted the last possible ID, unable to add more items"
+}
+
 func (e *UnsupportedCommandForExecution) Error() string {
 	return fmt.Sprintf("ERR unsupported command: %v", e.cmd)
 }
@@ -72,6 +78,20 @@ func (e *NoOtherCommandsInSubscribeContext) Error() string {
 	return fmt.Sprintf("ERR Can't execute '%v': only (P|S)SUBSCRIBE / (P|S)UNSUBSCRIBE / PING / QUIT / RESET are allowed in this context", e.cmd)
 }
 
+type ErrWrongType struct{}
+
+func (e *ErrWrongType) Error() string {
+	return "WRONGTYPE Operation against a key holding the wrong kind of value"
+}
+
+type ErrInvalidExpireTime struct {
+	cmd string
+}
+
+func (e *ErrInvalidExpireTime) Error() string {
+	return fmt.Sprintf("ERR invalid expire time in '%v' command", e.cmd)
+}
+
 type ErrAuthWrongPassword struct{}
 
 func (e *ErrAuthWrongPassword) Error() string {
@@ -83,3 +103,81 @@ type ErrNoAuth struct{}
 func (e *ErrNoAuth) Error() string {
 	return "NOAUTH Authentication required."
 }
+
+type ErrSyntax struct{}
+
+func (e *ErrSyntax) Error() string {
+	return "ERR syntax error"
+}
+
+type ErrInvalidCursor struct{}
+
+func (e *ErrInvalidCursor) Error() string {
+	return "ERR invalid cursor"
+}
+
+type ErrStringTooLong struct{}
+
+func (e *ErrStringTooLong) Error() string {
+	return "ERR string exceeds maximum allowed size (proto-max-bulk-len)"
+}
+
+type ErrNotFloat struct{}
+
+func (e *ErrNotFloat) Error() string {
+	return "ERR value is not a valid float"
+}
+
+type ErrOverflow struct{}
+
+func (e *ErrOverflow) Error() string {
+	return "ERR increment or decrement would overflow"
+}
+
+type ErrNaNOrInfinity struct{}
+
+func (e *ErrNaNOrInfinity) Error() string {
+	return "ERR increment would produce NaN or Infinity"
+}
+
+type ErrHashNotInteger struct{}
+
+func (e *ErrHashNotInteger) Error() string {
+	return "ERR hash value is not an integer"
+}
+
+type ErrHashNotFloat struct{}
+
+func (e *ErrHashNotFloat) Error() string {
+	return "ERR hash value is not a float"
+}
+
+type ErrNotHLL struct{}
+
+func (e *ErrNotHLL) Error() string {
+	return "WRONGTYPE Key is not a valid HyperLogLog string value."
+}
+
+type ErrCorruptedHLL struct{}
+
+func (e *ErrCorruptedHLL) Error() string {
+	return "INVALIDOBJ Corrupted HLL object detected"
+}
+
+type ErrBitOffset struct{}
+
+func (e *ErrBitOffset) Error() string {
+	return "ERR bit offset is not an integer or out of range"
+}
+
+type ErrBitValue struct{}
+
+func (e *ErrBitValue) Error() string {
+	return "ERR bit is not an integer or out of range"
+}
+
+type ErrBitArgument struct{}
+
+func (e *ErrBitArgument) Error() string {
+	return "ERR The bit argument must be 1 or 0."
+}
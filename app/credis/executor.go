@@ -1,16 +1,5 @@
 package credis
 
-import (
-	"time"
-)
-
-type BLPOPHold struct {
-	req     Request
-	resp    []string
-	keys    []string
-	timeout *time.Time
-}
-
 type RDBConfigProvider interface {
 	GetRDBFileName() string
 	GetRDBDir() string
@@ -18,8 +7,7 @@ type RDBConfigProvider interface {
 
 type Executor interface {
 	Exec(req Request) Response
-	processHold(hold *BLPOPHold) (concluded bool, resData []byte)
-	LStore() ListStore[string]
+	serveReady() []servedClient
 }
 
 type Exec interface {
@@ -29,9 +17,15 @@ type Exec interface {
 // Executor must remain stateless to allow concurrent usage
 type executor struct {
 	store struct {
-		KV     KVStore
-		Stream Stream
-		List   ListStore[string]
+		Keyspace  Keyspace
+		KV        KVStore
+		Stream    Stream
+		List      ListStore[string]
+		SortedSet SortedSet
+		Hash      HashStore
+		Set       SetStore
+		HLL       HyperLogLog
+		Waiting   *WaitingArea
 	}
 	// TODO: Need mutex for serverInfo?
 	serverInfo ServerInfo
@@ -50,8 +44,9 @@ func NewExec(
 	}
 }
 
-func (e *executor) LStore() ListStore[string] {
-	return e.store.List
+// serveReady serves the clients blocked on the keys that got data
+func (e *executor) serveReady() []servedClient {
+	return e.store.Waiting.serveReady(e)
 }
 
 func (e *executor) Exec(req Request) Response {
@@ -62,27 +57,3 @@ func (e *executor) Exec(req Request) Response {
 		return notImplemented(req.Specs().String())
 	}
 }
-
-func (e *executor) processHold(hold *BLPOPHold) (concluded bool, resData []byte) {
-	req := hold.req
-	select {
-	case <-req.Ctx().Done():
-		concluded = true
-	default:
-		for i := 0; i < len(hold.keys); i++ {
-			key := hold.keys[i]
-			popped := e.store.List.Pop(key)
-			if popped == nil {
-				return
-			}
-			hold.resp = append(hold.resp, key, *popped)
-		}
-		concluded = true
-		tokens := []Token{}
-		for _, p := range hold.resp {
-			tokens = append(tokens, NewToken(BULK_STRING, p))
-		}
-		resData = NewEncoder().Array(tokens...)
-	}
-	return
-}
@@ -0,0 +1,78 @@
+package credis
+
+import (
+	"time"
+)
+
+// Tuning of the active expire cycle, same defaults as redis
+const (
+	ACTIVE_EXPIRE_CYCLE_PERIOD = 100 * time.Millisecond
+	// Keys sampled per iteration
+	ACTIVE_EXPIRE_CYCLE_KEYS_PER_LOOP = 20
+	// Percentage of expired keys in a sample above which the cycle goes on
+	ACTIVE_EXPIRE_CYCLE_ACCEPTABLE_STALE = 10
+	// Share of the period a single cycle may run for
+	ACTIVE_EXPIRE_CYCLE_TIME_PERC = 25
+)
+
+type KeyspaceStats struct {
+	// Keys removed because their expiry passed
+	ExpiredKeys uint64
+	// Running estimate of the percentage of volatile keys already expired
+	ExpiredStalePerc float64
+}
+
+// ActiveExpire samples volatile keys and removes the expired ones. It keeps
+// sampling while more than ACTIVE_EXPIRE_CYCLE_ACCEPTABLE_STALE percent of
+// a sample was expired, within its time budget. The lock is released between
+// iterations so clients are not starved. Returns the removed keys
+func (ks *keyspace) ActiveExpire(now time.Time) []string {
+	start := time.Now()
+	budget := ACTIVE_EXPIRE_CYCLE_PERIOD * ACTIVE_EXPIRE_CYCLE_TIME_PERC / 100
+	removed := make([]string, 0)
+	totalSampled, totalExpired := 0, 0
+	for {
+		sampled, expired := 0, 0
+		ks.mu.Lock()
+		// Map iteration starts at a random position, good enough as a sample
+		for key := range ks.volatile {
+			if sampled == ACTIVE_EXPIRE_CYCLE_KEYS_PER_LOOP {
+				break
+			}
+			sampled++
+			if val := ks.keys[key]; val == nil || val.isExpired(now) {
+				ks.remove(key)
+				removed = append(removed, key)
+				expired++
+			}
+		}
+		ks.stats.ExpiredKeys += uint64(expired)
+		ks.mu.Unlock()
+		totalSampled += sampled
+		totalExpired += expired
+		if sampled == 0 || expired*100 <= sampled*ACTIVE_EXPIRE_CYCLE_ACCEPTABLE_STALE ||
+			time.Since(start) > budget {
+			break
+		}
+	}
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	perc := 0.0
+	if totalSampled > 0 {
+		perc = float64(totalExpired) * 100 / float64(totalSampled)
+	}
+	ks.stats.ExpiredStalePerc = perc*0.05 + ks.stats.ExpiredStalePerc*0.95
+	return removed
+}
+
+// activeExpire runs the expire cycle of the keyspace forever through the hub,
+// which propagates the removed keys
+func (srv *server) activeExpire() {
+	ticker := time.NewTicker(ACTIVE_EXPIRE_CYCLE_PERIOD)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		srv.hub.Expire(func() []string {
+			return srv.store.Keyspace.ActiveExpire(now)
+		})
+	}
+}
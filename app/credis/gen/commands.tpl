@@ -22,6 +22,8 @@ var commandRegistry = map[string]GenericSpec{
 		MinArgs: {{ .Args.Min }},
 		MaxArgs: {{ .Args.Max }},
 		Supported: true,
+		Propagate: {{ .Propagate }},
+		Keys: KeySpec{First: {{ .Keys.First }}, Last: {{ .Keys.Last }}, Step: {{ .Keys.Step }}},
 	},
 	{{ end }}
 }
@@ -56,6 +58,40 @@ type GenericSpec struct {
 	MinArgs   int
 	MaxArgs   int
 	Supported bool
+	// Writes that have to be replayed on replicas
+	Propagate bool
+	// Keys written by the command. Commands always propagated as other
+	// commands, like LMPOP, need none
+	Keys KeySpec
+}
+
+// KeySpec locates keys in the args of a command, from First to Last every
+// Step args. Negative positions count from the end, a zero Step means none
+type KeySpec struct {
+	First int
+	Last  int
+	Step  int
+}
+
+// Find returns the keys among args
+func (ks KeySpec) Find(args []Token) []string {
+	if ks.Step <= 0 {
+		return nil
+	}
+	first, last := ks.First, ks.Last
+	if first < 0 {
+		first += len(args)
+	}
+	if last < 0 {
+		last += len(args)
+	}
+	keys := []string{}
+	for i := max(first, 0); i <= last && i < len(args); i += ks.Step {
+		if key, ok := args[i].Literal.(string); ok {
+			keys = append(keys, key)
+		}
+	}
+	return keys
 }
 
 func GetGenericSpec(cmd string) GenericSpec {
@@ -66,7 +102,7 @@ func ParseCmd(tkns ...Token) (int, string, error) {
 	argsIndex := 1
 	var c string
 	c = strings.ToLower(tkns[0].Literal.(string))
-	if c == "acl" && len(tkns) >= 2 {
+	if (c == "acl" || c == "xinfo") && len(tkns) >= 2 {
 		subcmd := strings.ToLower(tkns[1].Literal.(string))
 		c = c + "_" + subcmd
 		argsIndex = 2
@@ -104,21 +140,21 @@ func (s *{{ .Name }}Specs) ParseScaler(args ...Token) (int, error) {
 	s.{{ toUpperFirst $arg.Name }} = {{ if isPointer (goType $arg) }}&{{ end }}strVal{{ $i }}
 {{ else if inList (goType $arg) "int64" "*int64" }}
 	if parsed, err := strconv.ParseInt(args[{{ $i }}].Literal.(string), 10, 64); err != nil {
-		return 0, err
+		return 0, &ErrNotInteger{data: args[{{ $i }}].Literal}
 	} else {
 		intVal{{ $i }} := parsed
 		s.{{ toUpperFirst $arg.Name }} = {{ if isPointer (goType $arg) }}&{{ end }}intVal{{ $i }}
 	}
 {{ else if inList (goType $arg) "float64" "*float64" }}
 	if parsed, err := strconv.ParseFloat(args[{{ $i }}].Literal.(string), 64); err != nil {
-		return 0, err
+		return 0, &ErrNotFloat{}
 	} else {
 		floatVal{{ $i }} := parsed
 		s.{{ toUpperFirst $arg.Name }} = {{ if isPointer (goType $arg) }}&{{ end }}floatVal{{ $i }}
 	}
 {{ else if inList (goType $arg) "uint64" "*uint64" }}
 	if parsed, err := strconv.ParseUint(args[{{ $i }}].Literal.(string), 10, 64); err != nil {
-		return 0, err
+		return 0, &ErrNotInteger{data: args[{{ $i }}].Literal}
 	} else {
 		uintVal{{ $i }} := parsed
 		s.{{ toUpperFirst $arg.Name }} = {{ if isPointer (goType $arg) }}&{{ end }}uintVal{{ $i }}
@@ -159,7 +195,10 @@ func ParseSpec(cmd string, args ...Token) (specs Specs, err error) {
 		return
 	}
 	if vp, ok := specs.(FullParser); ok {
-		vp.Parse(args...)
+		err = vp.Parse(args...)
+		if err != nil {
+			return nil, err
+		}
 		return
 	}
 	var consumed int
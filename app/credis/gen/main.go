@@ -40,9 +40,19 @@ type ArgsConfig struct {
 	Spec []SpecConfig `yaml:"spec"`
 }
 
+// KeysConfig locates the keys written by a command in its args, from first
+// to last every step args. Negative positions count from the end
+type KeysConfig struct {
+	First int `yaml:"first"`
+	Last  int `yaml:"last"`
+	Step  int `yaml:"step"`
+}
+
 type CmdConfig struct {
 	Name                     string     `yaml:"name"`
 	Timestamp                bool       `yaml:"timestamp"`
+	Propagate                bool       `yaml:"propagate"`
+	Keys                     KeysConfig `yaml:"keys"`
 	AutoGenerateScalerParser bool       `yaml:"autoGenerateScalerParser"`
 	Args                     ArgsConfig `yaml:"args"`
 }
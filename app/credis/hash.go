@@ -0,0 +1,242 @@
+package credis
+
+import (
+	"math"
+	"strconv"
+	"time"
+)
+
+type hashMap struct {
+	dict  map[string]string
+	index *scanIndex
+}
+
+func newHashMap() *hashMap {
+	return &hashMap{
+		dict:  make(map[string]string),
+		index: newScanIndex(),
+	}
+}
+
+// set reports whether field is new
+func (h *hashMap) set(field string, value string) bool {
+	_, exists := h.dict[field]
+	h.dict[field] = value
+	if !exists {
+		h.index.add(field)
+	}
+	return !exists
+}
+
+func (h *hashMap) delete(field string) bool {
+	if _, exists := h.dict[field]; !exists {
+		return false
+	}
+	delete(h.dict, field)
+	h.index.remove(field)
+	return true
+}
+
+type HashStore interface {
+	Set(key string, fields []KeyValue, nx bool) (int, error)
+	Get(key string, field string) (*string, error)
+	MGet(key string, fields []string) ([]*string, error)
+	Delete(key string, fields []string) (int, error)
+	GetAll(key string) ([]KeyValue, error)
+	IncrBy(key string, field string, delta int64) (int64, error)
+	IncrByFloat(key string, field string, delta float64) (string, error)
+	Len(key string) (int, error)
+	Scan(key string, cursor uint64, opts ScanOptions) (uint64, []string, error)
+}
+
+type hashStore struct {
+	ks *keyspace
+}
+
+func NewHashStore(ks *keyspace) HashStore {
+	return &hashStore{
+		ks: ks,
+	}
+}
+
+// lookup returns the hash stored at key, nil when key does not exist.
+// Caller must hold the lock
+func (s *hashStore) lookup(key string) (*hashMap, error) {
+	val, err := s.ks.lookupType(key, HASH_TYPE, time.Now())
+	if val == nil || err != nil {
+		return nil, err
+	}
+	return val.data.(*hashMap), nil
+}
+
+// lookupOrCreate is lookup creating an empty hash at key when it does not
+// exist. Caller must hold the write lock
+func (s *hashStore) lookupOrCreate(key string) (*hashMap, error) {
+	h, err := s.lookup(key)
+	if h == nil && err == nil {
+		h = newHashMap()
+		s.ks.set(key, HASH_TYPE, h, nil)
+	}
+	return h, err
+}
+
+// Set returns the number of fields added. With nx, fields that already
+// exist are left untouched
+func (s *hashStore) Set(key string, fields []KeyValue, nx bool) (int, error) {
+	s.ks.mu.Lock()
+	defer s.ks.mu.Unlock()
+	h, err := s.lookupOrCreate(key)
+	if err != nil {
+		return 0, err
+	}
+	added := 0
+	for _, kv := range fields {
+		if _, exists := h.dict[kv.Key]; exists && nx {
+			continue
+		}
+		if h.set(kv.Key, kv.Value) {
+			added++
+		}
+	}
+	return added, nil
+}
+
+func (s *hashStore) Get(key string, field string) (*string, error) {
+	s.ks.mu.RLock()
+	defer s.ks.mu.RUnlock()
+	h, err := s.lookup(key)
+	if h == nil || err != nil {
+		return nil, err
+	}
+	value, exists := h.dict[field]
+	if !exists {
+		return nil, nil
+	}
+	return &value, nil
+}
+
+func (s *hashStore) MGet(key string, fields []string) ([]*string, error) {
+	s.ks.mu.RLock()
+	defer s.ks.mu.RUnlock()
+	values := make([]*string, len(fields))
+	h, err := s.lookup(key)
+	if h == nil || err != nil {
+		return values, err
+	}
+	for i, field := range fields {
+		if value, exists := h.dict[field]; exists {
+			values[i] = &value
+		}
+	}
+	return values, nil
+}
+
+// Delete removes fields and the key along with its last field
+func (s *hashStore) Delete(key string, fields []string) (int, error) {
+	s.ks.mu.Lock()
+	defer s.ks.mu.Unlock()
+	h, err := s.lookup(key)
+	if h == nil || err != nil {
+		return 0, err
+	}
+	deleted := 0
+	for _, field := range fields {
+		if h.delete(field) {
+			deleted++
+		}
+	}
+	if len(h.dict) == 0 {
+		s.ks.remove(key)
+	}
+	return deleted, nil
+}
+
+func (s *hashStore) GetAll(key string) ([]KeyValue, error) {
+	s.ks.mu.RLock()
+	defer s.ks.mu.RUnlock()
+	fields := []KeyValue{}
+	h, err := s.lookup(key)
+	if h == nil || err != nil {
+		return fields, err
+	}
+	for field, value := range h.dict {
+		fields = append(fields, KeyValue{Key: field, Value: value})
+	}
+	return fields, nil
+}
+
+func (s *hashStore) IncrBy(key string, field string, delta int64) (int64, error) {
+	s.ks.mu.Lock()
+	defer s.ks.mu.Unlock()
+	h, err := s.lookupOrCreate(key)
+	if err != nil {
+		return 0, err
+	}
+	var current int64
+	if value, exists := h.dict[field]; exists {
+		var ok bool
+		if current, ok = parseInteger(value); !ok {
+			return 0, &ErrHashNotInteger{}
+		}
+	}
+	current, err = addInteger(current, delta)
+	if err != nil {
+		return 0, err
+	}
+	h.set(field, strconv.FormatInt(current, 10))
+	return current, nil
+}
+
+func (s *hashStore) IncrByFloat(key string, field string, delta float64) (string, error) {
+	s.ks.mu.Lock()
+	defer s.ks.mu.Unlock()
+	h, err := s.lookupOrCreate(key)
+	if err != nil {
+		return "", err
+	}
+	var current float64
+	if value, exists := h.dict[field]; exists {
+		var ok bool
+		if current, ok = parseFloat(value); !ok {
+			return "", &ErrHashNotFloat{}
+		}
+	}
+	current += delta
+	if math.IsNaN(current) || math.IsInf(current, 0) {
+		return "", &ErrNaNOrInfinity{}
+	}
+	updated := formatFloat(current)
+	h.set(field, updated)
+	return updated, nil
+}
+
+func (s *hashStore) Len(key string) (int, error) {
+	s.ks.mu.RLock()
+	defer s.ks.mu.RUnlock()
+	h, err := s.lookup(key)
+	if h == nil || err != nil {
+		return 0, err
+	}
+	return len(h.dict), nil
+}
+
+// Scan returns field, value pairs, only fields with opts.NoValues
+func (s *hashStore) Scan(key string, cursor uint64, opts ScanOptions) (uint64, []string, error) {
+	s.ks.mu.RLock()
+	defer s.ks.mu.RUnlock()
+	elems := []string{}
+	h, err := s.lookup(key)
+	if h == nil || err != nil {
+		return 0, elems, err
+	}
+	next := h.index.scan(cursor, opts.Count, func(field string) {
+		if !opts.matches(field) {
+			return
+		}
+		elems = append(elems, field)
+		if !opts.NoValues {
+			elems = append(elems, h.dict[field])
+		}
+	})
+	return next, elems, nil
+}
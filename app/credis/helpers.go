@@ -4,7 +4,9 @@ import (
 	"bytes"
 	"crypto/sha256"
 	"encoding/hex"
+	"math"
 	"math/rand"
+	"strconv"
 	"strings"
 )
 
@@ -43,3 +45,36 @@ func FlipCoin(bias float32) bool {
 	flipped := rand.Intn(101)
 	return flipped > int(lose)
 }
+
+// parseInteger only accepts the canonical form of an integer, e.g. not "+1"
+// or "01", same as redis
+func parseInteger(literal string) (int64, bool) {
+	num, err := strconv.ParseInt(literal, 10, 64)
+	if err != nil || strconv.FormatInt(num, 10) != literal {
+		return 0, false
+	}
+	return num, true
+}
+
+func addInteger(current int64, delta int64) (int64, error) {
+	if delta > 0 && current > math.MaxInt64-delta || delta < 0 && current < math.MinInt64-delta {
+		return 0, &ErrOverflow{}
+	}
+	return current + delta, nil
+}
+
+func parseFloat(literal string) (float64, bool) {
+	num, err := strconv.ParseFloat(literal, 64)
+	if err != nil || math.IsNaN(num) || strings.TrimSpace(literal) != literal {
+		return 0, false
+	}
+	return num, true
+}
+
+// formatFloat uses the plain notation without trailing zeros, like the long
+// double output of redis. Rounding to the 15 digits a float64 holds exactly
+// hides the error of the last bit, so 1.1 + 2.2 reads as 3.3
+func formatFloat(num float64) string {
+	rounded, _ := strconv.ParseFloat(strconv.FormatFloat(num, 'e', 14, 64), 64)
+	return strconv.FormatFloat(rounded, 'f', -1, 64)
+}
@@ -1,24 +1,14 @@
 package credis
 
 import (
+	"bytes"
 	"fmt"
+	"strings"
 	"sync"
-	"time"
 )
 
 const WORKERS_LIMIT = 6
 
-type WaitingArea struct {
-	queue map[string][]BLPOPHold
-	mu    sync.Mutex
-}
-
-var keyUpdatesChan = make(chan string, WORKERS_LIMIT)
-
-var waitingArea = WaitingArea{
-	queue: make(map[string][]BLPOPHold),
-}
-
 type Hub interface {
 	Shutdown()
 	Start(
@@ -29,6 +19,9 @@ type Hub interface {
 	RequestChannel() chan Request
 	Executor() Executor
 	Watcher() Watcher
+	ExecQueued(reqs []Request) []Response
+	ExecReplicated(req Request) Response
+	Expire(expire func() []string)
 }
 
 type hub struct {
@@ -37,6 +30,10 @@ type hub struct {
 	executor    Executor
 	replHandler Server
 	watcher     Watcher
+	// Held by commands that change data, so that they reach replicas in the
+	// order they were applied and the clients they unblock are served right
+	// after them
+	writeMu sync.Mutex
 }
 
 func NewHub() Hub {
@@ -49,68 +46,111 @@ func NewHub() Hub {
 
 func (h *hub) StartWorker() {
 	h.wg.Add(1)
-	send := h.watcher.Send()
-	go h.watcher.Start()
-	for {
-		select {
-		case req, ok := <-h.requestChan:
-			if !ok {
-				h.wg.Done()
-				break
-			}
-			res := h.executor.Exec(req)
-			spec := req.Specs()
-			if spec, ok := spec.(*BLPOPSpecs); ok && !spec.Concluded {
-				continue
-			}
-			req.Client().Receive() <- res
-			cmd := req.Specs().String()
-
-			// Propagate to replicas
-			args := req.Args()
-
-			switch cmd {
-			case SET:
-				spec := req.Specs().(*SETSpecs)
-				send <- spec.Key
-				h.replHandler.PropagateToReplicaGroup(cmd, args...)
-			case INCR:
-				spec := req.Specs().(*INCRSpecs)
-				send <- spec.Key
-				h.replHandler.PropagateToReplicaGroup(cmd, args...)
-			}
-
-			// TODO: Fix Replica Logic
-			// if h.executor.VerifiedReplica() && !h.replHandler.IsPartOfReplicaGroup(req.Id()) {
-			// 	h.replHandler.AddToReplicaGroup(req.Id(), req)
-			// }
-		case key := <-keyUpdatesChan:
-			// Key has been updated! check for blocked clients
-			waitingArea.mu.Lock()
-			for len(waitingArea.queue[key]) > 0 {
-				concluded, out := h.executor.processHold(&waitingArea.queue[key][0])
-				if !concluded {
-					break
-				}
-				if len(out) > 0 {
-					waitingArea.queue[key][0].req.Client().Receive() <- &response{
-						data: out,
-					}
-				}
-				if len(waitingArea.queue[key]) > 1 {
-					waitingArea.queue[key] = waitingArea.queue[key][1:]
-				} else {
-					delete(waitingArea.queue, key)
-				}
-				ls := h.executor.LStore()
-				if ls.Len(key) == 0 {
-					break
-				}
-			}
-			waitingArea.mu.Unlock()
-		default:
-			time.Sleep(10 * time.Millisecond)
+	defer h.wg.Done()
+	for req := range h.requestChan {
+		h.process(req)
+	}
+}
+
+// process executes req, replies to it and serves the clients it unblocked
+func (h *hub) process(req Request) {
+	cmd := req.Specs().String()
+	write := GetGenericSpec(cmd).Propagate
+	if write {
+		h.writeMu.Lock()
+		defer h.writeMu.Unlock()
+	}
+	res := h.executor.Exec(req)
+	if res == nil {
+		// Blocked, the reply comes once served or timed out
+		return
+	}
+	// Watchers learn about the write before the client gets its reply
+	h.propagate(req, res)
+	req.Client().Receive() <- res
+	if write {
+		// Only writes give data to keys
+		h.serveReady()
+	}
+
+	// TODO: Fix Replica Logic
+	// if h.executor.VerifiedReplica() && !h.replHandler.IsPartOfReplicaGroup(req.Id()) {
+	// 	h.replHandler.AddToReplicaGroup(req.Id(), req)
+	// }
+}
+
+// serveReady replies to the clients unblocked by the keys that got data and
+// propagates what they did. Caller must hold writeMu
+func (h *hub) serveReady() {
+	for _, c := range h.executor.serveReady() {
+		h.propagate(c.req, c.res)
+		c.req.Client().Receive() <- c.res
+	}
+}
+
+// ExecQueued executes the commands queued by MULTI one after the other and
+// propagates the writes among them, without writes of other clients in
+// between. The clients they unblocked are served once all are done
+func (h *hub) ExecQueued(reqs []Request) []Response {
+	h.writeMu.Lock()
+	defer h.writeMu.Unlock()
+	responses := make([]Response, 0, len(reqs))
+	for _, req := range reqs {
+		res := h.executor.Exec(req)
+		h.propagate(req, res)
+		responses = append(responses, res)
+	}
+	h.serveReady()
+	return responses
+}
+
+// ExecReplicated executes a write received from the master as clients'
+// writes are, so that the clients of the replica it unblocks are served
+// right after it and the transactions watching its keys fail
+func (h *hub) ExecReplicated(req Request) Response {
+	h.writeMu.Lock()
+	defer h.writeMu.Unlock()
+	res := h.executor.Exec(req)
+	h.propagate(req, res)
+	h.serveReady()
+	return res
+}
+
+// Expire runs expire, which removes keys without any client asking for it,
+// and propagates each removed key as a DEL in order with the other writes.
+// Replicas never expire keys on their own
+func (h *hub) Expire(expire func() []string) {
+	h.writeMu.Lock()
+	defer h.writeMu.Unlock()
+	for _, key := range expire() {
+		h.replicate(DEL, NewToken(BULK_STRING, key))
+	}
+}
+
+// propagate sends the request to replicas, or the commands it was rewritten
+// to. Writes that failed changed nothing and are not sent
+func (h *hub) propagate(req Request, res Response) {
+	cmd := req.Specs().String()
+	if !GetGenericSpec(cmd).Propagate || bytes.HasPrefix(res.Data(), []byte(SIMPLE_ERROR)) {
+		return
+	}
+	if commands := res.PropagateAll(); len(commands) > 0 {
+		for _, rewritten := range commands {
+			h.replicate(rewritten[0].Literal.(string), rewritten[1:]...)
 		}
+	} else if rewritten := res.Propagate(); len(rewritten) > 0 {
+		h.replicate(rewritten[0].Literal.(string), rewritten[1:]...)
+	} else if rewritten == nil {
+		h.replicate(cmd, req.Args()...)
+	}
+}
+
+// replicate sends a write to replicas and the keys it wrote to the watcher,
+// so that the transactions watching them fail
+func (h *hub) replicate(cmd string, args ...Token) {
+	h.replHandler.PropagateToReplicaGroup(cmd, args...)
+	for _, key := range GetGenericSpec(strings.ToLower(cmd)).Keys.Find(args) {
+		h.watcher.Notify(key)
 	}
 }
 
@@ -129,7 +169,6 @@ func (h *hub) Shutdown() {
 	close(h.requestChan)
 	fmt.Println("Waiting for unfinished jobs")
 	h.wg.Wait()
-	h.watcher.Stop()
 }
 
 func (h *hub) RequestChannel() chan Request {
@@ -0,0 +1,433 @@
+package credis
+
+import (
+	"encoding/binary"
+	"math"
+	"slices"
+	"time"
+)
+
+// HyperLogLogs are strings laid out as in redis: a header made of the "HYLL"
+// magic, the encoding, 3 unused bytes and the cached cardinality, followed by
+// the registers. Sparse ones run length encode the registers with the ZERO,
+// XZERO and VAL opcodes, dense ones pack them in 6 bits each. Being plain
+// strings they are read from and written to RDB files as is
+const (
+	HLL_P            = 14
+	HLL_Q            = 64 - HLL_P
+	HLL_REGISTERS    = 1 << HLL_P
+	HLL_P_MASK       = HLL_REGISTERS - 1
+	HLL_BITS         = 6
+	HLL_REGISTER_MAX = 1<<HLL_BITS - 1
+	HLL_HDR_SIZE     = 16
+	HLL_DENSE_SIZE   = HLL_HDR_SIZE + (HLL_REGISTERS*HLL_BITS+7)/8
+	HLL_DENSE        = 0
+	HLL_SPARSE       = 1
+	HLL_ALPHA_INF    = 0.721347520444481703680
+
+	HLL_SPARSE_VAL_MAX_VALUE = 32
+	HLL_SPARSE_VAL_MAX_LEN   = 4
+	HLL_SPARSE_ZERO_MAX_LEN  = 64
+	HLL_SPARSE_XZERO_MAX_LEN = 16384
+	// Sparse HyperLogLogs growing past this are converted to dense, same as
+	// redis hll-sparse-max-bytes
+	HLL_SPARSE_MAX_BYTES = 3000
+	// Seed of the hash of the elements, the one redis uses
+	HLL_HASH_SEED = 0xadc83b19
+)
+
+const HLL_MAGIC = "HYLL"
+
+// hllRegisters are the registers of a HyperLogLog, whatever its encoding
+type hllRegisters [HLL_REGISTERS]uint8
+
+// murmurHash64A is the 64 bits MurmurHash2 by Austin Appleby, reading the
+// input as little endian
+func murmurHash64A(data []byte, seed uint64) uint64 {
+	const m = 0xc6a4a7935bd1e995
+	const r = 47
+	h := seed ^ uint64(len(data))*m
+	for len(data) >= 8 {
+		k := binary.LittleEndian.Uint64(data)
+		k *= m
+		k ^= k >> r
+		k *= m
+		h ^= k
+		h *= m
+		data = data[8:]
+	}
+	if len(data) > 0 {
+		for i := len(data) - 1; i >= 0; i-- {
+			h ^= uint64(data[i]) << (8 * i)
+		}
+		h *= m
+	}
+	h ^= h >> r
+	h *= m
+	h ^= h >> r
+	return h
+}
+
+// hllPatLen returns the register of element and the length of the run of
+// zeros in its hash, plus one, that the register has to be at least
+func hllPatLen(element string) (index int, count uint8) {
+	hash := murmurHash64A([]byte(element), HLL_HASH_SEED)
+	index = int(hash & HLL_P_MASK)
+	// The run is at most HLL_Q long
+	hash = hash>>HLL_P | 1<<HLL_Q
+	count = 1
+	for bit := uint64(1); hash&bit == 0; bit <<= 1 {
+		count++
+	}
+	return index, count
+}
+
+// newHLL returns an empty sparse HyperLogLog, its cached cardinality being 0
+func newHLL() []byte {
+	data := make([]byte, HLL_HDR_SIZE, HLL_HDR_SIZE+2)
+	copy(data, HLL_MAGIC)
+	data[4] = HLL_SPARSE
+	return append(data, sparseXZero(HLL_REGISTERS)...)
+}
+
+// isHLL checks the header of data, and the size of dense ones
+func isHLL(data []byte) bool {
+	if len(data) < HLL_HDR_SIZE || string(data[:4]) != HLL_MAGIC || data[4] > HLL_SPARSE {
+		return false
+	}
+	return data[4] == HLL_SPARSE || len(data) == HLL_DENSE_SIZE
+}
+
+// cachedCount returns the cardinality cached in the header, ok is false once
+// the registers changed since it was computed
+func cachedCount(data []byte) (count uint64, ok bool) {
+	if data[HLL_HDR_SIZE-1]&0x80 != 0 {
+		return 0, false
+	}
+	return binary.LittleEndian.Uint64(data[8:HLL_HDR_SIZE]), true
+}
+
+func cacheCount(data []byte, count uint64) {
+	binary.LittleEndian.PutUint64(data[8:HLL_HDR_SIZE], count)
+}
+
+func invalidateCount(data []byte) {
+	data[HLL_HDR_SIZE-1] |= 0x80
+}
+
+func denseRegister(registers []byte, index int) uint8 {
+	i, fb := index*HLL_BITS/8, uint(index*HLL_BITS&7)
+	b0, b1 := uint(registers[i]), uint(0)
+	if i+1 < len(registers) {
+		b1 = uint(registers[i+1])
+	}
+	return uint8((b0>>fb | b1<<(8-fb)) & HLL_REGISTER_MAX)
+}
+
+func setDenseRegister(registers []byte, index int, value uint8) {
+	i, fb := index*HLL_BITS/8, uint(index*HLL_BITS&7)
+	registers[i] &^= byte(HLL_REGISTER_MAX << fb)
+	registers[i] |= value << fb
+	// The last register never spans two bytes
+	if i+1 < len(registers) {
+		registers[i+1] &^= byte(HLL_REGISTER_MAX >> (8 - fb))
+		registers[i+1] |= value >> (8 - fb)
+	}
+}
+
+func sparseXZero(length int) []byte {
+	return []byte{0x40 | byte((length-1)>>8), byte(length - 1)}
+}
+
+// registersOf decodes the registers of a HyperLogLog checked with isHLL.
+// Sparse ones not covering exactly every register are corrupted
+func registersOf(data []byte) (*hllRegisters, error) {
+	registers := &hllRegisters{}
+	if data[4] == HLL_DENSE {
+		for i := range registers {
+			registers[i] = denseRegister(data[HLL_HDR_SIZE:], i)
+		}
+		return registers, nil
+	}
+	index := 0
+	opcodes := data[HLL_HDR_SIZE:]
+	for i := 0; i < len(opcodes) && index <= HLL_REGISTERS; i++ {
+		switch op := opcodes[i]; {
+		case op&0xc0 == 0x00:
+			// ZERO: 00xxxxxx, a run of up to 64 zeros
+			index += int(op&0x3f) + 1
+		case op&0xc0 == 0x40:
+			// XZERO: 01xxxxxx yyyyyyyy, a run of up to 16384 zeros
+			if i+1 == len(opcodes) {
+				return nil, &ErrCorruptedHLL{}
+			}
+			index += (int(op&0x3f)<<8 | int(opcodes[i+1])) + 1
+			i++
+		default:
+			// VAL: 1vvvvvxx, a run of up to 4 registers set to up to 32
+			value, run := (op>>2)&0x1f+1, int(op&0x3)+1
+			if index+run > HLL_REGISTERS {
+				return nil, &ErrCorruptedHLL{}
+			}
+			for j := range run {
+				registers[index+j] = value
+			}
+			index += run
+		}
+	}
+	if index != HLL_REGISTERS {
+		return nil, &ErrCorruptedHLL{}
+	}
+	return registers, nil
+}
+
+// sparseOpcodes encodes registers with the sparse opcodes, ok is false when
+// a register is too large for VAL
+func sparseOpcodes(registers *hllRegisters) (opcodes []byte, ok bool) {
+	for i := 0; i < HLL_REGISTERS; {
+		value, run := registers[i], 1
+		for i+run < HLL_REGISTERS && registers[i+run] == value {
+			run++
+		}
+		i += run
+		for run > 0 {
+			var n int
+			switch {
+			case value > HLL_SPARSE_VAL_MAX_VALUE:
+				return nil, false
+			case value > 0:
+				n = min(run, HLL_SPARSE_VAL_MAX_LEN)
+				opcodes = append(opcodes, 0x80|(value-1)<<2|byte(n-1))
+			case run > HLL_SPARSE_ZERO_MAX_LEN:
+				n = min(run, HLL_SPARSE_XZERO_MAX_LEN)
+				opcodes = append(opcodes, sparseXZero(n)...)
+			default:
+				n = run
+				opcodes = append(opcodes, byte(n-1))
+			}
+			run -= n
+		}
+	}
+	return opcodes, true
+}
+
+// encodeHLL lays registers out after header, sparse unless dense is set,
+// a register does not fit VAL or the result would grow past
+// HLL_SPARSE_MAX_BYTES. The cached cardinality is invalidated
+func encodeHLL(header []byte, registers *hllRegisters, dense bool) []byte {
+	var data []byte
+	if opcodes, ok := sparseOpcodes(registers); ok && !dense && HLL_HDR_SIZE+len(opcodes) <= HLL_SPARSE_MAX_BYTES {
+		data = append(slices.Clone(header[:HLL_HDR_SIZE]), opcodes...)
+		data[4] = HLL_SPARSE
+	} else {
+		data = make([]byte, HLL_DENSE_SIZE)
+		copy(data, header[:HLL_HDR_SIZE])
+		data[4] = HLL_DENSE
+		for i, value := range registers {
+			setDenseRegister(data[HLL_HDR_SIZE:], i, value)
+		}
+	}
+	invalidateCount(data)
+	return data
+}
+
+func hllSigma(x float64) float64 {
+	if x == 1 {
+		return math.Inf(1)
+	}
+	y, z := 1.0, x
+	for {
+		x *= x
+		prev := z
+		z += x * y
+		y += y
+		if prev == z {
+			return z
+		}
+	}
+}
+
+func hllTau(x float64) float64 {
+	if x == 0 || x == 1 {
+		return 0
+	}
+	y, z := 1.0, 1-x
+	for {
+		x = math.Sqrt(x)
+		prev := z
+		y *= 0.5
+		z -= math.Pow(1-x, 2) * y
+		if prev == z {
+			return z / 3
+		}
+	}
+}
+
+// hllCount estimates the cardinality from the histogram of the registers,
+// as in "New cardinality estimation algorithms for HyperLogLog sketches" by
+// Otmar Ertl, which redis uses too
+func hllCount(registers *hllRegisters) uint64 {
+	m := float64(HLL_REGISTERS)
+	// Registers of corrupted dense HyperLogLogs can go up to 63
+	histogram := [HLL_REGISTER_MAX + 1]int{}
+	for _, value := range registers {
+		histogram[value]++
+	}
+	z := m * hllTau((m-float64(histogram[HLL_Q+1]))/m)
+	for j := HLL_Q; j >= 1; j-- {
+		z += float64(histogram[j])
+		z *= 0.5
+	}
+	z += m * hllSigma(float64(histogram[0])/m)
+	return uint64(math.Round(HLL_ALPHA_INF * m * m / z))
+}
+
+type HyperLogLog interface {
+	Add(key string, elements []string) (bool, error)
+	Count(keys []string) (uint64, bool, error)
+	Merge(dest string, keys []string) error
+}
+
+type hllStore struct {
+	ks *keyspace
+}
+
+func NewHyperLogLog(ks *keyspace) HyperLogLog {
+	return &hllStore{
+		ks: ks,
+	}
+}
+
+// lookup returns the HyperLogLog at key, nil when key does not exist.
+// Caller must hold the lock
+func (s *hllStore) lookup(key string) (*Value, error) {
+	val, err := s.ks.lookupType(key, STRING_TYPE, time.Now())
+	if val == nil || err != nil {
+		return nil, err
+	}
+	if !isHLL(val.data.([]byte)) {
+		return nil, &ErrNotHLL{}
+	}
+	return val, nil
+}
+
+// Add reports whether a register changed or key got created. Dense ones
+// are updated in place
+func (s *hllStore) Add(key string, elements []string) (bool, error) {
+	s.ks.mu.Lock()
+	defer s.ks.mu.Unlock()
+	val, err := s.lookup(key)
+	if err != nil {
+		return false, err
+	}
+	updated := false
+	if val == nil {
+		val = s.ks.set(key, STRING_TYPE, newHLL(), nil)
+		updated = true
+	}
+	data := val.data.([]byte)
+	if data[4] == HLL_DENSE {
+		for _, element := range elements {
+			index, count := hllPatLen(element)
+			if denseRegister(data[HLL_HDR_SIZE:], index) < count {
+				setDenseRegister(data[HLL_HDR_SIZE:], index, count)
+				updated = true
+			}
+		}
+	} else {
+		registers, err := registersOf(data)
+		if err != nil {
+			return false, err
+		}
+		changed := false
+		for _, element := range elements {
+			index, count := hllPatLen(element)
+			if registers[index] < count {
+				registers[index] = count
+				changed = true
+			}
+		}
+		if changed {
+			data = encodeHLL(data, registers, false)
+			val.data = data
+			updated = true
+		}
+	}
+	if updated {
+		invalidateCount(data)
+	}
+	return updated, nil
+}
+
+// Count estimates the number of distinct elements added to any of keys. The
+// estimate of a single key is cached in its header, stored reports whether
+// it was updated
+func (s *hllStore) Count(keys []string) (uint64, bool, error) {
+	s.ks.mu.Lock()
+	defer s.ks.mu.Unlock()
+	if len(keys) == 1 {
+		val, err := s.lookup(keys[0])
+		if val == nil || err != nil {
+			return 0, false, err
+		}
+		data := val.data.([]byte)
+		if count, ok := cachedCount(data); ok {
+			return count, false, nil
+		}
+		registers, err := registersOf(data)
+		if err != nil {
+			return 0, false, err
+		}
+		count := hllCount(registers)
+		cacheCount(data, count)
+		return count, true, nil
+	}
+	merged, _, err := s.merge(keys)
+	if err != nil {
+		return 0, false, err
+	}
+	return hllCount(merged), false, nil
+}
+
+// merge returns the largest value of each register across keys, dense is
+// set when one of them is dense. Caller must hold the lock
+func (s *hllStore) merge(keys []string) (merged *hllRegisters, dense bool, err error) {
+	merged = &hllRegisters{}
+	for _, key := range keys {
+		val, err := s.lookup(key)
+		if err != nil {
+			return nil, false, err
+		}
+		if val == nil {
+			continue
+		}
+		data := val.data.([]byte)
+		registers, err := registersOf(data)
+		if err != nil {
+			return nil, false, err
+		}
+		for i, value := range registers {
+			merged[i] = max(merged[i], value)
+		}
+		dense = dense || data[4] == HLL_DENSE
+	}
+	return merged, dense, nil
+}
+
+// Merge stores at dest the union of dest and keys, dense when one of them
+// is
+func (s *hllStore) Merge(dest string, keys []string) error {
+	s.ks.mu.Lock()
+	defer s.ks.mu.Unlock()
+	merged, dense, err := s.merge(append([]string{dest}, keys...))
+	if err != nil {
+		return err
+	}
+	val, _ := s.lookup(dest)
+	if val == nil {
+		s.ks.set(dest, STRING_TYPE, encodeHLL(newHLL(), merged, dense), nil)
+	} else {
+		val.data = encodeHLL(val.data.([]byte), merged, dense)
+	}
+	return nil
+}
@@ -0,0 +1,225 @@
+package credis
+
+import (
+	"strconv"
+	"testing"
+)
+
+func newTestHLL() HyperLogLog {
+	return NewHyperLogLog(NewKeyspace())
+}
+
+// elements returns n distinct elements starting with prefix
+func elements(prefix string, n int) []string {
+	elements := make([]string, n)
+	for i := range elements {
+		elements[i] = prefix + strconv.Itoa(i)
+	}
+	return elements
+}
+
+// Counts of the examples of the redis documentation
+func TestHLLCountKnown(t *testing.T) {
+	tests := []struct {
+		name  string
+		adds  map[string][][]string
+		count []string
+		want  uint64
+	}{
+		{"letters", map[string][][]string{"hll": {{"a", "b", "c", "d", "e", "f", "g"}}}, []string{"hll"}, 7},
+		{"duplicates", map[string][][]string{"hll": {{"foo", "bar", "zap"}, {"zap", "zap", "zap"}, {"foo", "bar"}}}, []string{"hll"}, 3},
+		{"union", map[string][][]string{
+			"hll":   {{"foo", "bar", "zap"}},
+			"other": {{"1", "2", "3"}},
+		}, []string{"hll", "other"}, 6},
+		{"overlap", map[string][][]string{
+			"hll1": {{"foo", "bar", "zap", "a"}},
+			"hll2": {{"a", "b", "c", "foo"}},
+		}, []string{"hll1", "hll2"}, 6},
+		{"missing", nil, []string{"missing"}, 0},
+	}
+	for _, tt := range tests {
+		hll := newTestHLL()
+		for key, adds := range tt.adds {
+			for _, add := range adds {
+				hll.Add(key, add)
+			}
+		}
+		if got, _, err := hll.Count(tt.count); err != nil || got != tt.want {
+			t.Errorf("%s: Count = %d, %v, want %d", tt.name, got, err, tt.want)
+		}
+	}
+}
+
+// Estimates of larger sets stay within about 3 standard errors, which is
+// 0.81% for 16384 registers
+func TestHLLCountError(t *testing.T) {
+	for _, n := range []int{100, 1000, 10_000, 100_000, 1_000_000} {
+		hll := newTestHLL()
+		hll.Add("hll", elements("e", n))
+		got, _, _ := hll.Count([]string{"hll"})
+		if diff := float64(got)/float64(n) - 1; diff > 0.025 || diff < -0.025 {
+			t.Errorf("Count of %d elements = %d", n, got)
+		}
+	}
+}
+
+// Merged keys count as the union of their elements, dense when one is
+func TestHLLMerge(t *testing.T) {
+	tests := []struct {
+		name      string
+		sizes     []int
+		wantDense bool
+	}{
+		{"sparse", []int{100, 200}, false},
+		{"dense", []int{100, 20_000}, true},
+	}
+	for _, tt := range tests {
+		hll := newTestHLL()
+		single := newTestHLL()
+		keys := []string{}
+		for i, n := range tt.sizes {
+			key := "hll" + strconv.Itoa(i)
+			keys = append(keys, key)
+			// Half of the elements are shared by all keys
+			hll.Add(key, elements("shared", n/2))
+			hll.Add(key, elements(key, n-n/2))
+			single.Add("all", elements("shared", n/2))
+			single.Add("all", elements(key, n-n/2))
+		}
+		if err := hll.Merge("dest", keys); err != nil {
+			t.Fatalf("%s: Merge: %v", tt.name, err)
+		}
+		merged, _, _ := hll.Count([]string{"dest"})
+		union, _, _ := hll.Count(keys)
+		want, _, _ := single.Count([]string{"all"})
+		if merged != want || union != want {
+			t.Errorf("%s: merged %d, union %d, want %d", tt.name, merged, union, want)
+		}
+		data := hll.(*hllStore).ks.keys["dest"].data.([]byte)
+		if dense := data[4] == HLL_DENSE; dense != tt.wantDense {
+			t.Errorf("%s: dense = %v, want %v", tt.name, dense, tt.wantDense)
+		}
+	}
+}
+
+// Sparse HyperLogLogs hold the registers of their elements and turn dense
+// once the opcodes grow past HLL_SPARSE_MAX_BYTES
+func TestHLLPromotion(t *testing.T) {
+	hll := newTestHLL()
+	want := &hllRegisters{}
+	for i := range 20_000 {
+		element := "e" + strconv.Itoa(i)
+		index, count := hllPatLen(element)
+		want[index] = max(want[index], count)
+		hll.Add("hll", []string{element})
+		data := hll.(*hllStore).ks.keys["hll"].data.([]byte)
+		if !isHLL(data) {
+			t.Fatalf("not a HyperLogLog after %d elements", i+1)
+		}
+		if data[4] == HLL_DENSE {
+			if len(data) != HLL_DENSE_SIZE {
+				t.Fatalf("dense size %d, want %d", len(data), HLL_DENSE_SIZE)
+			}
+			opcodes, _ := sparseOpcodes(want)
+			if HLL_HDR_SIZE+len(opcodes) <= HLL_SPARSE_MAX_BYTES {
+				t.Fatalf("promoted after %d elements with %d bytes of opcodes", i+1, len(opcodes))
+			}
+		} else if len(data) > HLL_SPARSE_MAX_BYTES {
+			t.Fatalf("sparse size %d past %d", len(data), HLL_SPARSE_MAX_BYTES)
+		}
+		if i%1000 == 0 || i == 19_999 {
+			got, err := registersOf(data)
+			if err != nil || *got != *want {
+				t.Fatalf("registers differ after %d elements: %v", i+1, err)
+			}
+		}
+	}
+	if data := hll.(*hllStore).ks.keys["hll"].data.([]byte); data[4] != HLL_DENSE {
+		t.Fatalf("still sparse after 20000 elements, %d bytes", len(data))
+	}
+}
+
+func TestHLLLayout(t *testing.T) {
+	empty := newHLL()
+	want := []byte("HYLL\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x7f\xff")
+	if string(empty) != string(want) {
+		t.Fatalf("newHLL() = %q, want %q", empty, want)
+	}
+	tests := []struct {
+		name      string
+		registers map[int]uint8
+		dense     bool
+		// Expected opcodes of sparse ones
+		opcodes []byte
+	}{
+		{"empty", nil, false, []byte{0x7f, 0xff}},
+		{"first", map[int]uint8{0: 3}, false, []byte{0x88, 0x7f, 0xfe}},
+		{"run", map[int]uint8{1: 2, 2: 2, 3: 2, 4: 2, 5: 2}, false, []byte{0x00, 0x87, 0x84, 0x7f, 0xf9}},
+		{"zeros", map[int]uint8{64: 1, HLL_REGISTERS - 1: 32}, false, []byte{0x3f, 0x80, 0x7f, 0xbd, 0xfc}},
+		{"large", map[int]uint8{7: HLL_SPARSE_VAL_MAX_VALUE + 1}, true, nil},
+		{"dense", map[int]uint8{0: 1, 1: HLL_REGISTER_MAX, HLL_REGISTERS - 1: 50}, true, nil},
+	}
+	for _, tt := range tests {
+		registers := &hllRegisters{}
+		for i, v := range tt.registers {
+			registers[i] = v
+		}
+		data := encodeHLL(newHLL(), registers, tt.dense)
+		if string(data[:4]) != HLL_MAGIC {
+			t.Errorf("%s: magic %q", tt.name, data[:4])
+		}
+		if _, ok := cachedCount(data); ok {
+			t.Errorf("%s: cached count still valid", tt.name)
+		}
+		if tt.opcodes != nil {
+			if data[4] != HLL_SPARSE || string(data[HLL_HDR_SIZE:]) != string(tt.opcodes) {
+				t.Errorf("%s: opcodes %x, want %x", tt.name, data[HLL_HDR_SIZE:], tt.opcodes)
+			}
+		} else {
+			if data[4] != HLL_DENSE || len(data) != HLL_DENSE_SIZE {
+				t.Errorf("%s: encoding %d, %d bytes", tt.name, data[4], len(data))
+			}
+			// Registers are packed 6 bits each from the least significant
+			for i, v := range tt.registers {
+				bit := i * HLL_BITS
+				got := uint16(data[HLL_HDR_SIZE+bit/8]) >> (bit % 8)
+				if bit/8+1 < HLL_DENSE_SIZE-HLL_HDR_SIZE {
+					got |= uint16(data[HLL_HDR_SIZE+bit/8+1]) << (8 - bit%8)
+				}
+				if uint8(got&HLL_REGISTER_MAX) != v {
+					t.Errorf("%s: register %d packed as %d, want %d", tt.name, i, got&HLL_REGISTER_MAX, v)
+				}
+			}
+		}
+		got, err := registersOf(data)
+		if err != nil || *got != *registers {
+			t.Errorf("%s: registers differ once decoded: %v", tt.name, err)
+		}
+	}
+}
+
+// The cardinality cached in the header is only invalidated by changes
+func TestHLLCountCache(t *testing.T) {
+	hll := newTestHLL()
+	hll.Add("hll", []string{"a", "b"})
+	if _, stored, _ := hll.Count([]string{"hll"}); !stored {
+		t.Fatal("first count was not cached")
+	}
+	data := hll.(*hllStore).ks.keys["hll"].data.([]byte)
+	if count, ok := cachedCount(data); !ok || count != 2 {
+		t.Fatalf("cached count = %d, %v, want 2", count, ok)
+	}
+	if updated, _ := hll.Add("hll", []string{"a"}); updated {
+		t.Fatal("adding a known element updated the registers")
+	}
+	if _, stored, _ := hll.Count([]string{"hll"}); stored {
+		t.Fatal("unchanged count was cached again")
+	}
+	if updated, _ := hll.Add("hll", []string{"c"}); !updated {
+		t.Fatal("adding a new element did not update the registers")
+	}
+	if count, stored, _ := hll.Count([]string{"hll"}); !stored || count != 3 {
+		t.Fatalf("Count = %d, cached %v, want 3 cached", count, stored)
+	}
+}
@@ -0,0 +1,269 @@
+package credis
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	NONE_TYPE   = "none"
+	STRING_TYPE = "string"
+	LIST_TYPE   = "list"
+	SET_TYPE    = "set"
+	ZSET_TYPE   = "zset"
+	HASH_TYPE   = "hash"
+	STREAM_TYPE = "stream"
+)
+
+// Value is a single entry of the keyspace. data holds the type specific
+// representation, e.g. []byte for strings and *sortedSet for zsets
+type Value struct {
+	typ       string
+	data      any
+	createdAt time.Time
+	exp       *time.Time
+}
+
+func (v *Value) isExpired(now time.Time) bool {
+	return v.exp != nil && now.After(*v.exp)
+}
+
+// ExpireCondition holds the NX, XX, GT and LT options of the EXPIRE family
+type ExpireCondition struct {
+	NX bool
+	XX bool
+	GT bool
+	LT bool
+}
+
+type Keyspace interface {
+	Type(key string, currentTime time.Time) string
+	Keys(pattern string, currentTime time.Time) []string
+	Scan(cursor uint64, opts ScanOptions, currentTime time.Time) (uint64, []string)
+	Delete(keys ...string) int
+	Exists(keys ...string) int
+	Expire(key string, at time.Time, cond ExpireCondition) int
+	Expiry(key string, currentTime time.Time) (exp *time.Time, exists bool)
+	Persist(key string) int
+	ActiveExpire(now time.Time) []string
+	Stats() KeyspaceStats
+}
+
+// keyspace records every key of the server along with the type it holds.
+// Typed stores are views over it and share its lock, so a key can only ever
+// hold a single type at a time
+type keyspace struct {
+	mu   sync.RWMutex
+	keys map[string]*Value
+	// Keys having an expiry, sampled by the active expire cycle
+	volatile map[string]struct{}
+	index    *scanIndex
+	stats    KeyspaceStats
+	// Keys that got data since blocked clients were last served, in the
+	// order they were signaled
+	ready    []string
+	readySet map[string]struct{}
+}
+
+func NewKeyspace() *keyspace {
+	return &keyspace{
+		keys:     make(map[string]*Value),
+		volatile: make(map[string]struct{}),
+		index:    newScanIndex(),
+		readySet: make(map[string]struct{}),
+	}
+}
+
+func (ks *keyspace) Stats() KeyspaceStats {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	return ks.stats
+}
+
+func (ks *keyspace) Type(key string, currentTime time.Time) string {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	val := ks.lookup(key, currentTime)
+	if val == nil {
+		return NONE_TYPE
+	}
+	return val.typ
+}
+
+func (ks *keyspace) Keys(pattern string, currentTime time.Time) []string {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	keys := make([]string, 0)
+	for key, val := range ks.keys {
+		if !val.isExpired(currentTime) && globMatch(pattern, key, false) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// Scan walks the keyspace COUNT slots at a time, the lock is only held for
+// a single call
+func (ks *keyspace) Scan(cursor uint64, opts ScanOptions, currentTime time.Time) (uint64, []string) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	keys := make([]string, 0)
+	next := ks.index.scan(cursor, opts.Count, func(key string) {
+		val := ks.lookup(key, currentTime)
+		if val == nil || opts.Type != "" && !strings.EqualFold(opts.Type, val.typ) {
+			return
+		}
+		if opts.matches(key) {
+			keys = append(keys, key)
+		}
+	})
+	return next, keys
+}
+
+func (ks *keyspace) Delete(keys ...string) int {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	now := time.Now()
+	deleted := 0
+	for _, key := range keys {
+		if ks.lookup(key, now) != nil {
+			deleted++
+		}
+		ks.remove(key)
+	}
+	return deleted
+}
+
+// Exists counts a key as many times as it is mentioned
+func (ks *keyspace) Exists(keys ...string) int {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	now := time.Now()
+	count := 0
+	for _, key := range keys {
+		if ks.lookup(key, now) != nil {
+			count++
+		}
+	}
+	return count
+}
+
+// Expire sets the expiry of key to at if cond allows it. A time in the past
+// deletes the key right away
+func (ks *keyspace) Expire(key string, at time.Time, cond ExpireCondition) int {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	now := time.Now()
+	val := ks.lookup(key, now)
+	if val == nil {
+		return 0
+	}
+	// Keys without expiry are treated as having an infinite TTL
+	if cond.NX && val.exp != nil ||
+		cond.XX && val.exp == nil ||
+		cond.GT && (val.exp == nil || !at.After(*val.exp)) ||
+		cond.LT && val.exp != nil && !at.Before(*val.exp) {
+		return 0
+	}
+	if !at.After(now) {
+		ks.remove(key)
+		return 1
+	}
+	ks.setExpiry(key, val, &at)
+	return 1
+}
+
+func (ks *keyspace) Expiry(key string, currentTime time.Time) (*time.Time, bool) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	val := ks.lookup(key, currentTime)
+	if val == nil {
+		return nil, false
+	}
+	return val.exp, true
+}
+
+func (ks *keyspace) Persist(key string) int {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	val := ks.lookup(key, time.Now())
+	if val == nil || val.exp == nil {
+		return 0
+	}
+	ks.setExpiry(key, val, nil)
+	return 1
+}
+
+// lookup returns the live value at key or nil. Caller must hold the lock
+func (ks *keyspace) lookup(key string, now time.Time) *Value {
+	val := ks.keys[key]
+	if val == nil || val.isExpired(now) {
+		return nil
+	}
+	return val
+}
+
+// lookupType is lookup that fails with ErrWrongType when key holds another
+// type than typ. Caller must hold the lock
+func (ks *keyspace) lookupType(key string, typ string, now time.Time) (*Value, error) {
+	val := ks.lookup(key, now)
+	if val == nil {
+		return nil, nil
+	}
+	if val.typ != typ {
+		return nil, &ErrWrongType{}
+	}
+	return val, nil
+}
+
+// set replaces whatever key holds. Caller must hold the write lock
+func (ks *keyspace) set(key string, typ string, data any, exp *time.Time) *Value {
+	val := &Value{
+		typ:       typ,
+		data:      data,
+		createdAt: time.Now(),
+	}
+	ks.keys[key] = val
+	ks.index.add(key)
+	ks.setExpiry(key, val, exp)
+	return val
+}
+
+// setExpiry changes the expiry of val stored at key. Caller must hold the
+// write lock
+func (ks *keyspace) setExpiry(key string, val *Value, exp *time.Time) {
+	val.exp = exp
+	if exp != nil {
+		ks.volatile[key] = struct{}{}
+	} else {
+		delete(ks.volatile, key)
+	}
+}
+
+// remove deletes key. Caller must hold the write lock
+func (ks *keyspace) remove(key string) {
+	delete(ks.keys, key)
+	delete(ks.volatile, key)
+	ks.index.remove(key)
+}
+
+// signalReady marks key as having got data that clients blocked on it may
+// be waiting for. Caller must hold the write lock
+func (ks *keyspace) signalReady(key string) {
+	if _, marked := ks.readySet[key]; marked {
+		return
+	}
+	ks.readySet[key] = struct{}{}
+	ks.ready = append(ks.ready, key)
+}
+
+// takeReady returns the keys signaled so far and clears them
+func (ks *keyspace) takeReady() []string {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	ready := ks.ready
+	ks.ready = nil
+	clear(ks.readySet)
+	return ready
+}
@@ -1,40 +1,75 @@
 package credis
 
 import (
-	"fmt"
-	"iter"
-	"maps"
-	"sync"
+	"math"
+	"strconv"
 	"time"
 )
 
-type Value struct {
-	exists    bool
-	data      Token
-	createdAt time.Time
-	exp       *time.Time
-}
-
 type KVStore interface {
 	ID() string
 	Error() error
-	Get(key string, currentTime time.Time) Token
+	Get(key string, currentTime time.Time) (*Token, error)
 	Set(key string, data Token, exp *time.Time)
-	Keys() iter.Seq[string]
-	Update(key string, data Token)
+	IncrBy(key string, delta int64) (int64, error)
+	IncrByFloat(key string, delta float64) (string, error)
+	SetIf(key string, data Token, exp *time.Time, opts SetOptions) (old *Token, written bool, err error)
+	MGet(keys []string, currentTime time.Time) []*Token
+	MSet(kvs []KeyValue, nx bool) bool
+	Append(key string, data string) (int, error)
+	SetRange(key string, offset int64, data string) (int, error)
+	GetDel(key string) (*Token, error)
+	GetEx(key string, exp *time.Time, persist bool) (*Token, error)
+	SetBit(key string, offset int64, bit int) (int, error)
+	GetBit(key string, offset int64) (int, error)
+	BitCount(key string, r BitRange) (int, error)
+	BitPos(key string, bit int, r BitRange) (int64, error)
+	BitOp(op string, dest string, keys []string) (int, error)
+	BitField(key string, ops []BitFieldOp) ([]*int64, error)
+}
+
+// Max length of a string value, same as redis proto-max-bulk-len
+const MAX_STRING_LENGTH = 512 * 1024 * 1024
+
+// Strings are kept as byte slices in the keyspace so that range and bit
+// writes happen in place. Tokens are only built for values leaving the store
+func stringToken(data []byte) *Token {
+	tkn := NewToken(BULK_STRING, string(data))
+	return &tkn
+}
+
+func tokenBytes(data Token) []byte {
+	literal, _ := data.Literal.(string)
+	return []byte(literal)
+}
+
+// grow pads data with zero bytes up to size
+func grow(data []byte, size int) []byte {
+	if size <= len(data) {
+		return data
+	}
+	return append(data, make([]byte, size-len(data))...)
+}
+
+// SetOptions are the conditions of SET. KeepTTL keeps the expiry of the
+// current value and Get asks for the value being replaced
+type SetOptions struct {
+	NX      bool
+	XX      bool
+	KeepTTL bool
+	Get     bool
 }
 
 type store struct {
-	id    string
-	mu    sync.RWMutex
-	err   error
-	store map[string]Value
+	id  string
+	err error
+	ks  *keyspace
 }
 
-func NewStore() KVStore {
+func NewStore(ks *keyspace) KVStore {
 	return &store{
-		id:    GenerateString(6),
-		store: make(map[string]Value),
+		id: GenerateString(6),
+		ks: ks,
 	}
 }
 
@@ -46,53 +81,217 @@ func (s *store) Error() error {
 	return s.err
 }
 
-func (s *store) Get(key string, currentTime time.Time) Token {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-	val := s.store[key]
-	if !val.exists {
-		return NewToken(BULK_STRING, "")
+// Get returns nil when key does not exist
+func (s *store) Get(key string, currentTime time.Time) (*Token, error) {
+	s.ks.mu.RLock()
+	defer s.ks.mu.RUnlock()
+	val, err := s.ks.lookupType(key, STRING_TYPE, currentTime)
+	if val == nil || err != nil {
+		return nil, err
+	}
+	return stringToken(val.data.([]byte)), nil
+}
+
+// MGet returns nil for keys that do not exist or do not hold a string
+func (s *store) MGet(keys []string, currentTime time.Time) []*Token {
+	s.ks.mu.RLock()
+	defer s.ks.mu.RUnlock()
+	values := make([]*Token, len(keys))
+	for i, key := range keys {
+		val := s.ks.lookup(key, currentTime)
+		if val == nil || val.typ != STRING_TYPE {
+			continue
+		}
+		values[i] = stringToken(val.data.([]byte))
 	}
-	if val.exp != nil {
-		// Value with expiry
-		if currentTime.After(*val.exp) {
-			// value is expired
-			delete(s.store, key)
-			return NewToken(BULK_STRING, "")
+	return values
+}
+
+// MSet writes every pair or, with nx, none of them if any key exists
+func (s *store) MSet(kvs []KeyValue, nx bool) bool {
+	s.ks.mu.Lock()
+	defer s.ks.mu.Unlock()
+	now := time.Now()
+	if nx {
+		for _, kv := range kvs {
+			if s.ks.lookup(kv.Key, now) != nil {
+				return false
+			}
 		}
 	}
-	return s.store[key].data
+	for _, kv := range kvs {
+		s.ks.set(kv.Key, STRING_TYPE, []byte(kv.Value), nil)
+	}
+	return true
 }
 
-func (s *store) Set(key string, data Token, exp *time.Time) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	val := Value{
-		data:      data,
-		createdAt: time.Now(),
-		exists:    true,
+func (s *store) Append(key string, data string) (int, error) {
+	s.ks.mu.Lock()
+	defer s.ks.mu.Unlock()
+	val, err := s.ks.lookupType(key, STRING_TYPE, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	if val == nil {
+		s.ks.set(key, STRING_TYPE, []byte(data), nil)
+		return len(data), nil
+	}
+	current := val.data.([]byte)
+	if len(current)+len(data) > MAX_STRING_LENGTH {
+		return 0, &ErrStringTooLong{}
+	}
+	val.data = append(current, data...)
+	return len(current) + len(data), nil
+}
+
+// SetRange overwrites the value at offset, padding it with zero bytes when
+// it is shorter than offset
+func (s *store) SetRange(key string, offset int64, data string) (int, error) {
+	s.ks.mu.Lock()
+	defer s.ks.mu.Unlock()
+	val, err := s.ks.lookupType(key, STRING_TYPE, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	var current []byte
+	if val != nil {
+		current = val.data.([]byte)
 	}
-	if exp != nil {
-		val.exp = exp
+	if len(data) == 0 {
+		// Nothing to write, not even the padding
+		return len(current), nil
 	}
-	s.store[key] = val
+	if offset+int64(len(data)) > MAX_STRING_LENGTH {
+		return 0, &ErrStringTooLong{}
+	}
+	buff := grow(current, int(offset)+len(data))
+	copy(buff[offset:], data)
+	if val == nil {
+		s.ks.set(key, STRING_TYPE, buff, nil)
+	} else {
+		val.data = buff
+	}
+	return len(buff), nil
 }
 
-func (s *store) Update(key string, data Token) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	val := s.store[key]
-	if !val.exists {
-		s.err = fmt.Errorf("can not update non existent key: %v", key)
-		return
+func (s *store) GetDel(key string) (*Token, error) {
+	s.ks.mu.Lock()
+	defer s.ks.mu.Unlock()
+	val, err := s.ks.lookupType(key, STRING_TYPE, time.Now())
+	if val == nil || err != nil {
+		return nil, err
+	}
+	s.ks.remove(key)
+	return stringToken(val.data.([]byte)), nil
+}
+
+// GetEx returns the value at key and sets its expiry to exp, or removes the
+// expiry with persist. An expiry in the past deletes the key
+func (s *store) GetEx(key string, exp *time.Time, persist bool) (*Token, error) {
+	s.ks.mu.Lock()
+	defer s.ks.mu.Unlock()
+	now := time.Now()
+	val, err := s.ks.lookupType(key, STRING_TYPE, now)
+	if val == nil || err != nil {
+		return nil, err
+	}
+	data := stringToken(val.data.([]byte))
+	switch {
+	case exp != nil && !exp.After(now):
+		s.ks.remove(key)
+	case exp != nil:
+		s.ks.setExpiry(key, val, exp)
+	case persist:
+		s.ks.setExpiry(key, val, nil)
+	}
+	return data, nil
+}
+
+func (s *store) Set(key string, data Token, exp *time.Time) {
+	s.ks.mu.Lock()
+	defer s.ks.mu.Unlock()
+	s.err = nil
+	s.ks.set(key, STRING_TYPE, tokenBytes(data), exp)
+}
+
+// SetIf is Set applying opts atomically. old is the replaced value, only
+// looked up when opts.Get is set
+func (s *store) SetIf(key string, data Token, exp *time.Time, opts SetOptions) (*Token, bool, error) {
+	s.ks.mu.Lock()
+	defer s.ks.mu.Unlock()
+	current := s.ks.lookup(key, time.Now())
+	var old *Token
+	if opts.Get && current != nil {
+		if current.typ != STRING_TYPE {
+			return nil, false, &ErrWrongType{}
+		}
+		old = stringToken(current.data.([]byte))
 	}
-	val.data = data
-	s.store[key] = val
+	if opts.NX && current != nil || opts.XX && current == nil {
+		return old, false, nil
+	}
+	if opts.KeepTTL && current != nil {
+		exp = current.exp
+	}
+	s.ks.set(key, STRING_TYPE, tokenBytes(data), exp)
+	return old, true, nil
 }
 
-func (s *store) Keys() iter.Seq[string] {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+// IncrBy adds delta to the integer at key, a missing key counts as 0
+func (s *store) IncrBy(key string, delta int64) (int64, error) {
+	s.ks.mu.Lock()
+	defer s.ks.mu.Unlock()
+	val, err := s.ks.lookupType(key, STRING_TYPE, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	var current int64
+	if val != nil {
+		literal := string(val.data.([]byte))
+		var ok bool
+		if current, ok = parseInteger(literal); !ok {
+			return 0, &ErrNotInteger{data: literal}
+		}
+	}
+	current, err = addInteger(current, delta)
+	if err != nil {
+		return 0, err
+	}
+	data := []byte(strconv.FormatInt(current, 10))
+	if val == nil {
+		s.ks.set(key, STRING_TYPE, data, nil)
+	} else {
+		val.data = data
+	}
+	return current, nil
+}
 
-	return maps.Keys(s.store)
+// IncrByFloat adds delta to the number at key and returns the new value as
+// it is stored
+func (s *store) IncrByFloat(key string, delta float64) (string, error) {
+	s.ks.mu.Lock()
+	defer s.ks.mu.Unlock()
+	val, err := s.ks.lookupType(key, STRING_TYPE, time.Now())
+	if err != nil {
+		return "", err
+	}
+	var current float64
+	if val != nil {
+		var ok bool
+		if current, ok = parseFloat(string(val.data.([]byte))); !ok {
+			return "", &ErrNotFloat{}
+		}
+	}
+	current += delta
+	if math.IsNaN(current) || math.IsInf(current, 0) {
+		return "", &ErrNaNOrInfinity{}
+	}
+	updated := formatFloat(current)
+	data := []byte(updated)
+	if val == nil {
+		s.ks.set(key, STRING_TYPE, data, nil)
+	} else {
+		val.data = data
+	}
+	return updated, nil
 }
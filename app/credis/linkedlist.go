@@ -17,27 +17,16 @@ func NewList[T any]() *LinkedList[T] {
 }
 
 func (l *LinkedList[T]) Append(data T) {
-	if l.head == nil {
-		l.head = &Node[T]{
-			data: data,
-		}
-		l.tail = l.head
-	} else if l.head.next == nil {
-		newNode := &Node[T]{
-			data: data,
-			prev: l.head,
-		}
-		l.head.next = newNode
-		l.tail = newNode
+	newNode := &Node[T]{
+		data: data,
+		prev: l.tail,
+	}
+	if l.tail != nil {
+		l.tail.next = newNode
 	} else {
-		prevLast := l.tail
-		newNode := &Node[T]{
-			data: data,
-			prev: prevLast,
-		}
-		prevLast.next = newNode
-		l.tail = newNode
+		l.head = newNode
 	}
+	l.tail = newNode
 	l.length++
 }
 
@@ -46,8 +35,8 @@ func (l *LinkedList[T]) Get(start, end int64) []T {
 	if l.length > 0 {
 		var currentIndex int64
 		currentNode := l.head
-		for currentNode != nil {
-			if currentIndex >= start && currentIndex <= end {
+		for currentNode != nil && currentIndex <= end {
+			if currentIndex >= start {
 				elements = append(elements, currentNode.data)
 			}
 			currentIndex++
@@ -58,32 +47,26 @@ func (l *LinkedList[T]) Get(start, end int64) []T {
 }
 
 func (l *LinkedList[T]) Prepend(data T) {
-	if l.head == nil {
-		l.head = &Node[T]{
-			data: data,
-		}
-		l.tail = l.head
+	newNode := &Node[T]{
+		data: data,
+		next: l.head,
+	}
+	if l.head != nil {
+		l.head.prev = newNode
 	} else {
-		prevHead := *l.head
-		newHead := &Node[T]{
-			data: data,
-			next: &prevHead,
-		}
-		prevHead.prev = newHead
-		l.head = newHead
+		l.tail = newNode
 	}
+	l.head = newNode
 	l.length++
 }
 
 func (l *LinkedList[T]) Pop() *T {
-	var popped *T
 	if l.head == nil {
-		return popped
+		return nil
 	}
-	popped = &l.head.data
-	l.head = l.head.next
-	l.length--
-	return popped
+	popped := l.head.data
+	l.unlink(l.head)
+	return &popped
 }
 
 func (l *LinkedList[T]) Len() int {
@@ -91,32 +74,28 @@ func (l *LinkedList[T]) Len() int {
 }
 
 func (l *LinkedList[T]) Remove(ind int) *T {
-	var removed *T
-	current := 0
-	if l.length == 0 {
-		return removed
-	}
 	currentNode := l.head
-	for currentNode != nil {
+	for current := 0; currentNode != nil; current++ {
 		if ind == current {
-			removed = &currentNode.data
-			if ind == 0 {
-				// remove head
-				removed = &l.head.data
-				l.head = l.head.next
-			} else {
-				if currentNode.prev != nil {
-					currentNode.prev.next = currentNode.next
-				}
-				if currentNode.next != nil {
-					currentNode.next.prev = currentNode.prev
-				}
-			}
-			l.length--
-			break
+			l.unlink(currentNode)
+			return &currentNode.data
 		}
 		currentNode = currentNode.next
-		current++
 	}
-	return removed
+	return nil
+}
+
+func (l *LinkedList[T]) unlink(node *Node[T]) {
+	if node.prev != nil {
+		node.prev.next = node.next
+	} else {
+		l.head = node.next
+	}
+	if node.next != nil {
+		node.next.prev = node.prev
+	} else {
+		l.tail = node.prev
+	}
+	node.prev, node.next = nil, nil
+	l.length--
 }
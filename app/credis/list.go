@@ -1,95 +1,337 @@
 package credis
 
 import (
-	"sync"
+	"fmt"
+	"time"
 )
 
-type ListStore[T any] interface {
-	Push(key string, values []T) int
-	Get(key string, start int64, end int64) []T
-	Prepend(key string, values []T) int
-	Len(key string) int
-	Pop(key string) *T
+type ListStore[T comparable] interface {
+	Push(key string, values []T, xx bool) (int, error)
+	Get(key string, start int64, end int64) ([]T, error)
+	Prepend(key string, values []T, xx bool) (int, error)
+	Len(key string) (int, error)
+	Pop(key string, count int) ([]T, error)
+	PopTail(key string, count int) ([]T, error)
+	Index(key string, index int64) (*T, error)
+	Set(key string, index int64, value T) error
+	Insert(key string, pivot T, value T, before bool) (int, error)
+	Remove(key string, count int64, value T) (int, error)
+	Trim(key string, start int64, end int64) error
+	Pos(key string, value T, opts ListPosOptions) ([]int, error)
+	Move(src string, dest string, fromTail bool, toTail bool) (*T, error)
 }
 
-type list[T any] struct {
-	// key -> List of values
-	data map[string]*LinkedList[T]
-	mu   sync.RWMutex
+// ListPosOptions are the RANK, COUNT and MAXLEN options of LPOS. Rank is
+// never 0, a negative rank searches from the tail. Count and MaxLen are
+// unlimited when 0
+type ListPosOptions struct {
+	Rank   int64
+	Count  int64
+	MaxLen int64
 }
 
-func NewListStore[T any]() ListStore[T] {
+type list[T comparable] struct {
+	ks *keyspace
+}
+
+func NewListStore[T comparable](ks *keyspace) ListStore[T] {
 	return &list[T]{
-		data: make(map[string]*LinkedList[T]),
+		ks: ks,
+	}
+}
+
+// listRange resolves start and end, which may count from the tail, against
+// a list of length elements. ok is false for an empty range
+func listRange(start int64, end int64, length int) (int64, int64, bool) {
+	last := int64(length)
+	if start < 0 {
+		start = max(last+start, 0)
+	}
+	if end < 0 {
+		end = last + end
+	}
+	end = min(end, last-1)
+	return start, end, start <= end
+}
+
+// lookup returns the list stored at key, nil when key does not exist.
+// Caller must hold the lock
+func (l *list[T]) lookup(key string) (*Quicklist[T], error) {
+	val, err := l.ks.lookupType(key, LIST_TYPE, time.Now())
+	if val == nil || err != nil {
+		return nil, err
 	}
+	return val.data.(*Quicklist[T]), nil
 }
 
-func (l *list[T]) Push(key string, values []T) int {
-	l.mu.Lock()
-	defer l.mu.Unlock()
-	if l.data[key] == nil {
-		l.data[key] = NewList[T]()
+// lookupOrCreate is lookup that creates an empty list when key does not
+// exist. Caller must hold the write lock
+func (l *list[T]) lookupOrCreate(key string) (*Quicklist[T], error) {
+	ls, err := l.lookup(key)
+	if err != nil {
+		return nil, err
+	}
+	if ls == nil {
+		ls = NewQuicklist[T]()
+		l.ks.set(key, LIST_TYPE, ls, nil)
+	}
+	return ls, nil
+}
+
+// removeIfEmpty drops key once its list has no element left, as empty
+// lists are never kept in the keyspace. Caller must hold the write lock
+func (l *list[T]) removeIfEmpty(key string, ls *Quicklist[T]) {
+	if ls.Len() == 0 {
+		l.ks.remove(key)
+	}
+}
+
+// Push appends values to the tail. With xx nothing is done unless the list
+// already exists
+func (l *list[T]) Push(key string, values []T, xx bool) (int, error) {
+	l.ks.mu.Lock()
+	defer l.ks.mu.Unlock()
+	lookup := l.lookupOrCreate
+	if xx {
+		lookup = l.lookup
+	}
+	ls, err := lookup(key)
+	if ls == nil || err != nil {
+		return 0, err
 	}
 	for _, d := range values {
-		l.data[key].Append(d)
+		ls.Append(d)
 	}
-	return l.data[key].Len()
+	l.ks.signalReady(key)
+	return ls.Len(), nil
 }
 
-func (l *list[T]) Get(key string, start int64, end int64) []T {
+func (l *list[T]) Get(key string, start int64, end int64) ([]T, error) {
 	elements := []T{}
-	l.mu.RLock()
-	defer l.mu.RUnlock()
-	if l.data[key] == nil {
-		return elements
+	l.ks.mu.RLock()
+	defer l.ks.mu.RUnlock()
+	ls, err := l.lookup(key)
+	if err != nil {
+		return nil, err
 	}
-	startInd := start
-	endInd := end
-	lastInd := int64(l.data[key].Len())
-	if startInd < 0 {
-		startInd = lastInd + startInd
+	if ls == nil {
+		return elements, nil
 	}
-	if endInd < 0 {
-		endInd = lastInd + endInd
+	if start, end, ok := listRange(start, end, ls.Len()); ok {
+		elements = ls.Get(start, end)
 	}
-	if endInd > lastInd {
-		endInd = lastInd - 1
+	return elements, nil
+}
+
+// Prepend inserts values at the head one after the other, so they end up
+// in reverse order. With xx nothing is done unless the list already exists
+func (l *list[T]) Prepend(key string, values []T, xx bool) (int, error) {
+	l.ks.mu.Lock()
+	defer l.ks.mu.Unlock()
+	lookup := l.lookupOrCreate
+	if xx {
+		lookup = l.lookup
 	}
-	if startInd < 0 {
-		startInd = 0
+	ls, err := lookup(key)
+	if ls == nil || err != nil {
+		return 0, err
 	}
-	if startInd >= 0 && endInd >= 0 && startInd <= endInd && lastInd > 0 && lastInd > startInd {
-		elements = l.data[key].Get(startInd, endInd)
+	for _, d := range values {
+		ls.Prepend(d)
 	}
-	return elements
+	l.ks.signalReady(key)
+	return ls.Len(), nil
 }
 
-func (l *list[T]) Prepend(key string, values []T) int {
-	l.mu.Lock()
-	defer l.mu.Unlock()
-	if l.data[key] == nil {
-		l.data[key] = NewList[T]()
+func (l *list[T]) Len(key string) (int, error) {
+	l.ks.mu.RLock()
+	defer l.ks.mu.RUnlock()
+	ls, err := l.lookup(key)
+	if ls == nil || err != nil {
+		return 0, err
 	}
-	for _, d := range values {
-		l.data[key].Prepend(d)
+	return ls.Len(), nil
+}
+
+// pop removes up to count elements from either end, nil when key does not
+// exist
+func (l *list[T]) pop(key string, count int, tail bool) ([]T, error) {
+	l.ks.mu.Lock()
+	defer l.ks.mu.Unlock()
+	ls, err := l.lookup(key)
+	if ls == nil || err != nil {
+		return nil, err
+	}
+	popped := []T{}
+	for range min(count, ls.Len()) {
+		if tail {
+			popped = append(popped, *ls.PopTail())
+		} else {
+			popped = append(popped, *ls.Pop())
+		}
+	}
+	l.removeIfEmpty(key, ls)
+	return popped, nil
+}
+
+func (l *list[T]) Pop(key string, count int) ([]T, error) {
+	return l.pop(key, count, false)
+}
+
+func (l *list[T]) PopTail(key string, count int) ([]T, error) {
+	return l.pop(key, count, true)
+}
+
+// Index returns the element at index, counted from the tail when negative
+func (l *list[T]) Index(key string, index int64) (*T, error) {
+	l.ks.mu.RLock()
+	defer l.ks.mu.RUnlock()
+	ls, err := l.lookup(key)
+	if ls == nil || err != nil {
+		return nil, err
+	}
+	return ls.Index(int(index)), nil
+}
+
+func (l *list[T]) Set(key string, index int64, value T) error {
+	l.ks.mu.Lock()
+	defer l.ks.mu.Unlock()
+	ls, err := l.lookup(key)
+	if err != nil {
+		return err
 	}
-	return l.data[key].Len()
+	if ls == nil {
+		return fmt.Errorf("ERR no such key")
+	}
+	if !ls.Set(int(index), value) {
+		return fmt.Errorf("ERR index out of range")
+	}
+	return nil
 }
 
-func (l *list[T]) Len(key string) int {
-	l.mu.RLock()
-	defer l.mu.RUnlock()
-	if l.data[key] == nil {
-		return 0
+// Insert adds value next to the first occurrence of pivot and returns the
+// new length, -1 when pivot is not found and 0 when key does not exist
+func (l *list[T]) Insert(key string, pivot T, value T, before bool) (int, error) {
+	l.ks.mu.Lock()
+	defer l.ks.mu.Unlock()
+	ls, err := l.lookup(key)
+	if ls == nil || err != nil {
+		return 0, err
+	}
+	for index, elem := range ls.All(false) {
+		if elem != pivot {
+			continue
+		}
+		if !before {
+			index++
+		}
+		ls.Insert(index, value)
+		return ls.Len(), nil
+	}
+	return -1, nil
+}
+
+// Remove deletes up to count occurrences of value starting from the head,
+// from the tail when count is negative and all of them when count is 0
+func (l *list[T]) Remove(key string, count int64, value T) (int, error) {
+	l.ks.mu.Lock()
+	defer l.ks.mu.Unlock()
+	ls, err := l.lookup(key)
+	if ls == nil || err != nil {
+		return 0, err
+	}
+	limit := count
+	if limit < 0 {
+		limit = -limit
 	}
-	return l.data[key].Len()
+	removed := ls.RemoveFunc(count < 0, int(limit), func(elem T) bool {
+		return elem == value
+	})
+	l.removeIfEmpty(key, ls)
+	return removed, nil
 }
 
-func (l *list[T]) Pop(key string) *T {
-	l.mu.Lock()
-	defer l.mu.Unlock()
-	if l.data[key] == nil {
+// Trim keeps the elements between start and end only
+func (l *list[T]) Trim(key string, start int64, end int64) error {
+	l.ks.mu.Lock()
+	defer l.ks.mu.Unlock()
+	ls, err := l.lookup(key)
+	if ls == nil || err != nil {
+		return err
+	}
+	start, end, ok := listRange(start, end, ls.Len())
+	if !ok {
+		l.ks.remove(key)
 		return nil
 	}
-	return l.data[key].Pop()
+	ls.Trim(int(start), int(end))
+	return nil
+}
+
+// Pos returns the indexes of the elements equal to value as selected by
+// opts, indexes always count from the head
+func (l *list[T]) Pos(key string, value T, opts ListPosOptions) ([]int, error) {
+	l.ks.mu.RLock()
+	defer l.ks.mu.RUnlock()
+	positions := []int{}
+	ls, err := l.lookup(key)
+	if ls == nil || err != nil {
+		return positions, err
+	}
+	skip := opts.Rank - 1
+	if opts.Rank < 0 {
+		skip = -opts.Rank - 1
+	}
+	compared := int64(0)
+	for index, elem := range ls.All(opts.Rank < 0) {
+		if opts.MaxLen > 0 && compared == opts.MaxLen {
+			break
+		}
+		compared++
+		if elem != value {
+			continue
+		}
+		if skip > 0 {
+			skip--
+			continue
+		}
+		positions = append(positions, index)
+		if opts.Count > 0 && int64(len(positions)) == opts.Count {
+			break
+		}
+	}
+	return positions, nil
+}
+
+// Move pops an element from one end of src and pushes it to one end of
+// dest, nil when src does not exist. The type of dest is only checked once
+// there is something to move, as redis does
+func (l *list[T]) Move(src string, dest string, fromTail bool, toTail bool) (*T, error) {
+	l.ks.mu.Lock()
+	defer l.ks.mu.Unlock()
+	from, err := l.lookup(src)
+	if from == nil || err != nil {
+		return nil, err
+	}
+	to, err := l.lookup(dest)
+	if err != nil {
+		return nil, err
+	}
+	pop := from.Pop
+	if fromTail {
+		pop = from.PopTail
+	}
+	moved := pop()
+	if to == nil {
+		to = NewQuicklist[T]()
+		l.ks.set(dest, LIST_TYPE, to, nil)
+	}
+	if toTail {
+		to.Append(*moved)
+	} else {
+		to.Prepend(*moved)
+	}
+	l.ks.signalReady(dest)
+	l.removeIfEmpty(src, from)
+	return moved, nil
 }
@@ -3,6 +3,7 @@ package credis
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"strconv"
 )
 
@@ -49,6 +50,7 @@ type Parser interface {
 	TryParse() (Token, int)
 	Error() error
 	ProcessRDB()
+	Wait() error
 }
 
 type parser struct {
@@ -106,6 +108,13 @@ func (p *parser) Error() error {
 	return p.err
 }
 
+// Wait blocks until there is input to parse without consuming it, returning
+// the error that stopped the reader instead, like io.EOF
+func (p *parser) Wait() error {
+	_, err := p.reader.Peek(1)
+	return err
+}
+
 func (p *parser) bulkString() (Token, int) {
 	bytesProcessed := 1
 	lenght, bytesConsumed := p.length()
@@ -114,7 +123,9 @@ func (p *parser) bulkString() (Token, int) {
 	}
 	bytesProcessed += bytesConsumed
 	strBytes := make([]byte, lenght)
-	n, err := p.reader.Read(strBytes)
+	// A single Read stops at the end of the buffered data, so large strings
+	// would be cut short
+	n, err := io.ReadFull(p.reader, strBytes)
 	bytesProcessed += n
 	if err != nil {
 		p.err = err
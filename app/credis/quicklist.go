@@ -0,0 +1,408 @@
+package credis
+
+import "iter"
+
+// Elements held by a single quicklist node. Pushes and pops at both ends are
+// O(1) and lookups by index skip whole nodes
+const QUICKLIST_NODE_SIZE = 128
+
+// quicklistNode stores its elements in elems[lo:hi]. elems doubles when it
+// runs out of room, up to QUICKLIST_NODE_SIZE, so short lists stay small.
+// Head nodes fill towards the front and tail nodes towards the back
+type quicklistNode[T any] struct {
+	elems []T
+	lo    int
+	hi    int
+	prev  *quicklistNode[T]
+	next  *quicklistNode[T]
+}
+
+func newQuicklistNode[T any]() *quicklistNode[T] {
+	return &quicklistNode[T]{}
+}
+
+// newNode is newQuicklistNode reusing the spare node when there is one. Its
+// room is left at the front when front is set. Only the first node of a list
+// grows on demand, lists needing more get full nodes right away
+func (ql *Quicklist[T]) newNode(front bool) *quicklistNode[T] {
+	node := ql.spare
+	if node == nil {
+		node = newQuicklistNode[T]()
+		if ql.head == nil {
+			return node
+		}
+		node.elems = make([]T, QUICKLIST_NODE_SIZE)
+	}
+	ql.spare = nil
+	node.lo, node.hi = 0, 0
+	if front {
+		node.lo, node.hi = len(node.elems), len(node.elems)
+	}
+	return node
+}
+
+// reserve makes room for one more element at the front when front is set,
+// at the back otherwise. It reports false when the node is full
+func (n *quicklistNode[T]) reserve(front bool) bool {
+	if front && n.lo > 0 || !front && n.hi < len(n.elems) {
+		return true
+	}
+	length := n.len()
+	if length == QUICKLIST_NODE_SIZE {
+		return false
+	}
+	elems := n.elems
+	if size := min(max(2*length, 1), QUICKLIST_NODE_SIZE); size > len(elems) {
+		elems = make([]T, size)
+	}
+	lo := 0
+	if front {
+		lo = len(elems) - length
+	}
+	copy(elems[lo:], n.elems[n.lo:n.hi])
+	if len(elems) == len(n.elems) {
+		// Moved within the same array, the slots left behind are cleared
+		if front {
+			clear(elems[:lo])
+		} else {
+			clear(elems[length:])
+		}
+	}
+	n.elems, n.lo, n.hi = elems, lo, lo+length
+	return true
+}
+
+func (n *quicklistNode[T]) len() int {
+	return n.hi - n.lo
+}
+
+// Quicklist is a doubly linked list of chunks of elements, similar to the
+// redis quicklist, which keeps the per element overhead low
+type Quicklist[T any] struct {
+	head   *quicklistNode[T]
+	tail   *quicklistNode[T]
+	length int
+	// Last node emptied by a pop, kept so that pushing and popping around a
+	// node boundary does not allocate every time
+	spare *quicklistNode[T]
+}
+
+func NewQuicklist[T any]() *Quicklist[T] {
+	return &Quicklist[T]{}
+}
+
+func (ql *Quicklist[T]) Len() int {
+	return ql.length
+}
+
+// link adds node behind prev, at the head when prev is nil
+func (ql *Quicklist[T]) link(prev *quicklistNode[T], node *quicklistNode[T]) {
+	node.prev = prev
+	if prev == nil {
+		node.next = ql.head
+		ql.head = node
+	} else {
+		node.next = prev.next
+		prev.next = node
+	}
+	if node.next != nil {
+		node.next.prev = node
+	} else {
+		ql.tail = node
+	}
+}
+
+func (ql *Quicklist[T]) unlink(node *quicklistNode[T]) {
+	if node.prev != nil {
+		node.prev.next = node.next
+	} else {
+		ql.head = node.next
+	}
+	if node.next != nil {
+		node.next.prev = node.prev
+	} else {
+		ql.tail = node.prev
+	}
+	node.prev, node.next = nil, nil
+}
+
+func (ql *Quicklist[T]) Append(data T) {
+	if ql.tail == nil || !ql.tail.reserve(false) {
+		ql.link(ql.tail, ql.newNode(false))
+		ql.tail.reserve(false)
+	}
+	ql.tail.elems[ql.tail.hi] = data
+	ql.tail.hi++
+	ql.length++
+}
+
+func (ql *Quicklist[T]) Prepend(data T) {
+	if ql.head == nil || !ql.head.reserve(true) {
+		ql.link(nil, ql.newNode(true))
+		ql.head.reserve(true)
+	}
+	ql.head.lo--
+	ql.head.elems[ql.head.lo] = data
+	ql.length++
+}
+
+func (ql *Quicklist[T]) Pop() *T {
+	if ql.head == nil {
+		return nil
+	}
+	node := ql.head
+	popped := node.elems[node.lo]
+	var zero T
+	node.elems[node.lo] = zero
+	node.lo++
+	ql.length--
+	if node.len() == 0 {
+		ql.unlink(node)
+		ql.spare = node
+	}
+	return &popped
+}
+
+func (ql *Quicklist[T]) PopTail() *T {
+	if ql.tail == nil {
+		return nil
+	}
+	node := ql.tail
+	node.hi--
+	popped := node.elems[node.hi]
+	var zero T
+	node.elems[node.hi] = zero
+	ql.length--
+	if node.len() == 0 {
+		ql.unlink(node)
+		ql.spare = node
+	}
+	return &popped
+}
+
+// locate returns the node holding the element at index along with its
+// position in the node's elems, walking from the closest end. index must be
+// within range
+func (ql *Quicklist[T]) locate(index int) (*quicklistNode[T], int) {
+	if index < ql.length/2 {
+		node := ql.head
+		for index >= node.len() {
+			index -= node.len()
+			node = node.next
+		}
+		return node, node.lo + index
+	}
+	index = ql.length - 1 - index
+	node := ql.tail
+	for index >= node.len() {
+		index -= node.len()
+		node = node.prev
+	}
+	return node, node.hi - 1 - index
+}
+
+// Get returns the elements from start to end included, both within range
+func (ql *Quicklist[T]) Get(start, end int64) []T {
+	elements := make([]T, 0, end-start+1)
+	node, pos := ql.locate(int(start))
+	for remaining := int(end - start + 1); remaining > 0; node = node.next {
+		chunk := node.elems[pos:min(node.hi, pos+remaining)]
+		elements = append(elements, chunk...)
+		remaining -= len(chunk)
+		if node.next != nil {
+			pos = node.next.lo
+		}
+	}
+	return elements
+}
+
+// Index returns the element at index, counted from the tail when negative,
+// nil when out of range
+func (ql *Quicklist[T]) Index(index int) *T {
+	if index < 0 {
+		index += ql.length
+	}
+	if index < 0 || index >= ql.length {
+		return nil
+	}
+	node, pos := ql.locate(index)
+	value := node.elems[pos]
+	return &value
+}
+
+// Set replaces the element at index, counted from the tail when negative,
+// and reports whether index was within range
+func (ql *Quicklist[T]) Set(index int, data T) bool {
+	if index < 0 {
+		index += ql.length
+	}
+	if index < 0 || index >= ql.length {
+		return false
+	}
+	node, pos := ql.locate(index)
+	node.elems[pos] = data
+	return true
+}
+
+// Insert adds data so that it ends up at index, from 0 to Len included
+func (ql *Quicklist[T]) Insert(index int, data T) {
+	switch index {
+	case 0:
+		ql.Prepend(data)
+		return
+	case ql.length:
+		ql.Append(data)
+		return
+	}
+	node, pos := ql.locate(index)
+	offset := pos - node.lo
+	if node.len() == QUICKLIST_NODE_SIZE {
+		// Full node, its second half moves to a new node
+		half := QUICKLIST_NODE_SIZE / 2
+		next := &quicklistNode[T]{elems: make([]T, half)}
+		next.hi = copy(next.elems, node.elems[node.lo+half:node.hi])
+		clear(node.elems[node.lo+half : node.hi])
+		node.hi = node.lo + half
+		ql.link(node, next)
+		if offset >= half {
+			node, offset = next, offset-half
+		}
+	}
+	node.reserve(false)
+	pos = node.lo + offset
+	copy(node.elems[pos+1:node.hi+1], node.elems[pos:node.hi])
+	node.hi++
+	node.elems[pos] = data
+	ql.length++
+}
+
+// RemoveFunc deletes up to limit elements for which match is true, all of
+// them when limit is 0, walking from the tail when reverse is set
+func (ql *Quicklist[T]) RemoveFunc(reverse bool, limit int, match func(T) bool) int {
+	removed := 0
+	node := ql.head
+	if reverse {
+		node = ql.tail
+	}
+	for node != nil && (limit == 0 || removed < limit) {
+		// Merges only happen with the neighbour already walked past
+		next, done := node.next, node.prev
+		if reverse {
+			next, done = node.prev, node.next
+		}
+		// Kept elements are compacted towards the end the walk comes from
+		if reverse {
+			w := node.hi - 1
+			for r := node.hi - 1; r >= node.lo; r-- {
+				if (limit == 0 || removed < limit) && match(node.elems[r]) {
+					removed++
+					continue
+				}
+				node.elems[w] = node.elems[r]
+				w--
+			}
+			clear(node.elems[node.lo : w+1])
+			node.lo = w + 1
+		} else {
+			w := node.lo
+			for r := node.lo; r < node.hi; r++ {
+				if (limit == 0 || removed < limit) && match(node.elems[r]) {
+					removed++
+					continue
+				}
+				node.elems[w] = node.elems[r]
+				w++
+			}
+			clear(node.elems[w:node.hi])
+			node.hi = w
+		}
+		ql.shrink(node, done)
+		node = next
+	}
+	ql.length -= removed
+	return removed
+}
+
+// shrink drops node once empty, or merges it with other, one of its
+// neighbours, when both fit in a single node
+func (ql *Quicklist[T]) shrink(node *quicklistNode[T], other *quicklistNode[T]) {
+	if node.len() == 0 {
+		ql.unlink(node)
+		return
+	}
+	if other == nil || node.len() >= QUICKLIST_NODE_SIZE/4 || other.len()+node.len() > QUICKLIST_NODE_SIZE {
+		return
+	}
+	first, second := other, node
+	if other == node.next {
+		first, second = node, other
+	}
+	// Both are compacted to the front of first
+	elems := first.elems
+	if total := first.len() + second.len(); total > len(elems) {
+		elems = make([]T, total)
+	}
+	n := copy(elems, first.elems[first.lo:first.hi])
+	n += copy(elems[n:], second.elems[second.lo:second.hi])
+	clear(elems[n:])
+	first.elems, first.lo, first.hi = elems, 0, n
+	ql.unlink(second)
+}
+
+// Trim keeps the elements from start to end included, both within range
+func (ql *Quicklist[T]) Trim(start, end int) {
+	for drop := start; drop > 0; {
+		node := ql.head
+		if node.len() <= drop {
+			drop -= node.len()
+			ql.length -= node.len()
+			ql.unlink(node)
+			continue
+		}
+		clear(node.elems[node.lo : node.lo+drop])
+		node.lo += drop
+		ql.length -= drop
+		drop = 0
+	}
+	for drop := ql.length - (end - start + 1); drop > 0; {
+		node := ql.tail
+		if node.len() <= drop {
+			drop -= node.len()
+			ql.length -= node.len()
+			ql.unlink(node)
+			continue
+		}
+		clear(node.elems[node.hi-drop : node.hi])
+		node.hi -= drop
+		ql.length -= drop
+		drop = 0
+	}
+}
+
+// All iterates over the elements along with their index, from the tail when
+// reverse is set
+func (ql *Quicklist[T]) All(reverse bool) iter.Seq2[int, T] {
+	return func(yield func(int, T) bool) {
+		if reverse {
+			index := ql.length - 1
+			for node := ql.tail; node != nil; node = node.prev {
+				for i := node.hi - 1; i >= node.lo; i-- {
+					if !yield(index, node.elems[i]) {
+						return
+					}
+					index--
+				}
+			}
+			return
+		}
+		index := 0
+		for node := ql.head; node != nil; node = node.next {
+			for _, elem := range node.elems[node.lo:node.hi] {
+				if !yield(index, elem) {
+					return
+				}
+				index++
+			}
+		}
+	}
+}
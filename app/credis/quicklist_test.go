@@ -0,0 +1,318 @@
+package credis
+
+import (
+	"math/rand"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+// Elements of the lists the benchmarks run against
+const benchListSize = 10_000_000
+
+// benchList is the API shared by the quicklist and the LinkedList it
+// replaced as list storage
+type benchList interface {
+	Append(data string)
+	Prepend(data string)
+	Pop() *string
+	Get(start, end int64) []string
+	Len() int
+}
+
+var benchLists = []struct {
+	name string
+	new  func() benchList
+}{
+	{"LinkedList", func() benchList { return NewList[string]() }},
+	{"Quicklist", func() benchList { return NewQuicklist[string]() }},
+}
+
+func newBenchList(newList func() benchList) benchList {
+	l := newList()
+	for i := range benchListSize {
+		l.Append(strconv.Itoa(i))
+	}
+	return l
+}
+
+// Each op builds a whole list, as RPUSH does
+func BenchmarkListAppend(b *testing.B) {
+	for _, bl := range benchLists {
+		b.Run(bl.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for range b.N {
+				l := bl.new()
+				for range benchListSize {
+					l.Append("x")
+				}
+			}
+		})
+	}
+}
+
+// Each op builds a whole list, as LPUSH does
+func BenchmarkListPrepend(b *testing.B) {
+	for _, bl := range benchLists {
+		b.Run(bl.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for range b.N {
+				l := bl.new()
+				for range benchListSize {
+					l.Prepend("x")
+				}
+			}
+		})
+	}
+}
+
+// A single element at a random index, as LINDEX does
+func BenchmarkListIndex(b *testing.B) {
+	for _, bl := range benchLists {
+		b.Run(bl.name, func(b *testing.B) {
+			l := newBenchList(bl.new)
+			b.ResetTimer()
+			for range b.N {
+				i := int64(rand.Intn(benchListSize))
+				l.Get(i, i)
+			}
+		})
+	}
+}
+
+// 100 elements in the middle, as LRANGE does
+func BenchmarkListRange(b *testing.B) {
+	for _, bl := range benchLists {
+		b.Run(bl.name, func(b *testing.B) {
+			l := newBenchList(bl.new)
+			b.ResetTimer()
+			for range b.N {
+				l.Get(benchListSize/2, benchListSize/2+99)
+			}
+		})
+	}
+}
+
+// Pushes and pops at the head of a full list, as LPUSH and LPOP do
+func BenchmarkListPushPop(b *testing.B) {
+	for _, bl := range benchLists {
+		b.Run(bl.name, func(b *testing.B) {
+			l := newBenchList(bl.new)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for range b.N {
+				l.Prepend("x")
+				l.Pop()
+			}
+		})
+	}
+}
+
+// newTestQuicklist returns a quicklist and the slice it is checked against,
+// both holding 0 to n-1
+func newTestQuicklist(n int) (*Quicklist[int], []int) {
+	ql, want := NewQuicklist[int](), make([]int, n)
+	for i := range n {
+		ql.Append(i)
+		want[i] = i
+	}
+	return ql, want
+}
+
+// checkQuicklist compares ql with want through every accessor and checks
+// that its nodes are linked, non empty and within QUICKLIST_NODE_SIZE
+func checkQuicklist(t *testing.T, ql *Quicklist[int], want []int) {
+	t.Helper()
+	if ql.Len() != len(want) {
+		t.Fatalf("Len() = %d, want %d", ql.Len(), len(want))
+	}
+	total := 0
+	var prev *quicklistNode[int]
+	for node := ql.head; node != nil; prev, node = node, node.next {
+		if node.prev != prev {
+			t.Fatalf("node after %d elements is badly linked", total)
+		}
+		if node.len() == 0 || node.len() > QUICKLIST_NODE_SIZE {
+			t.Fatalf("node after %d elements holds %d", total, node.len())
+		}
+		total += node.len()
+	}
+	if ql.tail != prev || total != len(want) {
+		t.Fatalf("nodes hold %d elements, want %d", total, len(want))
+	}
+	var got []int
+	for i, v := range ql.All(false) {
+		if i != len(got) {
+			t.Fatalf("All(false) yielded index %d at %d", i, len(got))
+		}
+		got = append(got, v)
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("All(false) = %v, want %v", got, want)
+	}
+	got = got[:0]
+	for i, v := range ql.All(true) {
+		if i != len(want)-1-len(got) {
+			t.Fatalf("All(true) yielded index %d at %d", i, len(got))
+		}
+		got = append(got, v)
+	}
+	slices.Reverse(got)
+	if !slices.Equal(got, want) {
+		t.Fatalf("All(true) = %v, want %v", got, want)
+	}
+	if len(want) > 0 {
+		if got := ql.Get(0, int64(len(want)-1)); !slices.Equal(got, want) {
+			t.Fatalf("Get = %v, want %v", got, want)
+		}
+	}
+	for i, v := range want {
+		if got := ql.Index(i); got == nil || *got != v {
+			t.Fatalf("Index(%d) = %v, want %d", i, got, v)
+		}
+		if got := ql.Index(i - len(want)); got == nil || *got != v {
+			t.Fatalf("Index(%d) = %v, want %d", i-len(want), got, v)
+		}
+	}
+	if ql.Index(len(want)) != nil || ql.Index(-len(want)-1) != nil {
+		t.Fatalf("Index out of range is not nil")
+	}
+}
+
+// Sizes around the node boundaries, the first node growing on demand
+var quicklistSizes = []int{0, 1, 2, QUICKLIST_NODE_SIZE - 1, QUICKLIST_NODE_SIZE,
+	QUICKLIST_NODE_SIZE + 1, 3*QUICKLIST_NODE_SIZE + 5}
+
+func TestQuicklistInsert(t *testing.T) {
+	for _, n := range quicklistSizes {
+		for _, index := range []int{0, 1, n / 2, n - 1, n, QUICKLIST_NODE_SIZE - 1,
+			QUICKLIST_NODE_SIZE, QUICKLIST_NODE_SIZE + 1} {
+			if index < 0 || index > n {
+				continue
+			}
+			ql, want := newTestQuicklist(n)
+			// Enough inserts at the same spot to split the node holding it
+			for i := range QUICKLIST_NODE_SIZE + 10 {
+				ql.Insert(index, -1-i)
+				want = slices.Insert(want, index, -1-i)
+			}
+			checkQuicklist(t, ql, want)
+		}
+	}
+}
+
+func TestQuicklistTrim(t *testing.T) {
+	const n = 3*QUICKLIST_NODE_SIZE + 5
+	tests := []struct{ start, end int }{
+		{0, n - 1},
+		{0, 0},
+		{n - 1, n - 1},
+		{1, n - 2},
+		{QUICKLIST_NODE_SIZE - 1, QUICKLIST_NODE_SIZE},
+		{QUICKLIST_NODE_SIZE, 2*QUICKLIST_NODE_SIZE - 1},
+		{QUICKLIST_NODE_SIZE + 1, 2*QUICKLIST_NODE_SIZE + 1},
+		{2 * QUICKLIST_NODE_SIZE, n - 1},
+	}
+	for _, tt := range tests {
+		ql, want := newTestQuicklist(n)
+		ql.Trim(tt.start, tt.end)
+		checkQuicklist(t, ql, want[tt.start:tt.end+1])
+	}
+}
+
+func TestQuicklistRemoveFunc(t *testing.T) {
+	const n = 3*QUICKLIST_NODE_SIZE + 5
+	tests := []struct {
+		name    string
+		reverse bool
+		limit   int
+		match   func(int) bool
+	}{
+		{"all", false, 0, func(int) bool { return true }},
+		{"none", false, 0, func(int) bool { return false }},
+		{"evens", false, 0, func(v int) bool { return v%2 == 0 }},
+		{"evens from tail", true, 0, func(v int) bool { return v%2 == 0 }},
+		{"limited", false, QUICKLIST_NODE_SIZE + 3, func(v int) bool { return v%3 != 0 }},
+		{"limited from tail", true, QUICKLIST_NODE_SIZE + 3, func(v int) bool { return v%3 != 0 }},
+		// Leaves nodes small enough to be merged with their neighbours
+		{"most", false, 0, func(v int) bool { return v%QUICKLIST_NODE_SIZE > 10 }},
+		{"most from tail", true, 0, func(v int) bool { return v%QUICKLIST_NODE_SIZE > 10 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ql, want := newTestQuicklist(n)
+			var kept []int
+			removed := 0
+			order := slices.Clone(want)
+			if tt.reverse {
+				slices.Reverse(order)
+			}
+			for _, v := range order {
+				if (tt.limit == 0 || removed < tt.limit) && tt.match(v) {
+					removed++
+					continue
+				}
+				kept = append(kept, v)
+			}
+			if tt.reverse {
+				slices.Reverse(kept)
+			}
+			if got := ql.RemoveFunc(tt.reverse, tt.limit, tt.match); got != removed {
+				t.Fatalf("RemoveFunc = %d, want %d", got, removed)
+			}
+			checkQuicklist(t, ql, kept)
+		})
+	}
+}
+
+// Random operations checked against a slice
+func TestQuicklistOps(t *testing.T) {
+	ql, want := NewQuicklist[int](), []int{}
+	for i := range 20_000 {
+		switch op := rand.Intn(8); {
+		case op == 0:
+			ql.Prepend(i)
+			want = slices.Insert(want, 0, i)
+		case op == 1:
+			ql.Append(i)
+			want = append(want, i)
+		case op == 2 && len(want) > 0:
+			if got := ql.Pop(); got == nil || *got != want[0] {
+				t.Fatalf("Pop() = %v, want %d", got, want[0])
+			}
+			want = want[1:]
+		case op == 3 && len(want) > 0:
+			if got := ql.PopTail(); got == nil || *got != want[len(want)-1] {
+				t.Fatalf("PopTail() = %v, want %d", got, want[len(want)-1])
+			}
+			want = want[:len(want)-1]
+		case op == 4:
+			index := rand.Intn(len(want) + 1)
+			ql.Insert(index, i)
+			want = slices.Insert(want, index, i)
+		case op == 5 && len(want) > 0:
+			index := rand.Intn(len(want))
+			ql.Set(index, i)
+			want[index] = i
+		case op == 6 && i%50 == 0:
+			// Drops the first 5 multiples of 7 from either end
+			reverse, removed := i%100 == 0, 0
+			ql.RemoveFunc(reverse, 5, func(v int) bool { return v%7 == 0 })
+			if reverse {
+				slices.Reverse(want)
+			}
+			want = slices.DeleteFunc(want, func(v int) bool {
+				if v%7 != 0 || removed == 5 {
+					return false
+				}
+				removed++
+				return true
+			})
+			if reverse {
+				slices.Reverse(want)
+			}
+			checkQuicklist(t, ql, want)
+		}
+	}
+	checkQuicklist(t, ql, want)
+}
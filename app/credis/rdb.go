@@ -5,7 +5,9 @@ import (
 	"bytes"
 	"encoding/binary"
 	"fmt"
+	"io"
 	"iter"
+	"math"
 	"os"
 	"path"
 	"strconv"
@@ -29,12 +31,12 @@ const (
 )
 
 const (
-	EOF          = 0xFF
-	EXPIRETIME   = 0xFD
-	EXPIRETIMEMS = 0xFC
-	SELECTDB     = 0xFE
-	AUX          = 0xFA
-	RESIZEDB     = 0xFB
+	EOF           = 0xFF
+	EXPIRETIMESEC = 0xFD
+	EXPIRETIMEMS  = 0xFC
+	SELECTDB      = 0xFE
+	AUX           = 0xFA
+	RESIZEDB      = 0xFB
 )
 
 const (
@@ -44,12 +46,22 @@ const (
 	SET_VALUE
 	SORTED_SET_VALUE
 	HASHMAP_VALUE
+	SORTED_SET_2_VALUE
+	_ // module (pre GA)
+	_ // module
+	_
 	ZIPMAP_VALUE
 	ZIPLIST_VALUE
 	INTSET_VALUE
 	SORTED_SET_ZIPLIST_VALUE
 	HASHMAP_ZIPLIST_VALUE
 	LIST_QUICKLIST_VALUE
+	STREAM_LISTPACKS_VALUE
+	HASHMAP_LISTPACK_VALUE
+	SORTED_SET_LISTPACK_VALUE
+	LIST_QUICKLIST_2_VALUE
+	STREAM_LISTPACKS_2_VALUE
+	SET_LISTPACK_VALUE
 )
 
 type rdbStore struct {
@@ -67,7 +79,7 @@ type RDBStore interface {
 	GetRDBDir() string
 	GetRDBFileName() string
 	Load()
-	Restore(str KVStore)
+	Restore(str dataStores)
 	Version() int
 	Error() error
 	GetAuxField(key string) string
@@ -95,25 +107,47 @@ func (cfg *rdbStore) string() string {
 				cfg.err = err
 				return ""
 			}
-			return fmt.Sprintf("%v", num)
+			return fmt.Sprintf("%v", int8(num))
 		case 1:
-			// 16 bit integer
+			// 16 bit integer, little endian
 			numBytes := make([]byte, 2)
-			_, err := cfg.reader.Read(numBytes)
+			_, err := io.ReadFull(cfg.reader, numBytes)
 			if err != nil {
 				cfg.err = err
 				return ""
 			}
-			return fmt.Sprintf("%v", binary.BigEndian.Uint16(numBytes))
+			return fmt.Sprintf("%v", int16(binary.LittleEndian.Uint16(numBytes)))
 		case 2:
-			// 32 bit integer
+			// 32 bit integer, little endian
 			numBytes := make([]byte, 4)
-			_, err := cfg.reader.Read(numBytes)
+			_, err := io.ReadFull(cfg.reader, numBytes)
 			if err != nil {
 				cfg.err = err
 				return ""
 			}
-			return fmt.Sprintf("%v", binary.BigEndian.Uint32(numBytes))
+			return fmt.Sprintf("%v", int32(binary.LittleEndian.Uint32(numBytes)))
+		case 3:
+			// LZF compressed string
+			compressedLen := cfg.length()
+			if cfg.err != nil {
+				return ""
+			}
+			strLen := cfg.length()
+			if cfg.err != nil {
+				return ""
+			}
+			compressed := make([]byte, compressedLen)
+			_, err := io.ReadFull(cfg.reader, compressed)
+			if err != nil {
+				cfg.err = err
+				return ""
+			}
+			str, err := lzfDecompress(compressed, strLen)
+			if err != nil {
+				cfg.err = err
+				return ""
+			}
+			return string(str)
 		default:
 			cfg.err = fmt.Errorf("unsupported encoding format")
 			return ""
@@ -123,7 +157,7 @@ func (cfg *rdbStore) string() string {
 		return ""
 	}
 	strBytes := make([]byte, strLen)
-	_, err := cfg.reader.Read(strBytes)
+	_, err := io.ReadFull(cfg.reader, strBytes)
 	if err != nil {
 		cfg.err = err
 		return ""
@@ -151,10 +185,24 @@ func (cfg *rdbStore) length() int {
 		lenBytes := make([]byte, 2)
 		lenBytes[0] = b & 63 // 0b00111111
 		lenBytes[1] = nextByte
-		length = int(binary.LittleEndian.Uint16(lenBytes))
+		length = int(binary.BigEndian.Uint16(lenBytes))
 	case 0b10:
-		lenBytes := make([]byte, 4)
-		length = int(binary.BigEndian.Uint32(lenBytes))
+		// 0x80 is followed by a 32 bit length, 0x81 by a 64 bit one
+		size := 4
+		if b == 0x81 {
+			size = 8
+		}
+		lenBytes := make([]byte, size)
+		_, err := io.ReadFull(reader, lenBytes)
+		if err != nil {
+			cfg.err = err
+			return 0
+		}
+		if size == 8 {
+			length = int(binary.BigEndian.Uint64(lenBytes))
+		} else {
+			length = int(binary.BigEndian.Uint32(lenBytes))
+		}
 	case 0b11:
 		length = -1
 		reader.UnreadByte()
@@ -207,7 +255,7 @@ func (r *rdbStore) Version() int {
 	return r.version
 }
 
-func (cfg *rdbStore) Restore(str KVStore) {
+func (cfg *rdbStore) Restore(str dataStores) {
 	if cfg.reader == nil {
 		cfg.err = fmt.Errorf("RDB file not loaded! restore skipped")
 		return
@@ -289,7 +337,7 @@ func (cfg *rdbStore) Restore(str KVStore) {
 					if cfg.err != nil {
 						return
 					}
-				case EXPIRETIME:
+				case EXPIRETIMESEC:
 					// Expiry time in seconds
 					etBytes := make([]byte, 4)
 					_, err := reader.Read(etBytes)
@@ -322,7 +370,7 @@ func (cfg *rdbStore) Restore(str KVStore) {
 				default:
 					// value type check
 					if valueType == UNSET {
-						if b > 15 {
+						if b > SET_LISTPACK_VALUE {
 							cfg.err = fmt.Errorf("invalid value type found")
 							return
 						}
@@ -336,6 +384,42 @@ func (cfg *rdbStore) Restore(str KVStore) {
 						switch valueType {
 						case STRING_VALUE:
 							value = cfg.string()
+						case SORTED_SET_VALUE, SORTED_SET_2_VALUE, SORTED_SET_ZIPLIST_VALUE, SORTED_SET_LISTPACK_VALUE:
+							members := cfg.sortedSet(valueType)
+							if cfg.err != nil {
+								return
+							}
+							if expiry == 0 || time.Until(msToTime(expiry)) > 0 {
+								str.SortedSet.Add(key, members, ZAddOptions{})
+								restoreExpiry(str, key, expiry)
+							}
+							expiry = 0
+							key = ""
+							valueType = UNSET
+						case HASHMAP_VALUE, HASHMAP_ZIPLIST_VALUE, HASHMAP_LISTPACK_VALUE:
+							fields := cfg.hash(valueType)
+							if cfg.err != nil {
+								return
+							}
+							if len(fields) > 0 && (expiry == 0 || time.Until(msToTime(expiry)) > 0) {
+								str.Hash.Set(key, fields, false)
+								restoreExpiry(str, key, expiry)
+							}
+							expiry = 0
+							key = ""
+							valueType = UNSET
+						case SET_VALUE, INTSET_VALUE, SET_LISTPACK_VALUE:
+							members := cfg.set(valueType)
+							if cfg.err != nil {
+								return
+							}
+							if len(members) > 0 && (expiry == 0 || time.Until(msToTime(expiry)) > 0) {
+								str.Set.Add(key, members)
+								restoreExpiry(str, key, expiry)
+							}
+							expiry = 0
+							key = ""
+							valueType = UNSET
 						default:
 							cfg.err = fmt.Errorf("to be implemented")
 							return
@@ -344,20 +428,18 @@ func (cfg *rdbStore) Restore(str KVStore) {
 				}
 				if key != "" && value != "" {
 					if expiry != 0 {
-						sec := expiry / 1000
-						usec := expiry % 1000
-						expTime := time.Unix(int64(sec), int64(usec)*1_000_000)
+						expTime := msToTime(expiry)
 						if time.Until(
 							expTime,
 						) > 0 {
-							str.Set(
+							str.KV.Set(
 								key,
 								NewToken(BULK_STRING, value),
 								&expTime,
 							)
 						}
 					} else {
-						str.Set(key, NewToken(BULK_STRING, value), nil)
+						str.KV.Set(key, NewToken(BULK_STRING, value), nil)
 					}
 					expiry = 0
 					key = ""
@@ -370,6 +452,416 @@ func (cfg *rdbStore) Restore(str KVStore) {
 
 }
 
+// restoreExpiry applies the expiry read for a collection key, 0 for none
+func restoreExpiry(str dataStores, key string, expiry uint64) {
+	if expiry != 0 {
+		str.Keyspace.Expire(key, msToTime(expiry), ExpireCondition{})
+	}
+}
+
+// hash reads the field, value pairs of a hash in any of its encodings
+func (cfg *rdbStore) hash(valueType int) []KeyValue {
+	var elems []string
+	switch valueType {
+	case HASHMAP_VALUE:
+		size := cfg.length()
+		if cfg.err != nil {
+			return nil
+		}
+		elems = make([]string, 0, 2*size)
+		for range 2 * size {
+			elems = append(elems, cfg.string())
+			if cfg.err != nil {
+				return nil
+			}
+		}
+	case HASHMAP_ZIPLIST_VALUE:
+		elems, cfg.err = ziplistEntries([]byte(cfg.string()))
+	case HASHMAP_LISTPACK_VALUE:
+		elems, cfg.err = listpackEntries([]byte(cfg.string()))
+	}
+	if cfg.err != nil {
+		return nil
+	}
+	if len(elems)%2 != 0 {
+		cfg.err = fmt.Errorf("hash with a field missing its value")
+		return nil
+	}
+	fields := make([]KeyValue, 0, len(elems)/2)
+	for i := 0; i < len(elems); i += 2 {
+		fields = append(fields, KeyValue{Key: elems[i], Value: elems[i+1]})
+	}
+	return fields
+}
+
+// set reads the members of a set in any of its encodings
+func (cfg *rdbStore) set(valueType int) []string {
+	switch valueType {
+	case SET_VALUE:
+		size := cfg.length()
+		if cfg.err != nil {
+			return nil
+		}
+		members := make([]string, 0, size)
+		for range size {
+			members = append(members, cfg.string())
+			if cfg.err != nil {
+				return nil
+			}
+		}
+		return members
+	case INTSET_VALUE:
+		var members []string
+		members, cfg.err = intsetEntries([]byte(cfg.string()))
+		return members
+	default:
+		var members []string
+		members, cfg.err = listpackEntries([]byte(cfg.string()))
+		return members
+	}
+}
+
+// intsetEntries decodes an intset: the size in bytes of every integer and
+// their count as 32 bit little endian, followed by the integers
+func intsetEntries(is []byte) ([]string, error) {
+	if len(is) < 8 {
+		return nil, fmt.Errorf("intset too short")
+	}
+	width := int(binary.LittleEndian.Uint32(is[0:4]))
+	count := int(binary.LittleEndian.Uint32(is[4:8]))
+	if width != 2 && width != 4 && width != 8 {
+		return nil, fmt.Errorf("invalid intset encoding %v", width)
+	}
+	if len(is) < 8+width*count {
+		return nil, fmt.Errorf("intset too short")
+	}
+	entries := make([]string, 0, count)
+	for i := range count {
+		start := 8 + i*width
+		entries = append(entries, strconv.FormatInt(littleEndianInt(is[start:start+width]), 10))
+	}
+	return entries, nil
+}
+
+// lzfDecompress inflates data compressed with LZF to a size of length
+func lzfDecompress(data []byte, length int) ([]byte, error) {
+	out := make([]byte, 0, length)
+	for i := 0; i < len(data); {
+		ctrl := int(data[i])
+		i++
+		if ctrl < 32 {
+			// Literal run of ctrl + 1 bytes
+			if i+ctrl+1 > len(data) {
+				return nil, fmt.Errorf("lzf: literal run out of bounds")
+			}
+			out = append(out, data[i:i+ctrl+1]...)
+			i += ctrl + 1
+			continue
+		}
+		// Back reference
+		size := ctrl >> 5
+		if size == 7 {
+			if i >= len(data) {
+				return nil, fmt.Errorf("lzf: truncated back reference")
+			}
+			size += int(data[i])
+			i++
+		}
+		if i >= len(data) {
+			return nil, fmt.Errorf("lzf: truncated back reference")
+		}
+		ref := len(out) - (ctrl&0x1f)<<8 - int(data[i]) - 1
+		i++
+		if ref < 0 {
+			return nil, fmt.Errorf("lzf: back reference out of bounds")
+		}
+		// Byte by byte, the reference may overlap what is being written
+		for j := range size + 2 {
+			out = append(out, out[ref+j])
+		}
+	}
+	if len(out) != length {
+		return nil, fmt.Errorf("lzf: expected %v bytes, got %v", length, len(out))
+	}
+	return out, nil
+}
+
+// ziplistEntries decodes the entries of a ziplist, integers are returned in
+// their string form
+func ziplistEntries(zl []byte) ([]string, error) {
+	// zlbytes, zltail and zllen come first
+	if len(zl) < 11 {
+		return nil, fmt.Errorf("ziplist: too short")
+	}
+	entries := make([]string, 0, binary.LittleEndian.Uint16(zl[8:10]))
+	errTruncated := fmt.Errorf("ziplist: truncated entry")
+	i := 10
+	for i < len(zl) && zl[i] != EOF {
+		// Length of the previous entry, 1 or 5 bytes
+		if zl[i] == 0xFE {
+			i += 5
+		} else {
+			i++
+		}
+		if i >= len(zl) {
+			return nil, errTruncated
+		}
+		enc := zl[i]
+		var strLen, headerLen int
+		switch enc >> 6 {
+		case 0b00:
+			strLen, headerLen = int(enc&0x3F), 1
+		case 0b01:
+			if i+2 > len(zl) {
+				return nil, errTruncated
+			}
+			strLen, headerLen = int(enc&0x3F)<<8|int(zl[i+1]), 2
+		case 0b10:
+			if i+5 > len(zl) {
+				return nil, errTruncated
+			}
+			strLen, headerLen = int(binary.BigEndian.Uint32(zl[i+1:i+5])), 5
+		default:
+			var num int64
+			var size int
+			switch {
+			case enc == 0xC0:
+				size = 2
+			case enc == 0xD0:
+				size = 4
+			case enc == 0xE0:
+				size = 8
+			case enc == 0xF0:
+				size = 3
+			case enc == 0xFE:
+				size = 1
+			case enc >= 0xF1 && enc <= 0xFD:
+				// Immediate 4 bit value between 0 and 12
+				num = int64(enc&0x0F) - 1
+			default:
+				return nil, fmt.Errorf("ziplist: invalid encoding %x", enc)
+			}
+			i++
+			if i+size > len(zl) {
+				return nil, errTruncated
+			}
+			if size > 0 {
+				num = littleEndianInt(zl[i : i+size])
+			}
+			entries = append(entries, strconv.FormatInt(num, 10))
+			i += size
+			continue
+		}
+		i += headerLen
+		if i+strLen > len(zl) {
+			return nil, errTruncated
+		}
+		entries = append(entries, string(zl[i:i+strLen]))
+		i += strLen
+	}
+	return entries, nil
+}
+
+// listpackEntries decodes the entries of a listpack, integers are returned
+// in their string form
+func listpackEntries(lp []byte) ([]string, error) {
+	// Total bytes and number of elements come first
+	if len(lp) < 7 {
+		return nil, fmt.Errorf("listpack: too short")
+	}
+	entries := make([]string, 0, binary.LittleEndian.Uint16(lp[4:6]))
+	errTruncated := fmt.Errorf("listpack: truncated entry")
+	i := 6
+	for i < len(lp) && lp[i] != EOF {
+		enc := lp[i]
+		var entryLen int
+		switch {
+		case enc>>7 == 0:
+			// 7 bit unsigned integer
+			entries = append(entries, strconv.Itoa(int(enc)))
+			entryLen = 1
+		case enc>>6 == 0b10:
+			// String up to 63 bytes
+			strLen := int(enc & 0x3F)
+			if i+1+strLen > len(lp) {
+				return nil, errTruncated
+			}
+			entries = append(entries, string(lp[i+1:i+1+strLen]))
+			entryLen = 1 + strLen
+		case enc>>5 == 0b110:
+			// 13 bit signed integer
+			if i+2 > len(lp) {
+				return nil, errTruncated
+			}
+			num := int(enc&0x1F)<<8 | int(lp[i+1])
+			if num >= 1<<12 {
+				num -= 1 << 13
+			}
+			entries = append(entries, strconv.Itoa(num))
+			entryLen = 2
+		case enc>>4 == 0b1110:
+			// String up to 4095 bytes
+			if i+2 > len(lp) {
+				return nil, errTruncated
+			}
+			strLen := int(enc&0x0F)<<8 | int(lp[i+1])
+			if i+2+strLen > len(lp) {
+				return nil, errTruncated
+			}
+			entries = append(entries, string(lp[i+2:i+2+strLen]))
+			entryLen = 2 + strLen
+		case enc == 0xF0:
+			// String with a 32 bit length
+			if i+5 > len(lp) {
+				return nil, errTruncated
+			}
+			strLen := int(binary.LittleEndian.Uint32(lp[i+1 : i+5]))
+			if i+5+strLen > len(lp) {
+				return nil, errTruncated
+			}
+			entries = append(entries, string(lp[i+5:i+5+strLen]))
+			entryLen = 5 + strLen
+		case enc >= 0xF1 && enc <= 0xF4:
+			// 16, 24, 32 and 64 bit signed integers
+			size := []int{2, 3, 4, 8}[enc-0xF1]
+			if i+1+size > len(lp) {
+				return nil, errTruncated
+			}
+			entries = append(entries, strconv.FormatInt(littleEndianInt(lp[i+1:i+1+size]), 10))
+			entryLen = 1 + size
+		default:
+			return nil, fmt.Errorf("listpack: invalid encoding %x", enc)
+		}
+		// Skip the entry and its back length
+		i += entryLen
+		switch {
+		case entryLen <= 127:
+			i++
+		case entryLen < 16383:
+			i += 2
+		case entryLen < 2097151:
+			i += 3
+		case entryLen < 268435455:
+			i += 4
+		default:
+			i += 5
+		}
+	}
+	return entries, nil
+}
+
+// littleEndianInt reads a signed little endian integer of 1 to 8 bytes
+func littleEndianInt(b []byte) int64 {
+	var num uint64
+	for i := len(b) - 1; i >= 0; i-- {
+		num = num<<8 | uint64(b[i])
+	}
+	// Sign extension
+	shift := 64 - 8*len(b)
+	return int64(num<<shift) >> shift
+}
+
+func (cfg *rdbStore) sortedSet(valueType int) []ZMember {
+	switch valueType {
+	case SORTED_SET_ZIPLIST_VALUE, SORTED_SET_LISTPACK_VALUE:
+		return cfg.packedSortedSet(valueType)
+	}
+	size := cfg.length()
+	if cfg.err != nil {
+		return nil
+	}
+	members := make([]ZMember, 0, size)
+	for range size {
+		member := cfg.string()
+		if cfg.err != nil {
+			return nil
+		}
+		var score float64
+		if valueType == SORTED_SET_2_VALUE {
+			score = cfg.binaryDouble()
+		} else {
+			score = cfg.stringDouble()
+		}
+		if cfg.err != nil {
+			return nil
+		}
+		members = append(members, ZMember{Member: member, Score: score})
+	}
+	return members
+}
+
+// packedSortedSet reads a small zset stored as a ziplist or listpack, where
+// every member is followed by its score written as a string or an integer
+func (cfg *rdbStore) packedSortedSet(valueType int) []ZMember {
+	var elems []string
+	if valueType == SORTED_SET_ZIPLIST_VALUE {
+		elems, cfg.err = ziplistEntries([]byte(cfg.string()))
+	} else {
+		elems, cfg.err = listpackEntries([]byte(cfg.string()))
+	}
+	if cfg.err != nil {
+		return nil
+	}
+	if len(elems)%2 != 0 {
+		cfg.err = fmt.Errorf("zset with a member missing its score")
+		return nil
+	}
+	members := make([]ZMember, 0, len(elems)/2)
+	for i := 0; i < len(elems); i += 2 {
+		score, err := strconv.ParseFloat(elems[i+1], 64)
+		if err != nil {
+			cfg.err = fmt.Errorf("invalid zset score %q: %w", elems[i+1], err)
+			return nil
+		}
+		members = append(members, ZMember{Member: elems[i], Score: score})
+	}
+	return members
+}
+
+// Scores of SORTED_SET_VALUE are stored as length prefixed strings, where
+// 253, 254 and 255 stand for NaN, +inf and -inf respectively
+func (cfg *rdbStore) stringDouble() float64 {
+	size, err := cfg.reader.ReadByte()
+	if err != nil {
+		cfg.err = err
+		return 0
+	}
+	switch size {
+	case 253:
+		return math.NaN()
+	case 254:
+		return math.Inf(1)
+	case 255:
+		return math.Inf(-1)
+	}
+	buff := make([]byte, size)
+	_, err = io.ReadFull(cfg.reader, buff)
+	if err != nil {
+		cfg.err = err
+		return 0
+	}
+	score, err := strconv.ParseFloat(string(buff), 64)
+	if err != nil {
+		cfg.err = err
+		return 0
+	}
+	return score
+}
+
+func (cfg *rdbStore) binaryDouble() float64 {
+	buff := make([]byte, 8)
+	_, err := io.ReadFull(cfg.reader, buff)
+	if err != nil {
+		cfg.err = err
+		return 0
+	}
+	return math.Float64frombits(binary.LittleEndian.Uint64(buff))
+}
+
+func msToTime(ms uint64) time.Time {
+	return time.Unix(int64(ms/1000), int64(ms%1000)*1_000_000)
+}
+
 func (cfg *rdbStore) GetAuxField(key string) string {
 	return cfg.auxFields[key]
 }
@@ -100,27 +100,31 @@ func (srv *server) StartReplica() {
 		switch token.Type {
 		case ARRAY:
 			tokens := token.Literal.([]Token)
-			exec := NewExec(srv.store, srv.info, srv.rdb)
 			if len(tokens) > 0 {
 				buffLen := uint(math.Min(float64(2), float64(len(tokens))))
 				argsIndex, cmd, err := ParseCmd(tokens[:buffLen]...)
 				if err != nil {
 					continue
 				}
-				switch cmd {
-				case SET, INCR, REPLCONF:
+				if GetGenericSpec(cmd).Propagate || cmd == REPLCONF {
 					req := NewRequest(redisClient, context.TODO())
 					var args []Token
 					if len(tokens) > argsIndex {
 						args = tokens[argsIndex:]
 					}
+					specs, err := ParseSpec(cmd, args...)
+					if err != nil {
+						fmt.Println("invalid command received from master: ", err)
+						continue
+					}
+					req.SetSpecs(specs)
 					req.SetArgs(args...)
-					out := exec.Exec(req)
+					out := srv.hub.ExecReplicated(req)
 					if cmd == REPLCONF {
 						conn.Write(out.Data())
 					}
-				default:
-					fmt.Println("command process not allowed for command: ")
+				} else {
+					fmt.Println("command process not allowed for command: ", cmd)
 				}
 			}
 		default:
@@ -0,0 +1,177 @@
+package credis
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Upper bound of empty slots visited per requested element, keeps a call
+// cheap when the index is mostly holes
+const SCAN_EMPTY_VISITS_PER_COUNT = 10
+
+// ScanOptions holds the MATCH, COUNT, TYPE and NOVALUES options of the SCAN
+// family
+type ScanOptions struct {
+	Pattern  string
+	Count    int64
+	Type     string
+	NoValues bool
+}
+
+func (opts *ScanOptions) matches(member string) bool {
+	return opts.Pattern == "" || globMatch(opts.Pattern, member, false)
+}
+
+// scanIndex gives every member of a collection a stable slot, so the
+// collection can be walked with a cursor while it changes between calls.
+// Members present for the whole walk are returned exactly once, members
+// added or removed meanwhile may or may not be returned
+type scanIndex struct {
+	slots []string
+	used  []bool
+	pos   map[string]int
+	free  []int
+}
+
+func newScanIndex() *scanIndex {
+	return &scanIndex{
+		pos: make(map[string]int),
+	}
+}
+
+func (si *scanIndex) add(member string) {
+	if _, exists := si.pos[member]; exists {
+		return
+	}
+	var slot int
+	if n := len(si.free); n > 0 {
+		slot = si.free[n-1]
+		si.free = si.free[:n-1]
+		si.slots[slot], si.used[slot] = member, true
+	} else {
+		slot = len(si.slots)
+		si.slots = append(si.slots, member)
+		si.used = append(si.used, true)
+	}
+	si.pos[member] = slot
+}
+
+func (si *scanIndex) remove(member string) {
+	slot, exists := si.pos[member]
+	if !exists {
+		return
+	}
+	delete(si.pos, member)
+	if len(si.pos) == 0 {
+		// Nothing can be skipped by starting over
+		si.slots, si.used, si.free = nil, nil, nil
+		return
+	}
+	si.slots[slot], si.used[slot] = "", false
+	si.free = append(si.free, slot)
+}
+
+// scan calls fn for up to count members starting at cursor and returns the
+// cursor to continue from, 0 once the walk is complete
+func (si *scanIndex) scan(cursor uint64, count int64, fn func(member string)) uint64 {
+	if count < 1 {
+		count = 1
+	}
+	visited, empty := int64(0), int64(0)
+	i := cursor
+	for ; i < uint64(len(si.slots)) && visited < count; i++ {
+		if !si.used[i] {
+			if empty++; empty >= count*SCAN_EMPTY_VISITS_PER_COUNT {
+				i++
+				break
+			}
+			continue
+		}
+		visited++
+		fn(si.slots[i])
+	}
+	if i >= uint64(len(si.slots)) {
+		return 0
+	}
+	return i
+}
+
+// globMatch reports whether str matches the glob pattern, using the same
+// rules as redis: *, ?, [abc], [^abc], [a-z] and \ to escape
+func globMatch(pattern string, str string, nocase bool) bool {
+	p, s := 0, 0
+	fold := func(c byte) byte {
+		if nocase {
+			return byte(unicode.ToLower(rune(c)))
+		}
+		return c
+	}
+	for p < len(pattern) && s < len(str) {
+		switch pattern[p] {
+		case '*':
+			for p+1 < len(pattern) && pattern[p+1] == '*' {
+				p++
+			}
+			if p+1 == len(pattern) {
+				return true
+			}
+			for i := s; i < len(str); i++ {
+				if globMatch(pattern[p+1:], str[i:], nocase) {
+					return true
+				}
+			}
+			return false
+		case '?':
+		case '[':
+			p++
+			not := p < len(pattern) && pattern[p] == '^'
+			if not {
+				p++
+			}
+			match := false
+			for p < len(pattern) && pattern[p] != ']' {
+				switch {
+				case pattern[p] == '\\' && p+1 < len(pattern):
+					p++
+					if pattern[p] == str[s] {
+						match = true
+					}
+				case p+2 < len(pattern) && pattern[p+1] == '-':
+					start, end := fold(pattern[p]), fold(pattern[p+2])
+					if start > end {
+						start, end = end, start
+					}
+					if c := fold(str[s]); c >= start && c <= end {
+						match = true
+					}
+					p += 2
+				default:
+					if fold(pattern[p]) == fold(str[s]) {
+						match = true
+					}
+				}
+				p++
+			}
+			// An unterminated class ends with the pattern
+			if p == len(pattern) {
+				p--
+			}
+			if match == not {
+				return false
+			}
+		case '\\':
+			if p+1 < len(pattern) {
+				p++
+			}
+			fallthrough
+		default:
+			if fold(pattern[p]) != fold(str[s]) {
+				return false
+			}
+		}
+		p++
+		s++
+	}
+	// Trailing stars match the empty string
+	return s == len(str) && strings.Trim(pattern[p:], "*") == ""
+}
@@ -0,0 +1,54 @@
+package credis
+
+import "testing"
+
+func TestGlobMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		str     string
+		nocase  bool
+		want    bool
+	}{
+		{"", "", false, true},
+		{"", "a", false, false},
+		{"*", "", false, true},
+		{"*", "anything", false, true},
+		{"**", "", false, true},
+		{"*a", "", false, false},
+		{"*a", "bba", false, true},
+		{"a*b*c", "aXXbYYc", false, true},
+		{"a*b*c", "aXXbYY", false, false},
+		{"h?llo", "hello", false, true},
+		{"h?llo", "hllo", false, false},
+		{"h[ae]llo", "hallo", false, true},
+		{"h[ae]llo", "hillo", false, false},
+		{"h[^e]llo", "hallo", false, true},
+		{"h[^e]llo", "hello", false, false},
+		{"[^a]", "b", false, true},
+		{"[^a]", "a", false, false},
+		{"h[a-c]llo", "hbllo", false, true},
+		{"h[a-c]llo", "hdllo", false, false},
+		// Reversed ranges are swapped
+		{"[z-a]", "m", false, true},
+		// Escaped characters match themselves
+		{`\*`, "*", false, true},
+		{`\*`, "a", false, false},
+		{`\?`, "?", false, true},
+		{`[\]]`, "]", false, true},
+		// A trailing backslash is taken literally
+		{`a\`, `a\`, false, true},
+		// Unterminated classes end with the pattern, "-" is then literal
+		{"[a-", "a", false, true},
+		{"[a-", "-", false, true},
+		{"[a-", "b", false, false},
+		{"[abc", "c", false, true},
+		{"HELLO", "hello", false, false},
+		{"HELLO", "hello", true, true},
+		{"[A-C]x", "bx", true, true},
+	}
+	for _, tt := range tests {
+		if got := globMatch(tt.pattern, tt.str, tt.nocase); got != tt.want {
+			t.Errorf("globMatch(%q, %q, %v) = %v, want %v", tt.pattern, tt.str, tt.nocase, got, tt.want)
+		}
+	}
+}
@@ -84,9 +84,15 @@ type Server interface {
 }
 
 type dataStores struct {
-	KV     KVStore
-	Stream Stream
-	List   ListStore[string]
+	Keyspace  Keyspace
+	KV        KVStore
+	Stream    Stream
+	List      ListStore[string]
+	SortedSet SortedSet
+	Hash      HashStore
+	Set       SetStore
+	HLL       HyperLogLog
+	Waiting   *WaitingArea
 }
 
 type server struct {
@@ -111,9 +117,10 @@ func New(hub Hub, opts ...ConfigOption) Server {
 	for _, opt := range opts {
 		opt(&cfg)
 	}
+	ks := NewKeyspace()
 	srv := &server{
 		store: dataStores{
-			NewStore(), NewStream(), NewListStore[string](),
+			ks, NewStore(ks), NewStream(ks), NewListStore[string](ks), NewSortedSet(ks), NewHashStore(ks), NewSetStore(ks), NewHyperLogLog(ks), NewWaitingArea(ks),
 		},
 		hub:                         hub,
 		host:                        "0.0.0.0",
@@ -139,6 +146,7 @@ func New(hub Hub, opts ...ConfigOption) Server {
 		srv.info.set("replication", "role", "master")
 		srv.info.set("replication", "master_repl_offset", "0")
 		srv.info.set("replication", "master_replid", GenerateString(40))
+		go srv.activeExpire()
 	}
 	if cfg.rdbDir != "" && cfg.rdbFileName != "" {
 		srv.rdb = NewRDB(cfg.rdbDir, cfg.rdbFileName)
@@ -146,7 +154,7 @@ func New(hub Hub, opts ...ConfigOption) Server {
 		if srv.rdb.Error() != nil {
 			fmt.Printf("RDB Restore aborted: %v", srv.rdb.Error().Error())
 		} else {
-			srv.rdb.Restore(srv.store.KV)
+			srv.rdb.Restore(srv.store)
 		}
 	}
 	return srv
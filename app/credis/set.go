@@ -0,0 +1,494 @@
+package credis
+
+import (
+	"math/rand"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// Sets of integers only are kept as a sorted slice up to this size, same as
+// redis set-max-intset-entries
+const SET_MAX_INTSET_ENTRIES = 512
+
+// setObject is an unordered set of strings. While every member is an
+// integer it uses the compact intset encoding and switches to a dict for
+// good once that no longer holds
+type setObject struct {
+	// Sorted members of the intset encoding, nil once converted
+	ints  []int64
+	dict  map[string]struct{}
+	index *scanIndex
+}
+
+func newSetObject() *setObject {
+	return &setObject{
+		ints: []int64{},
+	}
+}
+
+func (set *setObject) isIntset() bool {
+	return set.dict == nil
+}
+
+func (set *setObject) len() int {
+	if set.isIntset() {
+		return len(set.ints)
+	}
+	return len(set.dict)
+}
+
+func (set *setObject) convert() {
+	set.dict = make(map[string]struct{}, len(set.ints))
+	set.index = newScanIndex()
+	for _, n := range set.ints {
+		member := strconv.FormatInt(n, 10)
+		set.dict[member] = struct{}{}
+		set.index.add(member)
+	}
+	set.ints = nil
+}
+
+// add reports whether member is new
+func (set *setObject) add(member string) bool {
+	if set.isIntset() {
+		if n, ok := parseInteger(member); ok {
+			i, found := slices.BinarySearch(set.ints, n)
+			if found {
+				return false
+			}
+			if len(set.ints) < SET_MAX_INTSET_ENTRIES {
+				set.ints = slices.Insert(set.ints, i, n)
+				return true
+			}
+		}
+		set.convert()
+	}
+	if _, exists := set.dict[member]; exists {
+		return false
+	}
+	set.dict[member] = struct{}{}
+	set.index.add(member)
+	return true
+}
+
+func (set *setObject) remove(member string) bool {
+	if set.isIntset() {
+		n, ok := parseInteger(member)
+		if !ok {
+			return false
+		}
+		i, found := slices.BinarySearch(set.ints, n)
+		if found {
+			set.ints = slices.Delete(set.ints, i, i+1)
+		}
+		return found
+	}
+	if _, exists := set.dict[member]; !exists {
+		return false
+	}
+	delete(set.dict, member)
+	set.index.remove(member)
+	return true
+}
+
+func (set *setObject) has(member string) bool {
+	if set.isIntset() {
+		n, ok := parseInteger(member)
+		if !ok {
+			return false
+		}
+		_, found := slices.BinarySearch(set.ints, n)
+		return found
+	}
+	_, exists := set.dict[member]
+	return exists
+}
+
+func (set *setObject) members() []string {
+	members := make([]string, 0, set.len())
+	if set.isIntset() {
+		for _, n := range set.ints {
+			members = append(members, strconv.FormatInt(n, 10))
+		}
+		return members
+	}
+	for member := range set.dict {
+		members = append(members, member)
+	}
+	return members
+}
+
+// random returns a member picked uniformly, the set must not be empty
+func (set *setObject) random() string {
+	if set.isIntset() {
+		return strconv.FormatInt(set.ints[rand.Intn(len(set.ints))], 10)
+	}
+	// Slots of the scan index are probed so that every member is equally
+	// likely. Removed members leave holes, probing takes len(slots)/len(dict)
+	// tries on average against len(dict)/2 steps to walk the dict, the
+	// cheaper of the two is used
+	if n := len(set.dict); n*n > 2*len(set.index.slots) {
+		for {
+			if i := rand.Intn(len(set.index.slots)); set.index.used[i] {
+				return set.index.slots[i]
+			}
+		}
+	}
+	skip := rand.Intn(len(set.dict))
+	for member := range set.dict {
+		if skip == 0 {
+			return member
+		}
+		skip--
+	}
+	return ""
+}
+
+// sample returns count distinct members picked at random, all of them when
+// count is at least the size of the set
+func (set *setObject) sample(count int) []string {
+	size := set.len()
+	if count >= size {
+		return set.members()
+	}
+	// Picking members one at a time until enough are distinct is cheap
+	// unless count is a large share of the set, which is shuffled instead
+	if count*3 <= size {
+		picked := make(map[string]struct{}, count)
+		members := make([]string, 0, count)
+		for len(members) < count {
+			member := set.random()
+			if _, dup := picked[member]; !dup {
+				picked[member] = struct{}{}
+				members = append(members, member)
+			}
+		}
+		return members
+	}
+	members := set.members()
+	// Partial Fisher-Yates shuffle
+	for i := range count {
+		j := i + rand.Intn(len(members)-i)
+		members[i], members[j] = members[j], members[i]
+	}
+	return members[:count]
+}
+
+const (
+	SET_OP_INTER = "inter"
+	SET_OP_UNION = "union"
+	SET_OP_DIFF  = "diff"
+)
+
+type SetStore interface {
+	Add(key string, members []string) (int, error)
+	Remove(key string, members []string) (int, error)
+	Members(key string) ([]string, error)
+	IsMember(key string, members []string) ([]bool, error)
+	Card(key string) (int, error)
+	Pop(key string, count int) ([]string, error)
+	RandMember(key string, count int) ([]string, error)
+	Move(src string, dest string, member string) (bool, error)
+	Scan(key string, cursor uint64, opts ScanOptions) (uint64, []string, error)
+	Combine(op string, keys []string) ([]string, error)
+	CombineStore(op string, dest string, keys []string) (int, error)
+	InterCard(keys []string, limit int) (int, error)
+}
+
+type setStore struct {
+	ks *keyspace
+}
+
+func NewSetStore(ks *keyspace) SetStore {
+	return &setStore{
+		ks: ks,
+	}
+}
+
+// lookup returns the set stored at key, nil when key does not exist.
+// Caller must hold the lock
+func (s *setStore) lookup(key string) (*setObject, error) {
+	val, err := s.ks.lookupType(key, SET_TYPE, time.Now())
+	if val == nil || err != nil {
+		return nil, err
+	}
+	return val.data.(*setObject), nil
+}
+
+// Add returns the number of members that were not already in the set
+func (s *setStore) Add(key string, members []string) (int, error) {
+	s.ks.mu.Lock()
+	defer s.ks.mu.Unlock()
+	set, err := s.lookup(key)
+	if err != nil {
+		return 0, err
+	}
+	if set == nil {
+		set = newSetObject()
+		s.ks.set(key, SET_TYPE, set, nil)
+	}
+	added := 0
+	for _, member := range members {
+		if set.add(member) {
+			added++
+		}
+	}
+	return added, nil
+}
+
+// Remove deletes members and the key along with its last member
+func (s *setStore) Remove(key string, members []string) (int, error) {
+	s.ks.mu.Lock()
+	defer s.ks.mu.Unlock()
+	set, err := s.lookup(key)
+	if set == nil || err != nil {
+		return 0, err
+	}
+	removed := 0
+	for _, member := range members {
+		if set.remove(member) {
+			removed++
+		}
+	}
+	if set.len() == 0 {
+		s.ks.remove(key)
+	}
+	return removed, nil
+}
+
+func (s *setStore) Members(key string) ([]string, error) {
+	s.ks.mu.RLock()
+	defer s.ks.mu.RUnlock()
+	set, err := s.lookup(key)
+	if set == nil || err != nil {
+		return []string{}, err
+	}
+	return set.members(), nil
+}
+
+func (s *setStore) IsMember(key string, members []string) ([]bool, error) {
+	s.ks.mu.RLock()
+	defer s.ks.mu.RUnlock()
+	found := make([]bool, len(members))
+	set, err := s.lookup(key)
+	if set == nil || err != nil {
+		return found, err
+	}
+	for i, member := range members {
+		found[i] = set.has(member)
+	}
+	return found, nil
+}
+
+func (s *setStore) Card(key string) (int, error) {
+	s.ks.mu.RLock()
+	defer s.ks.mu.RUnlock()
+	set, err := s.lookup(key)
+	if set == nil || err != nil {
+		return 0, err
+	}
+	return set.len(), nil
+}
+
+// Pop removes and returns up to count random members
+func (s *setStore) Pop(key string, count int) ([]string, error) {
+	s.ks.mu.Lock()
+	defer s.ks.mu.Unlock()
+	set, err := s.lookup(key)
+	if set == nil || err != nil {
+		return []string{}, err
+	}
+	popped := set.sample(count)
+	if len(popped) == set.len() {
+		s.ks.remove(key)
+		return popped, nil
+	}
+	for _, member := range popped {
+		set.remove(member)
+	}
+	return popped, nil
+}
+
+// RandMember returns up to count distinct random members, or exactly -count
+// members that may repeat when count is negative
+func (s *setStore) RandMember(key string, count int) ([]string, error) {
+	s.ks.mu.RLock()
+	defer s.ks.mu.RUnlock()
+	set, err := s.lookup(key)
+	if set == nil || err != nil {
+		return []string{}, err
+	}
+	if count >= 0 {
+		return set.sample(count), nil
+	}
+	members := []string{}
+	for range -count {
+		members = append(members, set.random())
+	}
+	return members, nil
+}
+
+// Move reports whether member was found in src. dest must hold a set, if
+// anything, even when nothing is moved
+func (s *setStore) Move(src string, dest string, member string) (bool, error) {
+	s.ks.mu.Lock()
+	defer s.ks.mu.Unlock()
+	from, err := s.lookup(src)
+	if err != nil {
+		return false, err
+	}
+	to, err := s.lookup(dest)
+	if err != nil {
+		return false, err
+	}
+	if from == nil || !from.has(member) {
+		return false, nil
+	}
+	if src == dest {
+		return true, nil
+	}
+	from.remove(member)
+	if from.len() == 0 {
+		s.ks.remove(src)
+	}
+	if to == nil {
+		to = newSetObject()
+		s.ks.set(dest, SET_TYPE, to, nil)
+	}
+	to.add(member)
+	return true, nil
+}
+
+// Scan returns every member at once for the intset encoding, which is
+// small by construction
+func (s *setStore) Scan(key string, cursor uint64, opts ScanOptions) (uint64, []string, error) {
+	s.ks.mu.RLock()
+	defer s.ks.mu.RUnlock()
+	members := []string{}
+	set, err := s.lookup(key)
+	if set == nil || err != nil {
+		return 0, members, err
+	}
+	if set.isIntset() {
+		for _, member := range set.members() {
+			if opts.matches(member) {
+				members = append(members, member)
+			}
+		}
+		return 0, members, nil
+	}
+	next := set.index.scan(cursor, opts.Count, func(member string) {
+		if opts.matches(member) {
+			members = append(members, member)
+		}
+	})
+	return next, members, nil
+}
+
+// lookupAll returns the sets at keys, missing keys being empty sets. Every
+// key is checked to hold a set before anything is computed. Caller must
+// hold the lock
+func (s *setStore) lookupAll(keys []string) ([]*setObject, error) {
+	sets := make([]*setObject, len(keys))
+	for i, key := range keys {
+		set, err := s.lookup(key)
+		if err != nil {
+			return nil, err
+		}
+		if set == nil {
+			set = newSetObject()
+		}
+		sets[i] = set
+	}
+	return sets, nil
+}
+
+// combine applies op over the sets at keys. Caller must hold the lock
+func (s *setStore) combine(op string, keys []string) (*setObject, error) {
+	sets, err := s.lookupAll(keys)
+	if err != nil {
+		return nil, err
+	}
+	result := newSetObject()
+	switch op {
+	case SET_OP_INTER:
+		intersect(sets, 0, func(member string) {
+			result.add(member)
+		})
+	case SET_OP_UNION:
+		for _, set := range sets {
+			for _, member := range set.members() {
+				result.add(member)
+			}
+		}
+	case SET_OP_DIFF:
+		for _, member := range sets[0].members() {
+			if !slices.ContainsFunc(sets[1:], func(set *setObject) bool { return set.has(member) }) {
+				result.add(member)
+			}
+		}
+	}
+	return result, nil
+}
+
+// intersect calls fn for members found in every set, stopping after limit
+// of them unless limit is 0. Sets are walked from the smallest so that as
+// few members as possible are looked up
+func intersect(sets []*setObject, limit int, fn func(member string)) {
+	sets = slices.Clone(sets)
+	slices.SortFunc(sets, func(a, b *setObject) int {
+		return a.len() - b.len()
+	})
+	found := 0
+	for _, member := range sets[0].members() {
+		if !slices.ContainsFunc(sets[1:], func(set *setObject) bool { return !set.has(member) }) {
+			fn(member)
+			if found++; found == limit {
+				return
+			}
+		}
+	}
+}
+
+func (s *setStore) Combine(op string, keys []string) ([]string, error) {
+	s.ks.mu.RLock()
+	defer s.ks.mu.RUnlock()
+	result, err := s.combine(op, keys)
+	if err != nil {
+		return nil, err
+	}
+	return result.members(), nil
+}
+
+// CombineStore replaces whatever dest holds with the result of op and
+// returns its size, dest is deleted when the result is empty
+func (s *setStore) CombineStore(op string, dest string, keys []string) (int, error) {
+	s.ks.mu.Lock()
+	defer s.ks.mu.Unlock()
+	result, err := s.combine(op, keys)
+	if err != nil {
+		return 0, err
+	}
+	s.ks.remove(dest)
+	if result.len() > 0 {
+		s.ks.set(dest, SET_TYPE, result, nil)
+	}
+	return result.len(), nil
+}
+
+// InterCard returns the size of the intersection, counting at most limit
+// members unless limit is 0
+func (s *setStore) InterCard(keys []string, limit int) (int, error) {
+	s.ks.mu.RLock()
+	defer s.ks.mu.RUnlock()
+	sets, err := s.lookupAll(keys)
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	intersect(sets, limit, func(string) {
+		count++
+	})
+	return count, nil
+}
@@ -2,201 +2,975 @@ package credis
 
 import (
 	"fmt"
-	"sync"
+	"math"
+	"math/rand"
+	"slices"
+	"time"
 )
 
-// const MAX_DEPTH = 32
+const SKIPLIST_MAXLEVEL = 32
 
-type SetNode struct {
-	value string
-	score float64
-	next  *SetNode
-}
+// Probability of a node being promoted to the next express lane
+const SKIPLIST_P = 0.25
 
-type setVal struct {
-	score  float64
-	exists bool
+type skipListLevel struct {
+	forward *SetNode
+	// Number of level 0 nodes jumped over by following forward
+	span int
 }
 
-type SortedSet interface {
-	Rank(key string, value string) int
-	Range(key string, start int64, end int64) []string
-	Add(key string, value string, score float64) uint64
-	Cardinality(key string) int
-	Remove(key string, value string) int
-	Get(key string, value string) *string
+type SetNode struct {
+	value    string
+	score    float64
+	backward *SetNode
+	level    []skipListLevel
 }
 
 type skipList struct {
-	mu     sync.RWMutex
-	hasmap map[string]map[string]setVal
-	roots  map[string]*SetNode
-	length uint64
+	header *SetNode
+	tail   *SetNode
+	length int
+	level  int
 }
 
-func NewSortedSet() SortedSet {
+func newSetNode(level int, score float64, value string) *SetNode {
+	return &SetNode{
+		value: value,
+		score: score,
+		level: make([]skipListLevel, level),
+	}
+}
+
+func newSkipList() *skipList {
 	return &skipList{
-		hasmap: make(map[string]map[string]setVal),
-		roots:  make(map[string]*SetNode),
+		header: newSetNode(SKIPLIST_MAXLEVEL, 0, ""),
+		level:  1,
+	}
+}
+
+func randomLevel() int {
+	level := 1
+	for level < SKIPLIST_MAXLEVEL && rand.Float64() < SKIPLIST_P {
+		level++
 	}
+	return level
 }
 
-func (s *skipList) Add(key string, value string, score float64) uint64 {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	if s.hasmap[key] == nil {
-		s.hasmap[key] = make(map[string]setVal)
+// less reports whether node n sorts before the (score, value) pair
+func (n *SetNode) less(score float64, value string) bool {
+	return n.score < score || (n.score == score && n.value < value)
+}
+
+// insert assumes value is not part of the list yet
+func (zsl *skipList) insert(score float64, value string) *SetNode {
+	var update [SKIPLIST_MAXLEVEL]*SetNode
+	var rank [SKIPLIST_MAXLEVEL]int
+	x := zsl.header
+	for i := zsl.level - 1; i >= 0; i-- {
+		if i != zsl.level-1 {
+			rank[i] = rank[i+1]
+		}
+		for x.level[i].forward != nil && x.level[i].forward.less(score, value) {
+			rank[i] += x.level[i].span
+			x = x.level[i].forward
+		}
+		update[i] = x
+	}
+	level := randomLevel()
+	if level > zsl.level {
+		for i := zsl.level; i < level; i++ {
+			rank[i] = 0
+			update[i] = zsl.header
+			update[i].level[i].span = zsl.length
+		}
+		zsl.level = level
+	}
+	x = newSetNode(level, score, value)
+	for i := 0; i < level; i++ {
+		x.level[i].forward = update[i].level[i].forward
+		update[i].level[i].forward = x
+		x.level[i].span = update[i].level[i].span - (rank[0] - rank[i])
+		update[i].level[i].span = (rank[0] - rank[i]) + 1
 	}
-	elemtsAdded := 1
-	if s.hasmap[key][value].exists {
-		elemtsAdded = 0
+	// Untouched lanes now jump over the new node as well
+	for i := level; i < zsl.level; i++ {
+		update[i].level[i].span++
 	}
+	if update[0] != zsl.header {
+		x.backward = update[0]
+	}
+	if x.level[0].forward != nil {
+		x.level[0].forward.backward = x
+	} else {
+		zsl.tail = x
+	}
+	zsl.length++
+	return x
+}
 
-	// TODO: What to do if element is duplicate? remove from list???
-	s.hasmap[key][value] = setVal{
-		score:  score,
-		exists: true,
+func (zsl *skipList) deleteNode(x *SetNode, update *[SKIPLIST_MAXLEVEL]*SetNode) {
+	for i := 0; i < zsl.level; i++ {
+		if update[i].level[i].forward == x {
+			update[i].level[i].span += x.level[i].span - 1
+			update[i].level[i].forward = x.level[i].forward
+		} else {
+			update[i].level[i].span--
+		}
 	}
-	// TODO: Insert into express lanes
-	node := SetNode{
-		value: value,
-		score: score,
+	if x.level[0].forward != nil {
+		x.level[0].forward.backward = x.backward
+	} else {
+		zsl.tail = x.backward
+	}
+	for zsl.level > 1 && zsl.header.level[zsl.level-1].forward == nil {
+		zsl.level--
 	}
-	root := s.roots[key]
-	if root == nil {
-		s.roots[key] = &node
-		root = s.roots[key]
+	zsl.length--
+}
+
+func (zsl *skipList) delete(score float64, value string) bool {
+	var update [SKIPLIST_MAXLEVEL]*SetNode
+	x := zsl.header
+	for i := zsl.level - 1; i >= 0; i-- {
+		for x.level[i].forward != nil && x.level[i].forward.less(score, value) {
+			x = x.level[i].forward
+		}
+		update[i] = x
+	}
+	x = x.level[0].forward
+	if x != nil && x.score == score && x.value == value {
+		zsl.deleteNode(x, &update)
+		return true
+	}
+	return false
+}
+
+// updateScore moves the node in place when the new score keeps its position,
+// otherwise it is unlinked and inserted again
+func (zsl *skipList) updateScore(curScore float64, value string, newScore float64) *SetNode {
+	var update [SKIPLIST_MAXLEVEL]*SetNode
+	x := zsl.header
+	for i := zsl.level - 1; i >= 0; i-- {
+		for x.level[i].forward != nil && x.level[i].forward.less(curScore, value) {
+			x = x.level[i].forward
+		}
+		update[i] = x
+	}
+	x = x.level[0].forward
+	if (x.backward == nil || x.backward.less(newScore, value)) &&
+		(x.level[0].forward == nil || !x.level[0].forward.less(newScore, value)) {
+		x.score = newScore
+		return x
+	}
+	zsl.deleteNode(x, &update)
+	return zsl.insert(newScore, value)
+}
+
+// rank is 1 based, 0 is returned when the element is not found
+func (zsl *skipList) rank(score float64, value string) int {
+	rank := 0
+	x := zsl.header
+	for i := zsl.level - 1; i >= 0; i-- {
+		for x.level[i].forward != nil &&
+			(x.level[i].forward.less(score, value) ||
+				(x.level[i].forward.score == score && x.level[i].forward.value == value)) {
+			rank += x.level[i].span
+			x = x.level[i].forward
+		}
+		if x != zsl.header && x.value == value {
+			return rank
+		}
+	}
+	return 0
+}
+
+// byRank expects a 1 based rank
+func (zsl *skipList) byRank(rank int) *SetNode {
+	traversed := 0
+	x := zsl.header
+	for i := zsl.level - 1; i >= 0; i-- {
+		for x.level[i].forward != nil && traversed+x.level[i].span <= rank {
+			traversed += x.level[i].span
+			x = x.level[i].forward
+		}
+		if traversed == rank {
+			return x
+		}
+	}
+	return nil
+}
+
+// firstFrom returns the first node for which below is false along with its
+// 1 based rank, nil when there is none. below must only hold for nodes at
+// the head of the list
+func (zsl *skipList) firstFrom(below func(*SetNode) bool) (*SetNode, int) {
+	rank := 0
+	x := zsl.header
+	for i := zsl.level - 1; i >= 0; i-- {
+		for x.level[i].forward != nil && below(x.level[i].forward) {
+			rank += x.level[i].span
+			x = x.level[i].forward
+		}
+	}
+	return x.level[0].forward, rank + 1
+}
+
+// lastUpTo returns the last node for which within is true along with its
+// 1 based rank, nil when there is none. within must only hold for nodes at
+// the head of the list
+func (zsl *skipList) lastUpTo(within func(*SetNode) bool) (*SetNode, int) {
+	rank := 0
+	x := zsl.header
+	for i := zsl.level - 1; i >= 0; i-- {
+		for x.level[i].forward != nil && within(x.level[i].forward) {
+			rank += x.level[i].span
+			x = x.level[i].forward
+		}
+	}
+	if x == zsl.header {
+		return nil, 0
+	}
+	return x, rank
+}
+
+// between returns the nodes ranked from first to last, 1 based and both
+// included, starting from last when rev is set
+func (zsl *skipList) between(first int, last int, rev bool) []*SetNode {
+	if first > last {
+		return nil
+	}
+	nodes := make([]*SetNode, 0, last-first+1)
+	if rev {
+		for x := zsl.byRank(last); len(nodes) < cap(nodes); x = x.backward {
+			nodes = append(nodes, x)
+		}
+		return nodes
+	}
+	for x := zsl.byRank(first); len(nodes) < cap(nodes); x = x.level[0].forward {
+		nodes = append(nodes, x)
+	}
+	return nodes
+}
+
+// nodeRange is a range of nodes by score or by member
+type nodeRange interface {
+	aboveMin(n *SetNode) bool
+	belowMax(n *SetNode) bool
+}
+
+// ranks returns the ranks of the first and last nodes within r, first is
+// greater than last when there is none
+func (zsl *skipList) ranks(r nodeRange) (int, int) {
+	first, firstRank := zsl.firstFrom(func(n *SetNode) bool {
+		return !r.aboveMin(n)
+	})
+	last, lastRank := zsl.lastUpTo(r.belowMax)
+	if first == nil || last == nil {
+		return 1, 0
+	}
+	return firstRank, lastRank
+}
+
+// inRange returns the nodes within r after skipping offset of them, at most
+// count of them unless count is negative, starting from the max when rev
+// is set
+func (zsl *skipList) inRange(r nodeRange, rev bool, offset int64, count int64) []*SetNode {
+	first, last := zsl.ranks(r)
+	if offset < 0 || int64(last-first+1) <= offset {
+		return nil
+	}
+	if rev {
+		last -= int(offset)
+		if count >= 0 {
+			first = max(first, last-int(count)+1)
+		}
 	} else {
-		current := root
-		var prev *SetNode
-		for {
-			if score < current.score || (score == current.score && value < current.value) {
-				break
-			} else {
-				prev = current
-			}
-			if current.next == nil {
-				break
+		first += int(offset)
+		if count >= 0 {
+			last = min(last, first+int(count)-1)
+		}
+	}
+	return zsl.between(first, last, rev)
+}
+
+// ScoreRange is a range of scores as given to ZRANGEBYSCORE, either end may
+// be excluded
+type ScoreRange struct {
+	min          float64
+	max          float64
+	minExclusive bool
+	maxExclusive bool
+}
+
+func (r ScoreRange) aboveMin(n *SetNode) bool {
+	if r.minExclusive {
+		return n.score > r.min
+	}
+	return n.score >= r.min
+}
+
+func (r ScoreRange) belowMax(n *SetNode) bool {
+	if r.maxExclusive {
+		return n.score < r.max
+	}
+	return n.score <= r.max
+}
+
+// lexBound is one end of a LexRange, inf is -1 for "-" and 1 for "+" which
+// sort before and after every member
+type lexBound struct {
+	value     string
+	exclusive bool
+	inf       int
+}
+
+// LexRange is a range of members as given to ZRANGEBYLEX, which only makes
+// sense when all members share the same score
+type LexRange struct {
+	min lexBound
+	max lexBound
+}
+
+func (r LexRange) aboveMin(n *SetNode) bool {
+	switch {
+	case r.min.inf != 0:
+		return r.min.inf < 0
+	case r.min.exclusive:
+		return n.value > r.min.value
+	}
+	return n.value >= r.min.value
+}
+
+func (r LexRange) belowMax(n *SetNode) bool {
+	switch {
+	case r.max.inf != 0:
+		return r.max.inf > 0
+	case r.max.exclusive:
+		return n.value < r.max.value
+	}
+	return n.value <= r.max.value
+}
+
+const (
+	ZRANGE_BY_RANK  = "byrank"
+	ZRANGE_BY_SCORE = "byscore"
+	ZRANGE_BY_LEX   = "bylex"
+)
+
+// ZRangeOptions select members of a sorted set as ZRANGE does, by rank,
+// score or member depending on By
+type ZRangeOptions struct {
+	By string
+	// Ranks of ZRANGE_BY_RANK, negative ones count from the end
+	Start  int64
+	Stop   int64
+	Scores ScoreRange
+	Lex    LexRange
+	// Ranks count from the highest score and ranges are walked from the
+	// max when set
+	Rev bool
+	// LIMIT of the score and lex ranges, Count is negative for no limit
+	Offset int64
+	Count  int64
+}
+
+// ZMember is a member of a sorted set along with its score
+type ZMember struct {
+	Member string
+	Score  float64
+}
+
+// formatScore formats a score the way it is replied, infinities included
+func formatScore(score float64) string {
+	switch {
+	case math.IsInf(score, 1):
+		return "inf"
+	case math.IsInf(score, -1):
+		return "-inf"
+	}
+	return fmt.Sprintf("%v", score)
+}
+
+type sortedSet struct {
+	dict  map[string]float64
+	zsl   *skipList
+	index *scanIndex
+}
+
+func newSortedSet() *sortedSet {
+	return &sortedSet{
+		dict:  make(map[string]float64),
+		zsl:   newSkipList(),
+		index: newScanIndex(),
+	}
+}
+
+// insert adds value or updates its score, reports whether value is new
+func (set *sortedSet) insert(value string, score float64) bool {
+	if current, exists := set.dict[value]; exists {
+		if current != score {
+			set.zsl.updateScore(current, value, score)
+			set.dict[value] = score
+		}
+		return false
+	}
+	set.zsl.insert(score, value)
+	set.dict[value] = score
+	set.index.add(value)
+	return true
+}
+
+func (set *sortedSet) delete(value string) bool {
+	score, exists := set.dict[value]
+	if !exists {
+		return false
+	}
+	set.zsl.delete(score, value)
+	delete(set.dict, value)
+	set.index.remove(value)
+	return true
+}
+
+// rangeOf returns the nodes selected by opts
+func (set *sortedSet) rangeOf(opts ZRangeOptions) []*SetNode {
+	switch opts.By {
+	case ZRANGE_BY_SCORE:
+		return set.zsl.inRange(opts.Scores, opts.Rev, opts.Offset, opts.Count)
+	case ZRANGE_BY_LEX:
+		return set.zsl.inRange(opts.Lex, opts.Rev, opts.Offset, opts.Count)
+	}
+	length := set.zsl.length
+	start, end, ok := listRange(opts.Start, opts.Stop, length)
+	if !ok {
+		return nil
+	}
+	if opts.Rev {
+		return set.zsl.between(length-int(end), length-int(start), true)
+	}
+	return set.zsl.between(int(start)+1, int(end)+1, false)
+}
+
+// ZAddOptions are the flags of ZADD. With Incr the score of the single
+// member given is incremented, as ZINCRBY does
+type ZAddOptions struct {
+	NX   bool
+	XX   bool
+	GT   bool
+	LT   bool
+	CH   bool
+	Incr bool
+}
+
+// sample returns count distinct members picked at random, all of them when
+// count is at least the size of the set
+func (set *sortedSet) sample(count int) []*SetNode {
+	length := set.zsl.length
+	if count >= length {
+		return set.zsl.between(1, length, false)
+	}
+	// Random ranks are looked up until enough are distinct, unless count is
+	// a large share of the set, which is shuffled instead
+	if count*3 <= length {
+		picked := make(map[int]struct{}, count)
+		nodes := make([]*SetNode, 0, count)
+		for len(nodes) < count {
+			rank := rand.Intn(length) + 1
+			if _, dup := picked[rank]; !dup {
+				picked[rank] = struct{}{}
+				nodes = append(nodes, set.zsl.byRank(rank))
 			}
-			current = current.next
-		}
-		if prev == nil {
-			if current.score > score || (score == current.score && value < current.value) {
-				node.next = current
-				s.roots[key] = &node
-			} else {
-				s.roots[key].next = &node
+		}
+		return nodes
+	}
+	nodes := set.zsl.between(1, length, false)
+	// Partial Fisher-Yates shuffle
+	for i := range count {
+		j := i + rand.Intn(len(nodes)-i)
+		nodes[i], nodes[j] = nodes[j], nodes[i]
+	}
+	return nodes[:count]
+}
+
+type SortedSet interface {
+	Rank(key string, value string, rev bool) (int, error)
+	Range(key string, opts ZRangeOptions) ([]ZMember, error)
+	RangeStore(dest string, src string, opts ZRangeOptions) (int, error)
+	Combine(op string, keys []string, opts ZCombineOptions) ([]ZMember, error)
+	CombineStore(op string, dest string, keys []string, opts ZCombineOptions) ([]ZMember, error)
+	InterCard(keys []string, limit int) (int, error)
+	Count(key string, r ScoreRange) (int, error)
+	LexCount(key string, r LexRange) (int, error)
+	Add(key string, members []ZMember, opts ZAddOptions) (int, *float64, error)
+	Scores(key string, members []string) ([]*float64, error)
+	Pop(key string, count int, highest bool) ([]ZMember, error)
+	RandMember(key string, count int) ([]ZMember, error)
+	RemoveRange(key string, opts ZRangeOptions) (int, error)
+	Cardinality(key string) (int, error)
+	Remove(key string, value string) (int, error)
+	Get(key string, value string) (*string, error)
+	Scan(key string, cursor uint64, opts ScanOptions) (uint64, []string, error)
+}
+
+type sortedSetStore struct {
+	ks *keyspace
+}
+
+func NewSortedSet(ks *keyspace) SortedSet {
+	return &sortedSetStore{
+		ks: ks,
+	}
+}
+
+// lookup returns the sorted set stored at key, nil when key does not exist.
+// Caller must hold the lock
+func (s *sortedSetStore) lookup(key string) (*sortedSet, error) {
+	val, err := s.ks.lookupType(key, ZSET_TYPE, time.Now())
+	if val == nil || err != nil {
+		return nil, err
+	}
+	return val.data.(*sortedSet), nil
+}
+
+// Add returns the number of members added, or changed as well with CH. With
+// Incr the new score is returned, nil when the flags prevented the update
+func (s *sortedSetStore) Add(key string, members []ZMember, opts ZAddOptions) (int, *float64, error) {
+	s.ks.mu.Lock()
+	defer s.ks.mu.Unlock()
+	set, err := s.lookup(key)
+	if err != nil {
+		return 0, nil, err
+	}
+	if set == nil {
+		if opts.XX {
+			return 0, nil, nil
+		}
+		set = newSortedSet()
+		s.ks.set(key, ZSET_TYPE, set, nil)
+	}
+	changed, added := 0, 0
+	var updated *float64
+	for _, m := range members {
+		score := m.Score
+		current, exists := set.dict[m.Member]
+		if opts.Incr && exists {
+			score += current
+			if math.IsNaN(score) {
+				return 0, nil, fmt.Errorf("ERR resulting score is not a number (NaN)")
 			}
-		} else {
-			temp := prev.next
-			node.next = temp
-			prev.next = &node
 		}
+		if exists && (opts.NX || opts.GT && score <= current || opts.LT && score >= current) || !exists && opts.XX {
+			continue
+		}
+		set.insert(m.Member, score)
+		if !exists {
+			added++
+		}
+		if !exists || opts.CH && score != current {
+			changed++
+		}
+		updated = &score
+	}
+	// Only new members can serve the clients blocked on the key
+	if added > 0 {
+		s.ks.signalReady(key)
 	}
-	s.length++
-	return uint64(elemtsAdded)
+	return changed, updated, nil
 }
 
-func (s *skipList) Rank(key string, value string) int {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-	rank := -1
-	root := s.roots[key]
-	if root == nil {
-		return rank
+// Scores returns the score of each member, nil for those not found
+func (s *sortedSetStore) Scores(key string, members []string) ([]*float64, error) {
+	s.ks.mu.RLock()
+	defer s.ks.mu.RUnlock()
+	scores := make([]*float64, len(members))
+	set, err := s.lookup(key)
+	if set == nil || err != nil {
+		return scores, err
 	}
-	current := root
-	found := false
-	for current != nil {
-		rank++
-		if current.value == value {
-			found = true
-			break
+	for i, member := range members {
+		if score, exists := set.dict[member]; exists {
+			scores[i] = &score
 		}
-		current = current.next
 	}
-	if !found {
-		rank = -1
+	return scores, nil
+}
+
+// Pop removes and returns up to count members with the lowest scores, the
+// highest ones first when highest is set
+func (s *sortedSetStore) Pop(key string, count int, highest bool) ([]ZMember, error) {
+	s.ks.mu.Lock()
+	defer s.ks.mu.Unlock()
+	popped := []ZMember{}
+	set, err := s.lookup(key)
+	if set == nil || err != nil {
+		return popped, err
+	}
+	length := set.zsl.length
+	nodes := set.zsl.between(1, min(count, length), false)
+	if highest {
+		nodes = set.zsl.between(length-min(count, length)+1, length, true)
+	}
+	for _, node := range nodes {
+		popped = append(popped, ZMember{Member: node.value, Score: node.score})
 	}
-	return rank
+	for _, m := range popped {
+		set.delete(m.Member)
+	}
+	if len(set.dict) == 0 {
+		s.ks.remove(key)
+	}
+	return popped, nil
 }
 
-func (s *skipList) Range(key string, start int64, end int64) []string {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-	elems := []string{}
-	root := s.roots[key]
-	if root == nil {
-		return elems
+// RandMember returns up to count distinct random members, or exactly -count
+// members that may repeat when count is negative
+func (s *sortedSetStore) RandMember(key string, count int) ([]ZMember, error) {
+	s.ks.mu.RLock()
+	defer s.ks.mu.RUnlock()
+	members := []ZMember{}
+	set, err := s.lookup(key)
+	if set == nil || err != nil {
+		return members, err
 	}
-	startInd := start
-	endInd := end
-	lastInd := int64(len(s.hasmap[key]))
-	if startInd < 0 {
-		startInd = lastInd + startInd
+	if count >= 0 {
+		for _, node := range set.sample(count) {
+			members = append(members, ZMember{Member: node.value, Score: node.score})
+		}
+		return members, nil
+	}
+	for range -count {
+		node := set.zsl.byRank(rand.Intn(set.zsl.length) + 1)
+		members = append(members, ZMember{Member: node.value, Score: node.score})
+	}
+	return members, nil
+}
+
+// RemoveRange deletes the members selected by opts and returns their number
+func (s *sortedSetStore) RemoveRange(key string, opts ZRangeOptions) (int, error) {
+	s.ks.mu.Lock()
+	defer s.ks.mu.Unlock()
+	set, err := s.lookup(key)
+	if set == nil || err != nil {
+		return 0, err
+	}
+	nodes := set.rangeOf(opts)
+	for _, node := range nodes {
+		set.delete(node.value)
+	}
+	if len(set.dict) == 0 {
+		s.ks.remove(key)
 	}
-	if endInd < 0 {
-		endInd = lastInd + endInd
+	return len(nodes), nil
+}
+
+// Rank is 0 based and counts from the highest score when rev is set, -1
+// is returned when value is not found
+func (s *sortedSetStore) Rank(key string, value string, rev bool) (int, error) {
+	s.ks.mu.RLock()
+	defer s.ks.mu.RUnlock()
+	set, err := s.lookup(key)
+	if set == nil || err != nil {
+		return -1, err
+	}
+	score, exists := set.dict[value]
+	if !exists {
+		return -1, nil
+	}
+	rank := set.zsl.rank(score, value)
+	if rev {
+		return set.zsl.length - rank, nil
+	}
+	return rank - 1, nil
+}
+
+func (s *sortedSetStore) Range(key string, opts ZRangeOptions) ([]ZMember, error) {
+	s.ks.mu.RLock()
+	defer s.ks.mu.RUnlock()
+	members := []ZMember{}
+	set, err := s.lookup(key)
+	if set == nil || err != nil {
+		return members, err
 	}
-	if endInd > lastInd {
-		endInd = lastInd - 1
+	for _, node := range set.rangeOf(opts) {
+		members = append(members, ZMember{Member: node.value, Score: node.score})
 	}
-	if startInd < 0 {
-		startInd = 0
+	return members, nil
+}
+
+// RangeStore replaces whatever dest holds with the members of src selected
+// by opts and returns their number, dest is deleted when there is none
+func (s *sortedSetStore) RangeStore(dest string, src string, opts ZRangeOptions) (int, error) {
+	s.ks.mu.Lock()
+	defer s.ks.mu.Unlock()
+	set, err := s.lookup(src)
+	if err != nil {
+		return 0, err
 	}
-	current := root
-	currentInd := 0
-	for {
-		if currentInd >= int(startInd) && currentInd <= int(endInd) {
-			elems = append(elems, current.value)
+	result := newSortedSet()
+	if set != nil {
+		for _, node := range set.rangeOf(opts) {
+			result.insert(node.value, node.score)
 		}
-		if currentInd > int(endInd) || current.next == nil {
-			break
+	}
+	s.ks.remove(dest)
+	if len(result.dict) > 0 {
+		s.ks.set(dest, ZSET_TYPE, result, nil)
+		s.ks.signalReady(dest)
+	}
+	return len(result.dict), nil
+}
+
+const (
+	ZAGGREGATE_SUM = "sum"
+	ZAGGREGATE_MIN = "min"
+	ZAGGREGATE_MAX = "max"
+)
+
+// ZCombineOptions are the WEIGHTS and AGGREGATE options of ZUNION, ZINTER
+// and their STORE forms. Weights has one weight per key, nil for all 1
+type ZCombineOptions struct {
+	Weights   []float64
+	Aggregate string
+}
+
+// weigh multiplies score by the weight of the i-th key, 0 * inf being 0
+func (opts ZCombineOptions) weigh(i int, score float64) float64 {
+	if opts.Weights == nil {
+		return score
+	}
+	if weighted := score * opts.Weights[i]; !math.IsNaN(weighted) {
+		return weighted
+	}
+	return 0
+}
+
+// aggregate combines the scores a member has in two of the keys, inf - inf
+// being 0
+func (opts ZCombineOptions) aggregate(a float64, b float64) float64 {
+	switch opts.Aggregate {
+	case ZAGGREGATE_MIN:
+		return min(a, b)
+	case ZAGGREGATE_MAX:
+		return max(a, b)
+	}
+	if sum := a + b; !math.IsNaN(sum) {
+		return sum
+	}
+	return 0
+}
+
+// scoresAll returns the scores of the members at each of keys, members of
+// plain sets scoring 1 and missing keys being empty. Every key is checked to
+// hold a set or sorted set before anything is computed. Caller must hold
+// the lock
+func (s *sortedSetStore) scoresAll(keys []string) ([]map[string]float64, error) {
+	all := make([]map[string]float64, len(keys))
+	now := time.Now()
+	for i, key := range keys {
+		val := s.ks.lookup(key, now)
+		switch {
+		case val == nil:
+			all[i] = map[string]float64{}
+		case val.typ == ZSET_TYPE:
+			all[i] = val.data.(*sortedSet).dict
+		case val.typ == SET_TYPE:
+			members := val.data.(*setObject).members()
+			all[i] = make(map[string]float64, len(members))
+			for _, member := range members {
+				all[i][member] = 1
+			}
+		default:
+			return nil, &ErrWrongType{}
 		}
-		current = current.next
-		currentInd++
 	}
-	return elems
+	return all, nil
 }
 
-func (s *skipList) Cardinality(key string) int {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-	return len(s.hasmap[key])
+// combine applies op over the sets and sorted sets at keys. Weights and
+// the aggregate do not apply to SET_OP_DIFF, which keeps the scores of the
+// first key. Caller must hold the lock
+func (s *sortedSetStore) combine(op string, keys []string, opts ZCombineOptions) (*sortedSet, error) {
+	all, err := s.scoresAll(keys)
+	if err != nil {
+		return nil, err
+	}
+	result := newSortedSet()
+	switch op {
+	case SET_OP_INTER:
+	members:
+		for member, score := range all[0] {
+			score = opts.weigh(0, score)
+			for i, scores := range all[1:] {
+				other, exists := scores[member]
+				if !exists {
+					continue members
+				}
+				score = opts.aggregate(score, opts.weigh(i+1, other))
+			}
+			result.insert(member, score)
+		}
+	case SET_OP_UNION:
+		for i, scores := range all {
+			for member, score := range scores {
+				score = opts.weigh(i, score)
+				if current, exists := result.dict[member]; exists {
+					score = opts.aggregate(current, score)
+				}
+				result.insert(member, score)
+			}
+		}
+	case SET_OP_DIFF:
+		for member, score := range all[0] {
+			if !slices.ContainsFunc(all[1:], func(scores map[string]float64) bool {
+				_, exists := scores[member]
+				return exists
+			}) {
+				result.insert(member, score)
+			}
+		}
+	}
+	return result, nil
 }
 
-func (s *skipList) Get(key string, value string) *string {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-	if !s.hasmap[key][value].exists {
-		return nil
+// Combine returns the result of op over keys ordered by score
+func (s *sortedSetStore) Combine(op string, keys []string, opts ZCombineOptions) ([]ZMember, error) {
+	s.ks.mu.RLock()
+	defer s.ks.mu.RUnlock()
+	result, err := s.combine(op, keys, opts)
+	if err != nil {
+		return nil, err
+	}
+	members := []ZMember{}
+	for _, node := range result.zsl.between(1, result.zsl.length, false) {
+		members = append(members, ZMember{Member: node.value, Score: node.score})
 	}
-	val := fmt.Sprintf("%v", s.hasmap[key][value].score)
-	return &val
+	return members, nil
 }
 
-func (s *skipList) Remove(key string, value string) int {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	if !s.hasmap[key][value].exists {
-		return 0
+// CombineStore replaces whatever dest holds with the result of op and
+// returns its members ordered by score, dest is deleted when there is none
+func (s *sortedSetStore) CombineStore(op string, dest string, keys []string, opts ZCombineOptions) ([]ZMember, error) {
+	s.ks.mu.Lock()
+	defer s.ks.mu.Unlock()
+	result, err := s.combine(op, keys, opts)
+	if err != nil {
+		return nil, err
 	}
-	delete(s.hasmap[key], value)
+	s.ks.remove(dest)
+	members := []ZMember{}
+	if len(result.dict) > 0 {
+		s.ks.set(dest, ZSET_TYPE, result, nil)
+		s.ks.signalReady(dest)
+		for _, node := range result.zsl.between(1, result.zsl.length, false) {
+			members = append(members, ZMember{Member: node.value, Score: node.score})
+		}
+	}
+	return members, nil
+}
 
-	// Remove from linked list
-	current := s.roots[key]
-	var prev *SetNode
-	for {
-		if current.value == value || current.next == nil {
-			break
+// InterCard returns the size of the intersection, counting at most limit
+// members unless limit is 0
+func (s *sortedSetStore) InterCard(keys []string, limit int) (int, error) {
+	s.ks.mu.RLock()
+	defer s.ks.mu.RUnlock()
+	all, err := s.scoresAll(keys)
+	if err != nil {
+		return 0, err
+	}
+	slices.SortFunc(all, func(a, b map[string]float64) int {
+		return len(a) - len(b)
+	})
+	count := 0
+	for member := range all[0] {
+		if !slices.ContainsFunc(all[1:], func(scores map[string]float64) bool {
+			_, exists := scores[member]
+			return !exists
+		}) {
+			if count++; count == limit {
+				break
+			}
 		}
-		prev = current
-		current = current.next
 	}
-	if prev == nil {
-		s.roots[key] = current.next
-	} else {
-		prev.next = current.next
+	return count, nil
+}
+
+// Count returns the number of members with a score within r
+func (s *sortedSetStore) Count(key string, r ScoreRange) (int, error) {
+	s.ks.mu.RLock()
+	defer s.ks.mu.RUnlock()
+	set, err := s.lookup(key)
+	if set == nil || err != nil {
+		return 0, err
+	}
+	first, last := set.zsl.ranks(r)
+	return max(last-first+1, 0), nil
+}
+
+// LexCount returns the number of members within r
+func (s *sortedSetStore) LexCount(key string, r LexRange) (int, error) {
+	s.ks.mu.RLock()
+	defer s.ks.mu.RUnlock()
+	set, err := s.lookup(key)
+	if set == nil || err != nil {
+		return 0, err
+	}
+	first, last := set.zsl.ranks(r)
+	return max(last-first+1, 0), nil
+}
+
+func (s *sortedSetStore) Cardinality(key string) (int, error) {
+	s.ks.mu.RLock()
+	defer s.ks.mu.RUnlock()
+	set, err := s.lookup(key)
+	if set == nil || err != nil {
+		return 0, err
 	}
-	return 1
+	return len(set.dict), nil
+}
+
+func (s *sortedSetStore) Get(key string, value string) (*string, error) {
+	s.ks.mu.RLock()
+	defer s.ks.mu.RUnlock()
+	set, err := s.lookup(key)
+	if set == nil || err != nil {
+		return nil, err
+	}
+	score, exists := set.dict[value]
+	if !exists {
+		return nil, nil
+	}
+	val := formatScore(score)
+	return &val, nil
+}
+
+func (s *sortedSetStore) Remove(key string, value string) (int, error) {
+	s.ks.mu.Lock()
+	defer s.ks.mu.Unlock()
+	set, err := s.lookup(key)
+	if set == nil || err != nil {
+		return 0, err
+	}
+	if !set.delete(value) {
+		return 0, nil
+	}
+	if len(set.dict) == 0 {
+		s.ks.remove(key)
+	}
+	return 1, nil
+}
+
+// Scan returns member, score pairs
+func (s *sortedSetStore) Scan(key string, cursor uint64, opts ScanOptions) (uint64, []string, error) {
+	s.ks.mu.RLock()
+	defer s.ks.mu.RUnlock()
+	elems := []string{}
+	set, err := s.lookup(key)
+	if set == nil || err != nil {
+		return 0, elems, err
+	}
+	next := set.index.scan(cursor, opts.Count, func(member string) {
+		if opts.matches(member) {
+			elems = append(elems, member, formatScore(set.dict[member]))
+		}
+	})
+	return next, elems, nil
 }
@@ -0,0 +1,168 @@
+package credis
+
+import (
+	"cmp"
+	"math"
+	"math/rand"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// Members held by the skiplist the benchmarks run against
+const benchZSetSize = 1_000_000
+
+// newBenchSkipList returns a skiplist holding size members, named "m<i>"
+// with random scores, along with the scores by member index
+func newBenchSkipList(size int) (*skipList, []string, []float64) {
+	zsl := newSkipList()
+	names := make([]string, size)
+	scores := make([]float64, size)
+	for i := range size {
+		names[i] = "m" + strconv.Itoa(i)
+		scores[i] = rand.Float64()
+		zsl.insert(scores[i], names[i])
+	}
+	return zsl, names, scores
+}
+
+func BenchmarkZAdd(b *testing.B) {
+	zsl, _, _ := newBenchSkipList(benchZSetSize)
+	b.ResetTimer()
+	for i := range b.N {
+		zsl.insert(rand.Float64(), "n"+strconv.Itoa(i))
+	}
+}
+
+func BenchmarkZRank(b *testing.B) {
+	zsl, names, scores := newBenchSkipList(benchZSetSize)
+	b.ResetTimer()
+	for range b.N {
+		i := rand.Intn(benchZSetSize)
+		zsl.rank(scores[i], names[i])
+	}
+}
+
+// 10 members from a random rank, as ZRANGE does
+func BenchmarkZRange(b *testing.B) {
+	zsl, _, _ := newBenchSkipList(benchZSetSize)
+	b.ResetTimer()
+	for range b.N {
+		node := zsl.byRank(rand.Intn(benchZSetSize-10) + 1)
+		for range 10 {
+			node = node.level[0].forward
+		}
+	}
+}
+
+func BenchmarkZRem(b *testing.B) {
+	zsl, names, scores := newBenchSkipList(benchZSetSize)
+	b.ResetTimer()
+	for i := range b.N {
+		// Removed members are added back so the skiplist keeps its size
+		i %= benchZSetSize
+		zsl.delete(scores[i], names[i])
+		b.StopTimer()
+		zsl.insert(scores[i], names[i])
+		b.StartTimer()
+	}
+}
+
+// checkSkipList compares every rank of zsl with the members of want, which
+// must be in order
+func checkSkipList(t *testing.T, zsl *skipList, want []ZMember) {
+	t.Helper()
+	if zsl.length != len(want) {
+		t.Fatalf("length = %d, want %d", zsl.length, len(want))
+	}
+	for i, m := range want {
+		if rank := zsl.rank(m.Score, m.Member); rank != i+1 {
+			t.Fatalf("rank(%v, %q) = %d, want %d", m.Score, m.Member, rank, i+1)
+		}
+		node := zsl.byRank(i + 1)
+		if node == nil || node.value != m.Member || node.score != m.Score {
+			t.Fatalf("byRank(%d) = %+v, want %+v", i+1, node, m)
+		}
+	}
+	if node := zsl.byRank(len(want) + 1); node != nil {
+		t.Fatalf("byRank(%d) = %+v past the end", len(want)+1, node)
+	}
+}
+
+func TestSkipListRank(t *testing.T) {
+	zsl := newSkipList()
+	scores := map[string]float64{}
+	model := func() []ZMember {
+		members := make([]ZMember, 0, len(scores))
+		for member, score := range scores {
+			members = append(members, ZMember{member, score})
+		}
+		slices.SortFunc(members, func(a, b ZMember) int {
+			if c := cmp.Compare(a.Score, b.Score); c != 0 {
+				return c
+			}
+			return strings.Compare(a.Member, b.Member)
+		})
+		return members
+	}
+	for i := range 2000 {
+		// Few distinct scores so that ties are ordered by member
+		member, score := "m"+strconv.Itoa(rand.Intn(500)), float64(rand.Intn(20))
+		current, exists := scores[member]
+		switch {
+		case !exists:
+			zsl.insert(score, member)
+			scores[member] = score
+		case i%2 == 0:
+			zsl.updateScore(current, member, score)
+			scores[member] = score
+		default:
+			if !zsl.delete(current, member) {
+				t.Fatalf("delete(%v, %q) found nothing", current, member)
+			}
+			delete(scores, member)
+		}
+	}
+	checkSkipList(t, zsl, model())
+	if rank := zsl.rank(100, "missing"); rank != 0 {
+		t.Fatalf("rank of a missing member = %d, want 0", rank)
+	}
+}
+
+func TestSortedSetRange(t *testing.T) {
+	set := newSortedSet()
+	for _, m := range []ZMember{{"e", 5}, {"a", 1}, {"d", 3}, {"b", 2}, {"c", 3}} {
+		set.insert(m.Member, m.Score)
+	}
+	all := ScoreRange{min: math.Inf(-1), max: math.Inf(1)}
+	tests := []struct {
+		name string
+		opts ZRangeOptions
+		want string
+	}{
+		{"all ranks", ZRangeOptions{By: ZRANGE_BY_RANK, Start: 0, Stop: -1}, "abcde"},
+		{"negative ranks", ZRangeOptions{By: ZRANGE_BY_RANK, Start: -2, Stop: -1}, "de"},
+		{"stop past the end", ZRangeOptions{By: ZRANGE_BY_RANK, Start: 3, Stop: 100}, "de"},
+		{"start past stop", ZRangeOptions{By: ZRANGE_BY_RANK, Start: 3, Stop: 1}, ""},
+		{"start past the end", ZRangeOptions{By: ZRANGE_BY_RANK, Start: 5, Stop: 10}, ""},
+		{"rev ranks", ZRangeOptions{By: ZRANGE_BY_RANK, Start: 0, Stop: 1, Rev: true}, "ed"},
+		{"scores", ZRangeOptions{By: ZRANGE_BY_SCORE, Scores: ScoreRange{min: 2, max: 3}, Count: -1}, "bcd"},
+		{"exclusive scores", ZRangeOptions{By: ZRANGE_BY_SCORE, Scores: ScoreRange{min: 1, max: 3, minExclusive: true, maxExclusive: true}, Count: -1}, "b"},
+		{"scores out of range", ZRangeOptions{By: ZRANGE_BY_SCORE, Scores: ScoreRange{min: 6, max: 10}, Count: -1}, ""},
+		{"scores limit", ZRangeOptions{By: ZRANGE_BY_SCORE, Scores: all, Offset: 1, Count: 2}, "bc"},
+		{"scores offset past the end", ZRangeOptions{By: ZRANGE_BY_SCORE, Scores: all, Offset: 5, Count: -1}, ""},
+		{"rev scores limit", ZRangeOptions{By: ZRANGE_BY_SCORE, Scores: all, Rev: true, Offset: 1, Count: 3}, "dcb"},
+		{"lex", ZRangeOptions{By: ZRANGE_BY_LEX, Lex: LexRange{min: lexBound{value: "b"}, max: lexBound{value: "d", exclusive: true}}, Count: -1}, "bc"},
+		{"lex infinities", ZRangeOptions{By: ZRANGE_BY_LEX, Lex: LexRange{min: lexBound{inf: -1}, max: lexBound{inf: 1}}, Rev: true, Count: 2}, "ed"},
+	}
+	for _, tt := range tests {
+		var got strings.Builder
+		for _, node := range set.rangeOf(tt.opts) {
+			got.WriteString(node.value)
+		}
+		if got.String() != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got.String(), tt.want)
+		}
+	}
+}
@@ -1,23 +1,184 @@
 package credis
 
 import (
+	"cmp"
 	"fmt"
-	"sync"
+	"math"
+	"slices"
 	"time"
 )
 
 type Stream interface {
 	CreateOrUpdateStream(key string, values []KeyValue, opts ...AddStreamOpts) (string, error)
-	IsStreamKey(key string) bool
+	Len(key string) (int, error)
+	LastID(key string) (StreamID, error)
+	Info(key string) (*StreamInfo, error)
+	Range(key string, start StreamID, end StreamID, count int, rev bool) ([]StreamEntry, error)
+	Read(keys []string, cursors []StreamCursor, count int) ([]StreamEntries, error)
+}
+
+// StreamID is the <ms>-<seq> ID of a stream entry
+type StreamID struct {
+	Ms  uint64
+	Seq uint64
+}
+
+// Smallest and largest IDs, what "-" and "+" stand for
+var (
+	MIN_STREAM_ID = StreamID{}
+	MAX_STREAM_ID = StreamID{Ms: math.MaxUint64, Seq: math.MaxUint64}
+)
+
+func (id StreamID) String() string {
+	return fmt.Sprintf("%v-%v", id.Ms, id.Seq)
+}
+
+func (id StreamID) Compare(other StreamID) int {
+	if c := cmp.Compare(id.Ms, other.Ms); c != 0 {
+		return c
+	}
+	return cmp.Compare(id.Seq, other.Seq)
+}
+
+// Next returns the ID right after id, ok is false for MAX_STREAM_ID
+func (id StreamID) Next() (next StreamID, ok bool) {
+	switch {
+	case id == MAX_STREAM_ID:
+		return id, false
+	case id.Seq == math.MaxUint64:
+		return StreamID{Ms: id.Ms + 1}, true
+	}
+	return StreamID{Ms: id.Ms, Seq: id.Seq + 1}, true
+}
+
+// Prev returns the ID right before id, ok is false for MIN_STREAM_ID
+func (id StreamID) Prev() (prev StreamID, ok bool) {
+	switch {
+	case id == MIN_STREAM_ID:
+		return id, false
+	case id.Seq == 0:
+		return StreamID{Ms: id.Ms - 1, Seq: math.MaxUint64}, true
+	}
+	return StreamID{Ms: id.Ms, Seq: id.Seq - 1}, true
+}
+
+type StreamEntry struct {
+	ID     StreamID
+	Fields []KeyValue
+}
+
+// StreamCursor is where XREAD reads a stream from: the entries after ID, the
+// ones added from now on with New ("$") or the last one with Last ("+")
+type StreamCursor struct {
+	ID   StreamID
+	New  bool
+	Last bool
+}
+
+// StreamEntries are the entries read from the stream at Key
+type StreamEntries struct {
+	Key     string
+	Entries []StreamEntry
+}
+
+// StreamInfo is what XINFO STREAM reports of a stream
+type StreamInfo struct {
+	Length int
+	// ID of the last entry added, new entries must have a larger one
+	LastGeneratedID StreamID
+	// Largest ID of the entries deleted from the stream
+	MaxDeletedID StreamID
+	// Number of entries ever added to the stream
+	EntriesAdded uint64
+	// First and last entries, nil when the stream is empty
+	FirstEntry *StreamEntry
+	LastEntry  *StreamEntry
+}
+
+// stream keeps its entries ordered by ID, entries being only ever appended
+// with a larger ID than the last one generated for the stream
+type stream struct {
+	entries []StreamEntry
+	last    StreamID
+	// Number of entries ever added
+	added uint64
+}
+
+func newStream() *stream {
+	return &stream{}
+}
+
+func (strm *stream) lastID() StreamID {
+	return strm.last
+}
+
+// nextID returns the ID of an entry added to the stream, generated from ms
+// when it is given without a sequence or from the clock without ms
+func (strm *stream) nextID(opts addStreamOpts) (StreamID, error) {
+	if opts.ms == nil {
+		ms := uint64(time.Now().UnixMilli())
+		if ms > strm.last.Ms {
+			return StreamID{Ms: ms}, nil
+		}
+		// The clock went backwards or several entries were added within the
+		// same millisecond, go on from the last ID
+		next, ok := strm.last.Next()
+		if !ok {
+			return next, &ErrStreamExhausted{}
+		}
+		return next, nil
+	}
+	id := StreamID{Ms: *opts.ms}
+	switch {
+	case opts.seq != nil:
+		id.Seq = *opts.seq
+		if id == MIN_STREAM_ID {
+			return id, &ErrInvalidStreamId{}
+		}
+	case id.Ms == strm.last.Ms:
+		// Also the case of a 0 ms on an empty stream, which gets 0-1
+		if strm.last.Seq == math.MaxUint64 {
+			return id, &ErrIdLessThenStreamTop{}
+		}
+		id.Seq = strm.last.Seq + 1
+	}
+	if id.Compare(strm.last) <= 0 {
+		return id, &ErrIdLessThenStreamTop{}
+	}
+	return id, nil
+}
+
+// between returns up to count entries with an ID within start and end, from
+// the largest ID when rev is set. A negative count means no limit
+func (strm *stream) between(start StreamID, end StreamID, count int, rev bool) []StreamEntry {
+	entries := []StreamEntry{}
+	if start.Compare(end) > 0 || count == 0 {
+		return entries
+	}
+	first, _ := slices.BinarySearchFunc(strm.entries, start, func(entry StreamEntry, id StreamID) int {
+		return entry.ID.Compare(id)
+	})
+	last, found := slices.BinarySearchFunc(strm.entries, end, func(entry StreamEntry, id StreamID) int {
+		return entry.ID.Compare(id)
+	})
+	if found {
+		last++
+	}
+	selected := strm.entries[first:last]
+	if rev {
+		for i := len(selected) - 1; i >= 0 && len(entries) != count; i-- {
+			entries = append(entries, selected[i])
+		}
+		return entries
+	}
+	if count > 0 && count < len(selected) {
+		selected = selected[:count]
+	}
+	return append(entries, selected...)
 }
 
 type streamStore struct {
-	mu        sync.RWMutex
-	store     map[string]map[int]map[int][]KeyValue
-	ids       map[string][]int
-	sequences map[string]map[int][]int
-	lastId    int
-	lastSeq   int
+	ks *keyspace
 }
 
 type KeyValue struct {
@@ -26,114 +187,173 @@ type KeyValue struct {
 	Exists bool
 }
 
-func NewStream() Stream {
+func NewStream(ks *keyspace) Stream {
 	return &streamStore{
-		store:     make(map[string]map[int]map[int][]KeyValue),
-		sequences: make(map[string]map[int][]int),
-		ids:       make(map[string][]int),
-		// store: map[string]map[string][]KeyValue{
-		// 	"123132121212": {
-		// 		"1": []KeyValue{
-		// 			{"", ""},
-		// 		},
-		// 	},
-		// },
-		// ids: []string{
-		// 	"123132121212",
-		// },
-		// sequences: map[string][]string{
-		// 	"123132121212": {
-		// 		"1",
-		// 	},
-		// },
+		ks: ks,
 	}
 }
 
 type addStreamOpts struct {
-	id  *int
-	seq *int
+	ms  *uint64
+	seq *uint64
 }
 
 type AddStreamOpts func(opts *addStreamOpts)
 
-func WithPredefinedId(id int) AddStreamOpts {
+func WithPredefinedId(ms uint64) AddStreamOpts {
 	return func(opts *addStreamOpts) {
-		opts.id = &id
+		opts.ms = &ms
 	}
 }
 
-func WithPredefinedIdAndSequence(id int, seq int) AddStreamOpts {
+func WithPredefinedIdAndSequence(ms uint64, seq uint64) AddStreamOpts {
 	return func(opts *addStreamOpts) {
-		opts.id = &id
+		opts.ms = &ms
 		opts.seq = &seq
 	}
 }
 
 func (s *streamStore) CreateOrUpdateStream(key string, values []KeyValue, opts ...AddStreamOpts) (string, error) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	s.ks.mu.Lock()
+	defer s.ks.mu.Unlock()
+	val, err := s.ks.lookupType(key, STREAM_TYPE, time.Now())
+	if err != nil {
+		return "", err
+	}
 	options := addStreamOpts{}
 	for _, opt := range opts {
 		opt(&options)
 	}
-	var id int
-	var seq int
-	if options.id != nil {
-		id = *options.id
-	} else {
-		// TODO: Generate new Id
-		if s.lastId == 0 && s.lastSeq == 0 {
-			id = int(time.Now().UnixMilli())
-		} else {
-			id = s.lastId + 1
-		}
+	strm := newStream()
+	if val != nil {
+		strm = val.data.(*stream)
 	}
-	if options.seq != nil {
-		seq = *options.seq
-	} else {
-		if id == s.lastId {
-			seq = s.lastSeq + 1
-		}
+	id, err := strm.nextID(options)
+	if err != nil {
+		return "", err
+	}
+
+	// Add or update stream
+	if val == nil {
+		s.ks.set(key, STREAM_TYPE, strm, nil)
+	}
+	entry := StreamEntry{
+		ID: id,
+	}
+	for _, kv := range values {
+		entry.Fields = append(entry.Fields, KeyValue{
+			Key:   kv.Key,
+			Value: kv.Value,
+		})
 	}
-	if id == 0 && seq == 0 {
-		return "", &ErrInvalidStreamId{}
+	strm.entries = append(strm.entries, entry)
+	strm.last = id
+	strm.added++
+	s.ks.signalReady(key)
+	return entry.ID.String(), nil
+}
+
+// lookup returns the stream at key, nil when key does not exist. Caller
+// must hold the lock
+func (s *streamStore) lookup(key string) (*stream, error) {
+	val, err := s.ks.lookupType(key, STREAM_TYPE, time.Now())
+	if val == nil || err != nil {
+		return nil, err
 	}
+	return val.data.(*stream), nil
+}
 
-	if id < s.lastId {
-		// TODO: Validate
-		return "", &ErrIdLessThenStreamTop{}
+func (s *streamStore) Len(key string) (int, error) {
+	s.ks.mu.RLock()
+	defer s.ks.mu.RUnlock()
+	strm, err := s.lookup(key)
+	if strm == nil || err != nil {
+		return 0, err
 	}
+	return len(strm.entries), nil
+}
 
-	if s.lastId == id && s.lastSeq >= seq {
-		return "", &ErrIdLessThenStreamTop{}
+// LastID returns the ID of the last entry of the stream at key, 0-0 when it
+// has none
+func (s *streamStore) LastID(key string) (StreamID, error) {
+	s.ks.mu.RLock()
+	defer s.ks.mu.RUnlock()
+	strm, err := s.lookup(key)
+	if strm == nil || err != nil {
+		return MIN_STREAM_ID, err
 	}
+	return strm.lastID(), nil
+}
 
-	// Add or update stream
-	s.ids[key] = append(s.ids[key], id)
-	if s.sequences[key] == nil {
-		s.sequences[key] = make(map[int][]int)
+// Info returns what XINFO STREAM reports of the stream at key, nil when key
+// does not exist
+func (s *streamStore) Info(key string) (*StreamInfo, error) {
+	s.ks.mu.RLock()
+	defer s.ks.mu.RUnlock()
+	strm, err := s.lookup(key)
+	if strm == nil || err != nil {
+		return nil, err
 	}
-	s.sequences[key][id] = append(s.sequences[key][id], seq)
-	if s.store[key] == nil {
-		s.store[key] = make(map[int]map[int][]KeyValue)
+	info := &StreamInfo{
+		Length:          len(strm.entries),
+		LastGeneratedID: strm.last,
+		EntriesAdded:    strm.added,
 	}
-	if s.store[key][id] == nil {
-		s.store[key][id] = make(map[int][]KeyValue)
+	if len(strm.entries) > 0 {
+		info.FirstEntry = &strm.entries[0]
+		info.LastEntry = &strm.entries[len(strm.entries)-1]
 	}
-	for _, kv := range values {
-		s.store[key][id][seq] = append(s.store[key][id][seq], KeyValue{
-			Key:   kv.Key,
-			Value: kv.Value,
-		})
+	return info, nil
+}
+
+// Range returns up to count entries with an ID within start and end, from
+// the largest ID when rev is set. A negative count means no limit
+func (s *streamStore) Range(key string, start StreamID, end StreamID, count int, rev bool) ([]StreamEntry, error) {
+	s.ks.mu.RLock()
+	defer s.ks.mu.RUnlock()
+	strm, err := s.lookup(key)
+	if strm == nil || err != nil {
+		return []StreamEntry{}, err
 	}
-	s.lastId = id
-	s.lastSeq = seq
-	return fmt.Sprintf("%v-%v", id, seq), nil
+	return strm.between(start, end, count, rev), nil
 }
 
-func (s *streamStore) IsStreamKey(key string) bool {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-	streams := s.ids[key]
-	return len(streams) > 0
+// Read returns up to count entries from each stream at keys, from the
+// matching cursor. A count of 0 means no limit. Only streams with entries to
+// read are returned, every key being checked to hold a stream first
+func (s *streamStore) Read(keys []string, cursors []StreamCursor, count int) ([]StreamEntries, error) {
+	s.ks.mu.RLock()
+	defer s.ks.mu.RUnlock()
+	streams := make([]*stream, len(keys))
+	for i, key := range keys {
+		strm, err := s.lookup(key)
+		if err != nil {
+			return nil, err
+		}
+		streams[i] = strm
+	}
+	if count == 0 {
+		count = -1
+	}
+	read := []StreamEntries{}
+	for i, strm := range streams {
+		if strm == nil || len(strm.entries) == 0 {
+			continue
+		}
+		var entries []StreamEntry
+		switch cursor := cursors[i]; {
+		case cursor.Last:
+			entries = strm.entries[len(strm.entries)-1:]
+		case cursor.New:
+			// Nothing was added since the read started
+		default:
+			if start, ok := cursor.ID.Next(); ok {
+				entries = strm.between(start, MAX_STREAM_ID, count, false)
+			}
+		}
+		if len(entries) > 0 {
+			read = append(read, StreamEntries{Key: keys[i], Entries: entries})
+		}
+	}
+	return read, nil
 }
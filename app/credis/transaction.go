@@ -40,36 +40,28 @@ func (tx *TX) Exec(client Client, ctx context.Context) []byte {
 	if !tx.multi {
 		data = enc.SimpleError((&ErrExecWithoutMulti{}).Error())
 	} else {
-		for _, c := range tx.txs.Get(0, int64(tx.txs.length)) {
-			var key string
-			switch c.Specs().String() {
-			case SET:
-				spec := c.Specs().(*SETSpecs)
-				key = spec.Key
-			case INCR:
-				spec := c.Specs().(*INCRSpecs)
-				key = spec.Key
-			}
-			if key != "" && client.IsDirty() {
-				tx.txs = LinkedList[Request]{}
-				tx.multi = false
-				client.TerminateWatcher()
-				return enc.NullArray()
-			}
+		if client.IsDirty() {
+			tx.txs = LinkedList[Request]{}
+			tx.multi = false
+			client.TerminateWatcher()
+			return enc.NullArray()
 		}
+		queued := []Request{}
 		for {
 			r := tx.txs.Remove(0)
 			if r == nil {
 				break
 			}
-			executor := client.Executor()
 			relayReq := NewRequest(
 				client,
 				(*r).Ctx(),
 			)
 			relayReq.SetSpecs((*r).Specs())
-			out := executor.Exec(relayReq).Data()
-			responses = append(responses, out)
+			relayReq.SetArgs((*r).Args()...)
+			queued = append(queued, relayReq)
+		}
+		for _, res := range client.Srv().Hub().ExecQueued(queued) {
+			responses = append(responses, res.Data())
 		}
 		data = enc.ArrayRaw(responses)
 	}
@@ -46,11 +46,12 @@ func main() {
 	}
 	h := credis.NewHub()
 	srv := credis.New(h, opts...)
+	exec := credis.NewExec(srv.Store(), srv.Info(), srv.RDB())
+	// Started before anything reaches the hub, replicated commands included
+	h.Start(exec, srv)
 	if isSlave {
 		go srv.StartReplica()
 	}
-	exec := credis.NewExec(srv.Store(), srv.Info(), srv.RDB())
-	go h.Start(exec, srv)
 	err := srv.StartMaster()
 	if err != nil {
 		fmt.Println(err)